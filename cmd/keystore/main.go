@@ -0,0 +1,189 @@
+// cmd/keystore/main.go
+//
+// keystore manages the encrypted seed file the server loads with
+// KEYSTORE_PATH. The password is read from KEYSTORE_PASSWORD_FILE.
+//
+// Create a keystore from a new mnemonic, or import one from stdin with -import:
+//
+//	KEYSTORE_PASSWORD_FILE=pw.txt go run ./cmd/keystore -out wallet.json -words 24
+//
+// Split its seed into Shamir shares, one per line, any 2 of which restore it:
+//
+//	KEYSTORE_PASSWORD_FILE=pw.txt go run ./cmd/keystore backup -in wallet.json -threshold 2 -shares 3
+//
+// Rebuild a keystore from shares read from stdin:
+//
+//	KEYSTORE_PASSWORD_FILE=pw.txt go run ./cmd/keystore restore -out wallet.json < shares.txt
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"andi-custodian/internal/keystore"
+	"andi-custodian/internal/wallet"
+)
+
+func main() {
+	args := os.Args[1:]
+	cmd := "create"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+	switch cmd {
+	case "create":
+		create(args)
+	case "backup":
+		backup(args)
+	case "restore":
+		restore(args)
+	default:
+		log.Fatalf("unknown command %q; want create, backup or restore", cmd)
+	}
+}
+
+// create writes a keystore for a generated or imported mnemonic.
+func create(args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	out := fs.String("out", "keystore.json", "keystore file to write")
+	kdf := fs.String("kdf", keystore.KDFArgon2id, "password hash: argon2id or scrypt")
+	words := fs.Int("words", 24, "mnemonic length when generating")
+	importMnemonic := fs.Bool("import", false, "read an existing mnemonic from stdin instead of generating one")
+	fs.Parse(args)
+
+	// MNEMONIC_PASSPHRASE selects the BIP-39 passphrase wallet to store
+	passphrase := os.Getenv("MNEMONIC_PASSPHRASE")
+	var seed []byte
+	var err error
+	if *importMnemonic {
+		mnemonic, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && mnemonic == "" {
+			log.Fatalf("read mnemonic: %v", err)
+		}
+		if seed, err = wallet.NewSeed(mnemonic, passphrase); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		var mnemonic string
+		if mnemonic, seed, err = wallet.GenerateMnemonic(*words, passphrase); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintln(os.Stderr, "Write down this mnemonic; it is shown once:")
+		fmt.Println(mnemonic)
+	}
+	defer clear(seed)
+	save(*out, *kdf, seed)
+}
+
+// backup prints Shamir shares of a keystore's seed, one per line.
+func backup(args []string) {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	in := fs.String("in", "keystore.json", "keystore file to back up")
+	threshold := fs.Int("threshold", 2, "shares needed to restore")
+	total := fs.Int("shares", 3, "shares to create")
+	fs.Parse(args)
+
+	ks, err := keystore.Open(*in)
+	if err != nil {
+		log.Fatalf("open keystore: %v", err)
+	}
+	password := readPassword()
+	err = ks.Unlock(password, 0)
+	clear(password)
+	if err != nil {
+		log.Fatalf("unlock keystore: %v", err)
+	}
+	defer ks.Lock()
+
+	var tk *wallet.ThresholdKey
+	err = ks.WithSeed(func(seed []byte) error {
+		tk, err = wallet.NewThresholdKeyFromSeed(seed, wallet.ThresholdPolicy{Threshold: *threshold, Total: *total})
+		return err
+	})
+	if err != nil {
+		log.Fatalf("split seed: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Store each line separately; any %d of these %d shares restore wallet %s:\n",
+		*threshold, *total, ks.Fingerprint())
+	for _, s := range tk.Shares {
+		fmt.Println(s)
+	}
+}
+
+// restore rebuilds a keystore from shares read from stdin, one per line.
+func restore(args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	out := fs.String("out", "keystore.json", "keystore file to write")
+	kdf := fs.String("kdf", keystore.KDFArgon2id, "password hash: argon2id or scrypt")
+	fs.Parse(args)
+
+	var shares []*wallet.Share
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		s, err := wallet.ParseShare(text)
+		if err != nil {
+			log.Fatalf("line %d: %v", line, err)
+		}
+		shares = append(shares, s)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("read shares: %v", err)
+	}
+	seed, err := wallet.RestoreSeed(shares)
+	if err != nil {
+		log.Fatalf("restore seed: %v", err)
+	}
+	defer clear(seed)
+	save(*out, *kdf, seed)
+}
+
+// save seals seed under the keystore password and writes it to path.
+func save(path, kdf string, seed []byte) {
+	params := keystore.Argon2id()
+	switch kdf {
+	case keystore.KDFArgon2id:
+	case keystore.KDFScrypt:
+		params = keystore.Scrypt()
+	default:
+		log.Fatalf("unknown kdf %q", kdf)
+	}
+	password := readPassword()
+	defer clear(password)
+
+	f, err := keystore.Encrypt(seed, password, params)
+	if err != nil {
+		log.Fatalf("encrypt seed: %v", err)
+	}
+	if err := keystore.Save(path, f); err != nil {
+		log.Fatalf("write keystore: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s (fingerprint %s)\n", path, f.Fingerprint)
+}
+
+// readPassword returns the keystore password without its trailing newline.
+// The caller clears it.
+func readPassword() []byte {
+	passwordFile := os.Getenv("KEYSTORE_PASSWORD_FILE")
+	if passwordFile == "" {
+		log.Fatal("KEYSTORE_PASSWORD_FILE environment variable is required")
+	}
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		log.Fatalf("read password: %v", err)
+	}
+	password = bytes.TrimRight(password, "\r\n")
+	if len(password) == 0 {
+		log.Fatal("keystore password is empty")
+	}
+	return password
+}
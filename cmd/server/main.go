@@ -2,17 +2,27 @@
 package main
 
 import (
-	"andi-custodian/internal/chain"
 	"andi-custodian/internal/wallet"
+	"bytes"
 	"context"
-	"github.com/tyler-smith/go-bip39"
+	"errors"
+	"fmt"
 	"log"
 	"net"
+	"os"
+	"strings"
+	"time"
 
 	pb "andi-custodian/api/custody/v1"
+	"andi-custodian/internal/chain"
 	"andi-custodian/internal/custody"
+	"andi-custodian/internal/keystore"
 	"andi-custodian/internal/store"
+	"andi-custodian/pkg/chains"
+	"andi-custodian/pkg/networks"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type server struct {
@@ -21,30 +31,221 @@ type server struct {
 }
 
 func (s *server) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
+	c, err := chains.Parse(req.Chain)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	result, err := s.service.Transfer(ctx, &custody.TransferRequest{
 		ID:    req.Id,
-		Chain: chain.Chain(req.Chain),
+		Chain: c,
 		From:  req.From,
 		To:    req.To,
 		Value: req.Value,
 	})
 	if err != nil {
-		return nil, err
+		return nil, transferError(err)
 	}
 	return &pb.TransferResponse{
 		TxId:   result.TxID,
 		Status: result.Status,
 	}, nil
 }
-func main() {
-	// Use a fixed mnemonic for deterministic demo behavior
+
+// transferError gives a transfer error the gRPC code a client can act on: a
+// reused request ID is not retried, an in-progress one is retried later.
+func transferError(err error) error {
+	code := codes.Unknown
+	switch {
+	case errors.Is(err, custody.ErrIdempotencyConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, custody.ErrTransferInProgress):
+		code = codes.Aborted
+	case errors.Is(err, custody.ErrTransferFailed):
+		code = codes.FailedPrecondition
+	case errors.Is(err, keystore.ErrLocked):
+		// Nothing unlocks a running server's keystore but a restart
+		return status.Error(codes.Unavailable, "signer unavailable: the keystore locked itself after KEYSTORE_AUTOLOCK without use; restart the server to unlock it")
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, err.Error())
+}
+
+// nodeClients connects each chain to its node when an RPC endpoint is configured.
+// EVM networks use the first RPC URL from the networks registry unless an
+// environment variable overrides it. Chains without one run in simulation mode.
+func nodeClients() []custody.Option {
+	evmURLs := map[string]string{}
+	for _, n := range networks.Default().All() {
+		if len(n.RPCURLs) > 0 {
+			evmURLs[n.Name] = n.RPCURLs[0]
+		}
+	}
+	if url := os.Getenv("SEPOLIA_RPC_URL"); url != "" {
+		evmURLs[string(chains.EthereumSepolia)] = url
+	}
+	if url := os.Getenv("FUJI_RPC_URL"); url != "" {
+		evmURLs[string(chains.AvalancheFuji)] = url
+	}
+
+	var opts []custody.Option
+	for name, url := range evmURLs {
+		opts = append(opts, custody.WithClient(chains.Chain(name), chain.NewEthereumRPCClient(url, nil)))
+	}
+	if url := os.Getenv("BITCOIN_RPC_URL"); url != "" {
+		client := chain.NewBitcoinRPCClient(url, os.Getenv("BITCOIN_RPC_USER"), os.Getenv("BITCOIN_RPC_PASS"), nil)
+		opts = append(opts, custody.WithClient(bitcoinChain(), client))
+	}
+	if url := os.Getenv("SOLANA_RPC_URL"); url != "" {
+		opts = append(opts, custody.WithClient(chain.SolanaDevnet, chain.NewSolanaRPCClient(url, nil)))
+	}
+	return opts
+}
+
+// bitcoinChain is the Bitcoin network the node behind BITCOIN_RPC_URL runs:
+// bitcoin-regtest in CI, bitcoin-signet for staging, bitcoin-mainnet in
+// production. It defaults to bitcoin-testnet.
+func bitcoinChain() chains.Chain {
+	name := os.Getenv("BITCOIN_NETWORK")
+	if name == "" {
+		return chains.BitcoinTestnet
+	}
+	c, err := chains.Parse(name)
+	if err != nil || c.Family() != chains.FamilyUTXO {
+		log.Fatalf("BITCOIN_NETWORK: unknown Bitcoin network %q", name)
+	}
+	return c
+}
+
+// openKeys returns the signer and what deposit addresses are derived from.
+// The seed comes from the encrypted keystore at KEYSTORE_PATH, unlocked with
+// the password in KEYSTORE_PASSWORD_FILE. Only with DEMO_MNEMONIC=1 does the
+// server run without one, on a fixed demo mnemonic that is public in this
+// source, with MNEMONIC_PASSPHRASE selecting the BIP-39 passphrase wallet
+// behind it.
+func openKeys() (wallet.Signer, wallet.AddressDeriver, error) {
+	if path := os.Getenv("KEYSTORE_PATH"); path != "" {
+		return keystoreKeys(path)
+	}
+	if os.Getenv("DEMO_MNEMONIC") != "1" {
+		return nil, nil, errors.New("KEYSTORE_PATH is required; set DEMO_MNEMONIC=1 to run on the public demo mnemonic instead")
+	}
+	log.Println("WARNING: DEMO MODE. Keys come from the demo mnemonic published in this source code;")
+	log.Println("WARNING: anyone can spend from its addresses. Never send real funds to them.")
 	testMnemonic := "slab lonely fish push bomb festival open oval empower federal slot hotel"
-	testSeed := bip39.NewSeed(testMnemonic, "")
+	passphrase := os.Getenv("MNEMONIC_PASSPHRASE")
+	testSeed, err := wallet.NewSeed(testMnemonic, passphrase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	deriver, err := depositWallet(func() (wallet.AddressDeriver, error) {
+		return wallet.NewWallet(testMnemonic, wallet.WithPassphrase(passphrase))
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return wallet.NewSimulatedMPCSigner(testSeed), deriver, nil
+}
+
+// defaultAutoLock is how long the keystore stays unlocked without signing
+// when KEYSTORE_AUTOLOCK is not set.
+const defaultAutoLock = 15 * time.Minute
+
+// keystoreKeys unlocks the keystore at path. It locks itself once no transfer
+// has signed for KEYSTORE_AUTOLOCK (a Go duration, default 15m; 0 never
+// locks), after which signing fails until the server restarts. The signer
+// borrows the seed per signature; deposit addresses are derived from account
+// keys exported once at startup.
+func keystoreKeys(path string) (wallet.Signer, wallet.AddressDeriver, error) {
+	ks, err := keystore.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open keystore: %w", err)
+	}
+	autoLock := defaultAutoLock
+	if v := os.Getenv("KEYSTORE_AUTOLOCK"); v != "" {
+		if autoLock, err = time.ParseDuration(v); err != nil || autoLock < 0 {
+			return nil, nil, fmt.Errorf("KEYSTORE_AUTOLOCK: %q is not a duration such as 15m or 0", v)
+		}
+	}
+	passwordFile := os.Getenv("KEYSTORE_PASSWORD_FILE")
+	if passwordFile == "" {
+		return nil, nil, errors.New("KEYSTORE_PASSWORD_FILE is required with KEYSTORE_PATH")
+	}
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read keystore password: %w", err)
+	}
+	err = ks.Unlock(bytes.TrimRight(password, "\r\n"), autoLock)
+	clear(password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unlock keystore: %w", err)
+	}
+	log.Printf("Unlocked keystore %s (fingerprint %s), auto-lock after %s idle", path, ks.Fingerprint(), autoLock)
+
+	deriver, err := depositWallet(func() (wallet.AddressDeriver, error) {
+		depositChains := []chains.Chain{bitcoinChain()}
+		for _, n := range networks.Default().All() {
+			depositChains = append(depositChains, chains.Chain(n.Name))
+		}
+		// Deposit addresses are issued under account 0
+		return wallet.NewWatchOnlyWalletFrom(ks, 0, depositChains...)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return wallet.NewSimulatedMPCSignerFrom(ks), deriver, nil
+}
+
+// depositWallet returns what deposit addresses are derived from. DEPOSIT_XPUBS
+// lists account keys as chain=xpub pairs separated by commas; when it is set
+// the service derives from those alone and never sees the seed for deposits.
+// Otherwise it uses the deriver fallback returns.
+func depositWallet(fallback func() (wallet.AddressDeriver, error)) (wallet.AddressDeriver, error) {
+	xpubs := os.Getenv("DEPOSIT_XPUBS")
+	if xpubs == "" {
+		return fallback()
+	}
+	watch := wallet.NewWatchOnlyWallet()
+	for _, pair := range strings.Split(xpubs, ",") {
+		name, xpub, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("DEPOSIT_XPUBS: %q is not chain=xpub", pair)
+		}
+		c, err := chains.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("DEPOSIT_XPUBS: %w", err)
+		}
+		if _, err := watch.AddAccount(c, xpub); err != nil {
+			return nil, fmt.Errorf("DEPOSIT_XPUBS: %s: %w", c, err)
+		}
+	}
+	return watch, nil
+}
+
+func main() {
+	// Deployments add networks or RPC endpoints on top of the built-in ones
+	if path := os.Getenv("NETWORKS_CONFIG"); path != "" {
+		if err := networks.LoadFile(path); err != nil {
+			log.Fatalf("failed to load networks: %v", err)
+		}
+	}
 
 	// Initialize dependencies
 	store := store.NewInMemoryStore()
-	signer := wallet.NewSimulatedMPCSigner(testSeed)
-	service := custody.NewService(signer, store)
+	signer, deriver, err := openKeys()
+	if err != nil {
+		log.Fatalf("failed to open keys: %v", err)
+	}
+	opts := append(nodeClients(), custody.WithWallet(deriver))
+	service := custody.NewService(signer, store, opts...)
+	defer service.Close()
+	if n, err := service.ResumeMonitoring(context.Background()); err != nil {
+		log.Fatalf("failed to resume monitoring: %v", err)
+	} else if n > 0 {
+		log.Printf("Resumed monitoring %d transfers", n)
+	}
 
 	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
@@ -6,61 +6,120 @@ import (
 	"errors"
 	"fmt"
 	"github.com/btcsuite/btcd/btcutil"
-	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+
+	"andi-custodian/pkg/networks"
 )
 
+// IsBitcoin reports whether c is a Bitcoin network.
+func IsBitcoin(c Chain) bool {
+	return networks.IsBitcoin(string(c))
+}
+
+// BitcoinNetwork returns the parameters of a Bitcoin network.
+func BitcoinNetwork(c Chain) (networks.BitcoinNetwork, error) {
+	return networks.Bitcoin(string(c))
+}
+
+// decodeBitcoinAddress parses an address of network n. Errors wrap
+// ErrInvalidAddress, and also networks.ErrWrongNetwork when the address is
+// well formed but belongs to another network.
+func decodeBitcoinAddress(n networks.BitcoinNetwork, addr string) (btcutil.Address, error) {
+	decoded, err := n.DecodeAddress(addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAddress, err)
+	}
+	return decoded, nil
+}
+
 // BitcoinBuilder constructs unsigned Bitcoin transactions.
 type BitcoinBuilder struct{}
 
 func (b *BitcoinBuilder) BuildTx(req *TxRequest, opts BuildOptions) (*TxResult, error) {
-	if req.Chain != BitcoinTestnet {
+	network, err := BitcoinNetwork(req.Chain)
+	if err != nil {
 		return nil, errors.New("BitcoinBuilder: invalid chain")
 	}
 
-	// Validate addresses
-	fromAddr, err := btcutil.DecodeAddress(req.From, &chaincfg.TestNet3Params)
+	// Validate addresses; both must belong to the chain's network
+	fromAddr, err := decodeBitcoinAddress(network, req.From)
 	if err != nil {
-		return nil, fmt.Errorf("invalid from address: %w", ErrInvalidAddress)
+		return nil, fmt.Errorf("invalid from address: %w", err)
 	}
-	toAddr, err := btcutil.DecodeAddress(req.To, &chaincfg.TestNet3Params)
+	toAddr, err := decodeBitcoinAddress(network, req.To)
 	if err != nil {
-		return nil, fmt.Errorf("invalid to address: %w", ErrInvalidAddress)
+		return nil, fmt.Errorf("invalid to address: %w", err)
 	}
 
-	// UTXO selection
-	target := req.Value.Int64()
-	selected, _, change, err := b.selectUTXOs(opts.UTXOs, target)
+	// Only native segwit (BIP-84) P2WPKH wallets can be spent from
+	if _, ok := fromAddr.(*btcutil.AddressWitnessPubKeyHash); !ok {
+		return nil, fmt.Errorf("from address must be P2WPKH: %w", ErrInvalidAddress)
+	}
+	fromScript, err := txscript.PayToAddrScript(fromAddr)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.Value == nil || req.Value.Sign() <= 0 || !req.Value.IsInt64() {
+		return nil, fmt.Errorf("value out of range: %w", ErrInvalidAmount)
+	}
+
+	toScript, err := txscript.PayToAddrScript(toAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fee-aware UTXO selection
+	feeRate := opts.FeeRate
+	if feeRate <= 0 {
+		feeRate = DefaultFeeRate
+	}
+	selector := opts.Selector
+	if selector == nil {
+		selector = LargestFirstSelector{}
+	}
+	sel, err := selector.Select(opts.UTXOs, SelectionParams{
+		Target:      req.Value.Int64(),
+		FeeRate:     feeRate,
+		BaseVSize:   txOverheadVSize + OutputVSize(toScript),
+		InputVSize:  p2wpkhInputVSize,
+		ChangeVSize: OutputVSize(fromScript),
+		SubtractFee: opts.SubtractFeeFromAmount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	selected := sel.Inputs
+
 	// Build transaction
 	msgTx := wire.NewMsgTx(wire.TxVersion)
 
-	// Add inputs
-	for _, u := range selected {
+	// Add inputs. Segwit inputs keep an empty scriptSig; the witness is added in Finalize.
+	inputs := make([]UTXO, len(selected))
+	for i, u := range selected {
 		txHash, err := chainhash.NewHashFromStr(u.TxID)
 		if err != nil {
 			return nil, err
 		}
-		outPoint := wire.NewOutPoint(txHash, u.VOut)
-		txIn := wire.NewTxIn(outPoint, nil, nil) // unlocking script will be added during signing
-		msgTx.AddTxIn(txIn)
-	}
+		// The spent script is committed to by the BIP-143 sighash, so it must be the
+		// from address's script. Default it when the UTXO source did not record it.
+		if len(u.PkScript) == 0 {
+			u.PkScript = fromScript
+		} else if !bytes.Equal(u.PkScript, fromScript) {
+			return nil, fmt.Errorf("utxo %s:%d is not owned by %s", u.TxID, u.VOut, req.From)
+		}
+		inputs[i] = u
 
-	// Add outputs
-	toScript, err := txscript.PayToAddrScript(toAddr)
-	if err != nil {
-		return nil, err
+		outPoint := wire.NewOutPoint(txHash, u.VOut)
+		msgTx.AddTxIn(wire.NewTxIn(outPoint, nil, nil))
 	}
-	msgTx.AddTxOut(wire.NewTxOut(target, toScript))
 
-	if change > 0 {
-		changeScript, _ := txscript.PayToAddrScript(fromAddr)
-		msgTx.AddTxOut(wire.NewTxOut(change, changeScript))
+	// Add outputs; change goes back to the from address
+	msgTx.AddTxOut(wire.NewTxOut(sel.Amount, toScript))
+	if sel.Change > 0 {
+		msgTx.AddTxOut(wire.NewTxOut(sel.Change, fromScript))
 	}
 
 	// Serialize unsigned tx
@@ -69,36 +128,79 @@ func (b *BitcoinBuilder) BuildTx(req *TxRequest, opts BuildOptions) (*TxResult,
 		return nil, err
 	}
 
-	// Estimate fee (10 sat/byte)
-	fee := int64(len(buf.Bytes())) * 10
-
 	return &TxResult{
+		Chain:        req.Chain,
 		RawTx:        buf.Bytes(),
-		EstimatedFee: fee,
+		EstimatedFee: sel.Fee,
+		Inputs:       inputs,
 	}, nil
 }
 
-func (b *BitcoinBuilder) selectUTXOs(utxos []UTXO, target int64) ([]UTXO, int64, int64, error) {
-	// Sort descending
-	for i := 0; i < len(utxos); i++ {
-		for j := i + 1; j < len(utxos); j++ {
-			if utxos[i].Value < utxos[j].Value {
-				utxos[i], utxos[j] = utxos[j], utxos[i]
-			}
+// SigHashes returns the BIP-143 witness sighash (SIGHASH_ALL) of every input.
+// Inputs must spend P2WPKH outputs whose script and amount are recorded in tx.Inputs.
+func (b *BitcoinBuilder) SigHashes(tx *TxResult) ([][]byte, error) {
+	msgTx, sigHashes, err := decodeBitcoinTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([][]byte, len(msgTx.TxIn))
+	for i, u := range tx.Inputs {
+		if !txscript.IsPayToWitnessPubKeyHash(u.PkScript) {
+			return nil, fmt.Errorf("input %d: only P2WPKH inputs are supported", i)
 		}
+		hashes[i], err = txscript.CalcWitnessSigHash(u.PkScript, sigHashes, txscript.SigHashAll, msgTx, i, u.Value)
+		if err != nil {
+			return nil, fmt.Errorf("input %d sighash: %w", i, err)
+		}
+	}
+	return hashes, nil
+}
+
+// Finalize populates each input's witness with <DER signature || SIGHASH_ALL> <compressed pubkey>.
+func (b *BitcoinBuilder) Finalize(tx *TxResult, sigs []Signature) (*SignedTx, error) {
+	msgTx, _, err := decodeBitcoinTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(sigs) != len(msgTx.TxIn) {
+		return nil, ErrSignatureCount
 	}
 
-	var selected []UTXO
-	var total int64
-	for _, u := range utxos {
-		if total >= target {
-			break
+	for i, sig := range sigs {
+		if len(sig.PubKey) != 33 {
+			return nil, fmt.Errorf("input %d: compressed public key required", i)
+		}
+		msgTx.TxIn[i].Witness = wire.TxWitness{
+			append(append([]byte{}, sig.Sig...), byte(txscript.SigHashAll)),
+			sig.PubKey,
 		}
-		selected = append(selected, u)
-		total += u.Value
 	}
-	if total < target {
-		return nil, 0, 0, ErrInsufficientFunds
+
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	return &SignedTx{
+		RawTx: buf.Bytes(),
+		TxID:  msgTx.TxHash().String(),
+	}, nil
+}
+
+// decodeBitcoinTx deserializes an unsigned transaction and prepares its BIP-143 midstate.
+func decodeBitcoinTx(tx *TxResult) (*wire.MsgTx, *txscript.TxSigHashes, error) {
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	if err := msgTx.Deserialize(bytes.NewReader(tx.RawTx)); err != nil {
+		return nil, nil, fmt.Errorf("decode bitcoin tx: %w", err)
+	}
+	if len(tx.Inputs) != len(msgTx.TxIn) {
+		return nil, nil, errors.New("spent outputs do not match transaction inputs")
+	}
+
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(tx.Inputs))
+	for i, u := range tx.Inputs {
+		prevOuts[msgTx.TxIn[i].PreviousOutPoint] = wire.NewTxOut(u.Value, u.PkScript)
 	}
-	return selected, total, total - target, nil
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	return msgTx, txscript.NewTxSigHashes(msgTx, fetcher), nil
 }
@@ -0,0 +1,171 @@
+// bitcoin_client.go
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Bitcoin Core RPC error codes the client maps onto chain errors.
+const (
+	btcRPCInvalidAddressOrKey = -5  // unknown transaction
+	btcRPCVerifyError         = -25 // missing or spent inputs
+	btcRPCVerifyRejected      = -26 // policy or consensus rejection
+	btcRPCVerifyAlreadyInUTXO = -27 // already in the chain
+)
+
+// BitcoinRPCClient is a BitcoinClient over Bitcoin Core's JSON-RPC API. UTXO
+// listing uses listunspent, so the node's wallet must watch the addresses
+// (importaddress or a descriptor wallet with the custody xpub).
+type BitcoinRPCClient struct {
+	rpc *rpcClient
+}
+
+var _ BitcoinClient = (*BitcoinRPCClient)(nil)
+
+// NewBitcoinRPCClient returns a client for the node at url, authenticating with
+// the RPC user and password. A nil httpClient uses http.DefaultClient.
+func NewBitcoinRPCClient(url, user, pass string, httpClient *http.Client) *BitcoinRPCClient {
+	rpc := newRPCClient(url, httpClient)
+	rpc.user, rpc.pass = user, pass
+	return &BitcoinRPCClient{rpc: rpc}
+}
+
+func (c *BitcoinRPCClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	utxos, err := c.ListUnspent(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return big.NewInt(sumValues(utxos)), nil
+}
+
+func (c *BitcoinRPCClient) ListUnspent(ctx context.Context, address string) ([]UTXO, error) {
+	var unspent []struct {
+		TxID         string  `json:"txid"`
+		VOut         uint32  `json:"vout"`
+		Amount       float64 `json:"amount"` // BTC
+		ScriptPubKey string  `json:"scriptPubKey"`
+	}
+	if err := c.rpc.call(ctx, &unspent, "listunspent", 1, 9999999, []string{address}); err != nil {
+		return nil, err
+	}
+	utxos := make([]UTXO, 0, len(unspent))
+	for _, u := range unspent {
+		value, err := btcutil.NewAmount(u.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("utxo %s:%d: %w", u.TxID, u.VOut, err)
+		}
+		script, err := hex.DecodeString(u.ScriptPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("utxo %s:%d: invalid script: %w", u.TxID, u.VOut, err)
+		}
+		utxos = append(utxos, UTXO{TxID: u.TxID, VOut: u.VOut, Value: int64(value), PkScript: script})
+	}
+	return utxos, nil
+}
+
+func (c *BitcoinRPCClient) EstimateFeeRate(ctx context.Context, confTarget int) (int64, error) {
+	var est struct {
+		FeeRate *float64 `json:"feerate"` // BTC/kvB
+		Errors  []string `json:"errors"`
+	}
+	if err := c.rpc.call(ctx, &est, "estimatesmartfee", confTarget); err != nil {
+		return 0, err
+	}
+	if est.FeeRate == nil {
+		return 0, fmt.Errorf("estimatesmartfee: no estimate: %v", est.Errors)
+	}
+	perKvB, err := btcutil.NewAmount(*est.FeeRate)
+	if err != nil {
+		return 0, err
+	}
+	rate := (int64(perKvB) + 999) / 1000 // round up to whole sat/vB
+	if rate < 1 {
+		rate = 1
+	}
+	return rate, nil
+}
+
+func (c *BitcoinRPCClient) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	var txID string
+	err := c.rpc.call(ctx, &txID, "sendrawtransaction", hex.EncodeToString(rawTx))
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case btcRPCVerifyAlreadyInUTXO:
+			// Already confirmed: the node answers with an error, not the ID
+			msgTx := wire.NewMsgTx(wire.TxVersion)
+			if err := msgTx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+				return "", err
+			}
+			return msgTx.TxHash().String(), nil
+		case btcRPCVerifyError:
+			return "", fmt.Errorf("%w: %s", ErrInputsSpent, rpcErr.Message)
+		case btcRPCVerifyRejected:
+			return "", fmt.Errorf("%w: %s", ErrTxRejected, rpcErr.Message)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return txID, nil
+}
+
+func (c *BitcoinRPCClient) TxStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	var tx struct {
+		Confirmations uint64 `json:"confirmations"`
+		BlockHash     string `json:"blockhash"`
+	}
+	err := c.rpc.call(ctx, &tx, "getrawtransaction", txID, true)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == btcRPCInvalidAddressOrKey {
+		// Without -txindex the node only finds mempool transactions
+		return c.walletTxStatus(ctx, txID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TxStatus{Confirmations: tx.Confirmations, BlockHash: tx.BlockHash}
+	if tx.Confirmations > 0 {
+		var height uint64
+		if err := c.rpc.call(ctx, &height, "getblockcount"); err != nil {
+			return nil, err
+		}
+		status.BlockHeight = height - tx.Confirmations + 1
+	}
+	return status, nil
+}
+
+// walletTxStatus looks a transaction up in the node's wallet, which knows the
+// confirmed transactions of the addresses it watches even without -txindex.
+// Unconfirmed and conflicted wallet transactions may have left the mempool,
+// so only confirmed ones count as found.
+func (c *BitcoinRPCClient) walletTxStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	var tx struct {
+		Confirmations int64  `json:"confirmations"` // negative once conflicted
+		BlockHash     string `json:"blockhash"`
+		BlockHeight   uint64 `json:"blockheight"`
+	}
+	err := c.rpc.call(ctx, &tx, "gettransaction", txID, true)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		// Not a wallet transaction, or no wallet loaded
+		return nil, ErrTxNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tx.Confirmations <= 0 {
+		return nil, ErrTxNotFound
+	}
+	return &TxStatus{Confirmations: uint64(tx.Confirmations), BlockHash: tx.BlockHash, BlockHeight: tx.BlockHeight}, nil
+}
@@ -2,11 +2,19 @@
 package chain
 
 import (
+	"bytes"
+	"errors"
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"math/big"
+	"strings"
 	"testing"
+
+	"andi-custodian/pkg/networks"
 )
 
 /*
@@ -19,6 +27,10 @@ const (
 )
 */
 func mustCreateTestnetAddress() string {
+	return mustCreateAddress(&chaincfg.TestNet3Params)
+}
+
+func mustCreateAddress(params *chaincfg.Params) string {
 	privKey, err := btcec.NewPrivateKey()
 	if err != nil {
 		panic(err)
@@ -26,7 +38,7 @@ func mustCreateTestnetAddress() string {
 	pubKey := privKey.PubKey()
 	addr, err := btcutil.NewAddressWitnessPubKeyHash(
 		btcutil.Hash160(pubKey.SerializeCompressed()),
-		&chaincfg.TestNet3Params,
+		params,
 	)
 	if err != nil {
 		panic(err)
@@ -64,6 +76,62 @@ func TestBitcoinBuilder_BuildTx_Success(t *testing.T) {
 	}
 }
 
+func TestBitcoinBuilder_BuildTx_Networks(t *testing.T) {
+	for c, params := range map[Chain]*chaincfg.Params{
+		BitcoinMainnet: &chaincfg.MainNetParams,
+		BitcoinSignet:  &chaincfg.SigNetParams,
+		BitcoinRegtest: &chaincfg.RegressionNetParams,
+	} {
+		builder, err := NewBuilder(c)
+		if err != nil {
+			t.Fatalf("%s: NewBuilder failed: %v", c, err)
+		}
+		req := &TxRequest{
+			Chain: c,
+			From:  mustCreateAddress(params),
+			To:    mustCreateAddress(params),
+			Value: big.NewInt(500000),
+		}
+		result, err := builder.BuildTx(req, BuildOptions{UTXOs: []UTXO{{TxID: strings.Repeat("aa", 32), Value: 1000000}}})
+		if err != nil {
+			t.Fatalf("%s: BuildTx failed: %v", c, err)
+		}
+		if result.Chain != c {
+			t.Errorf("%s: result chain = %s", c, result.Chain)
+		}
+	}
+}
+
+func TestBitcoinBuilder_BuildTx_WrongNetwork(t *testing.T) {
+	builder := &BitcoinBuilder{}
+	utxos := []UTXO{{TxID: strings.Repeat("aa", 32), Value: 1000000}}
+	for _, tc := range []struct {
+		chain    Chain
+		from, to string
+	}{
+		{BitcoinTestnet, mustCreateTestnetAddress(), mustCreateAddress(&chaincfg.MainNetParams)},
+		{BitcoinMainnet, mustCreateAddress(&chaincfg.MainNetParams), mustCreateTestnetAddress()},
+		{BitcoinMainnet, mustCreateAddress(&chaincfg.RegressionNetParams), mustCreateAddress(&chaincfg.MainNetParams)},
+		{BitcoinTestnet, mustCreateTestnetAddress(), mustCreateAddress(&chaincfg.RegressionNetParams)},
+	} {
+		_, err := builder.BuildTx(&TxRequest{Chain: tc.chain, From: tc.from, To: tc.to, Value: big.NewInt(1000)}, BuildOptions{UTXOs: utxos})
+		if !errors.Is(err, ErrInvalidAddress) || !errors.Is(err, networks.ErrWrongNetwork) {
+			t.Errorf("%s from %s to %s: got %v, want a wrong network error", tc.chain, tc.from, tc.to, err)
+		}
+	}
+
+	// Legacy base58 addresses carry the network in their version byte
+	mainnetP2PKH, _ := btcutil.NewAddressPubKeyHash(make([]byte, 20), &chaincfg.MainNetParams)
+	_, err := builder.BuildTx(&TxRequest{Chain: BitcoinTestnet, From: mustCreateTestnetAddress(), To: mainnetP2PKH.EncodeAddress(), Value: big.NewInt(1000)}, BuildOptions{UTXOs: utxos})
+	if !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("mainnet P2PKH on testnet: got %v", err)
+	}
+
+	if _, err := builder.BuildTx(&TxRequest{Chain: EthereumSepolia}, BuildOptions{}); err == nil {
+		t.Error("BuildTx accepted a non-Bitcoin chain")
+	}
+}
+
 func TestBitcoinBuilder_BuildTx_InsufficientFunds(t *testing.T) {
 	fromAddr := mustCreateTestnetAddress()
 	toAddr := mustCreateTestnetAddress()
@@ -86,21 +154,268 @@ func TestBitcoinBuilder_BuildTx_InsufficientFunds(t *testing.T) {
 	}
 }
 
-func TestBitcoinBuilder_selectUTXOs(t *testing.T) {
-	builder := &BitcoinBuilder{}
+func TestLargestFirstSelector_Select(t *testing.T) {
 	utxos := []UTXO{
 		{Value: 600000},
 		{Value: 500000},
 		{Value: 100000},
 	}
-	selected, _, change, err := builder.selectUTXOs(utxos, 1050000) // 1.05 BTC
+	params := SelectionParams{
+		Target:      1050000,
+		FeeRate:     1,
+		BaseVSize:   42,
+		InputVSize:  68,
+		ChangeVSize: 31,
+	}
+	sel, err := LargestFirstSelector{}.Select(utxos, params)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(sel.Inputs) != 2 {
+		t.Errorf("Expected 2 UTXOs, got %d", len(sel.Inputs))
+	}
+	wantFee := int64(42 + 2*68 + 31)
+	if sel.Change != 50000-wantFee {
+		t.Errorf("Expected change=%d, got %d", 50000-wantFee, sel.Change)
+	}
+}
+
+func TestBitcoinBuilder_BuildTx_FeeRate(t *testing.T) {
+	privKey, _ := btcec.NewPrivateKey()
+	pubKey := privKey.PubKey().SerializeCompressed()
+	fromAddr, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+
+	builder := &BitcoinBuilder{}
+	req := &TxRequest{
+		Chain: BitcoinTestnet,
+		From:  fromAddr.EncodeAddress(),
+		To:    mustCreateTestnetAddress(),
+		Value: big.NewInt(150000),
+	}
+	utxos := []UTXO{
+		{TxID: strings.Repeat("aa", 32), VOut: 0, Value: 100000},
+		{TxID: strings.Repeat("bb", 32), VOut: 0, Value: 100000},
+	}
+	const feeRate = 25
+	result, err := builder.BuildTx(req, BuildOptions{UTXOs: utxos, FeeRate: feeRate})
+	if err != nil {
+		t.Fatalf("BuildTx failed: %v", err)
+	}
+
+	// Inputs must balance outputs plus the estimated fee
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	_ = msgTx.Deserialize(bytes.NewReader(result.RawTx))
+	var out int64
+	for _, o := range msgTx.TxOut {
+		out += o.Value
+	}
+	if 200000-out != result.EstimatedFee {
+		t.Errorf("Fee = %d, want inputs - outputs = %d", result.EstimatedFee, 200000-out)
+	}
+
+	// The estimate must cover the real vsize once witnesses are added
+	hashes, _ := builder.SigHashes(result)
+	sigs := make([]Signature, len(hashes))
+	for i, h := range hashes {
+		sigs[i] = Signature{Sig: ecdsa.Sign(privKey, h).Serialize(), PubKey: pubKey}
+	}
+	signed, err := builder.Finalize(result, sigs)
+	if err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	signedTx := wire.NewMsgTx(wire.TxVersion)
+	_ = signedTx.Deserialize(bytes.NewReader(signed.RawTx))
+	weight := int64(signedTx.SerializeSizeStripped()*3 + signedTx.SerializeSize())
+	vsize := (weight + 3) / 4
+	if result.EstimatedFee < vsize*feeRate {
+		t.Errorf("Fee %d below %d sat/vB for vsize %d", result.EstimatedFee, feeRate, vsize)
+	}
+	if result.EstimatedFee > (vsize+2)*feeRate {
+		t.Errorf("Fee %d overestimates vsize %d", result.EstimatedFee, vsize)
+	}
+}
+
+func TestBitcoinBuilder_BuildTx_SubtractFee(t *testing.T) {
+	builder := &BitcoinBuilder{}
+	req := &TxRequest{
+		Chain: BitcoinTestnet,
+		From:  mustCreateTestnetAddress(),
+		To:    mustCreateTestnetAddress(),
+		Value: big.NewInt(300000), // sweep the full balance
+	}
+	utxos := []UTXO{
+		{TxID: strings.Repeat("aa", 32), VOut: 0, Value: 200000},
+		{TxID: strings.Repeat("bb", 32), VOut: 0, Value: 100000},
+	}
+	result, err := builder.BuildTx(req, BuildOptions{UTXOs: utxos, FeeRate: 5, SubtractFeeFromAmount: true})
+	if err != nil {
+		t.Fatalf("BuildTx failed: %v", err)
+	}
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	_ = msgTx.Deserialize(bytes.NewReader(result.RawTx))
+	if len(msgTx.TxOut) != 1 {
+		t.Fatalf("Sweep should have no change output, got %d outputs", len(msgTx.TxOut))
+	}
+	if msgTx.TxOut[0].Value != 300000-result.EstimatedFee {
+		t.Errorf("Recipient gets %d, want %d", msgTx.TxOut[0].Value, 300000-result.EstimatedFee)
+	}
+}
+
+// fixedSelector spends exactly the given UTXOs.
+type fixedSelector struct{ utxos []UTXO }
+
+func (f fixedSelector) Select(_ []UTXO, p SelectionParams) (*Selection, error) {
+	return NewSelection(f.utxos, p)
+}
+
+func TestBitcoinBuilder_BuildTx_UsesSelector(t *testing.T) {
+	builder := &BitcoinBuilder{}
+	req := &TxRequest{
+		Chain: BitcoinTestnet,
+		From:  mustCreateTestnetAddress(),
+		To:    mustCreateTestnetAddress(),
+		Value: big.NewInt(50000),
+	}
+	small := UTXO{TxID: strings.Repeat("cc", 32), VOut: 2, Value: 60000}
+	utxos := []UTXO{
+		{TxID: strings.Repeat("aa", 32), VOut: 0, Value: 1000000},
+		small,
+	}
+	result, err := builder.BuildTx(req, BuildOptions{UTXOs: utxos, Selector: fixedSelector{[]UTXO{small}}})
+	if err != nil {
+		t.Fatalf("BuildTx failed: %v", err)
+	}
+	if len(result.Inputs) != 1 || result.Inputs[0].VOut != 2 {
+		t.Errorf("Builder did not spend the selector's choice: %+v", result.Inputs)
+	}
+}
+
+func TestBitcoinBuilder_SigHashes(t *testing.T) {
+	fromAddr := mustCreateTestnetAddress()
+	addr, _ := btcutil.DecodeAddress(fromAddr, &chaincfg.TestNet3Params)
+	pkScript, _ := txscript.PayToAddrScript(addr)
+
+	builder := &BitcoinBuilder{}
+	req := &TxRequest{
+		Chain: BitcoinTestnet,
+		From:  fromAddr,
+		To:    mustCreateTestnetAddress(),
+		Value: big.NewInt(1500000),
+	}
+	utxos := []UTXO{
+		{TxID: strings.Repeat("aa", 32), VOut: 0, Value: 1000000, PkScript: pkScript},
+		{TxID: strings.Repeat("bb", 32), VOut: 1, Value: 1000000, PkScript: pkScript},
+	}
+	result, err := builder.BuildTx(req, BuildOptions{UTXOs: utxos})
+	if err != nil {
+		t.Fatalf("BuildTx failed: %v", err)
+	}
+
+	hashes, err := builder.SigHashes(result)
+	if err != nil {
+		t.Fatalf("SigHashes failed: %v", err)
+	}
+	if len(hashes) != 2 {
+		t.Fatalf("Expected 2 sighashes, got %d", len(hashes))
+	}
+	if bytes.Equal(hashes[0], hashes[1]) {
+		t.Error("Sighashes must commit to the input being signed")
+	}
+
+	// Non-P2WPKH inputs are rejected
+	result.Inputs[0].PkScript = []byte{txscript.OP_TRUE}
+	if _, err := builder.SigHashes(result); err == nil {
+		t.Error("Expected error for non-P2WPKH input")
+	}
+}
+
+func TestBitcoinBuilder_SignAndFinalize_P2WPKH(t *testing.T) {
+	privKey, _ := btcec.NewPrivateKey()
+	pubKey := privKey.PubKey().SerializeCompressed()
+	fromAddr, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+
+	builder := &BitcoinBuilder{}
+	req := &TxRequest{
+		Chain: BitcoinTestnet,
+		From:  fromAddr.EncodeAddress(),
+		To:    mustCreateTestnetAddress(),
+		Value: big.NewInt(1500000),
+	}
+	// PkScript omitted: the builder fills in the from address's script
+	utxos := []UTXO{
+		{TxID: strings.Repeat("aa", 32), VOut: 0, Value: 1000000},
+		{TxID: strings.Repeat("bb", 32), VOut: 1, Value: 1000000},
+	}
+	result, err := builder.BuildTx(req, BuildOptions{UTXOs: utxos})
+	if err != nil {
+		t.Fatalf("BuildTx failed: %v", err)
+	}
+
+	hashes, err := builder.SigHashes(result)
+	if err != nil {
+		t.Fatalf("SigHashes failed: %v", err)
+	}
+	sigs := make([]Signature, len(hashes))
+	for i, h := range hashes {
+		sigs[i] = Signature{Sig: ecdsa.Sign(privKey, h).Serialize(), PubKey: pubKey}
+	}
+	signed, err := builder.Finalize(result, sigs)
 	if err != nil {
-		t.Fatalf("selectUTXOs failed: %v", err)
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	if err := msgTx.Deserialize(bytes.NewReader(signed.RawTx)); err != nil {
+		t.Fatalf("Deserialize signed tx: %v", err)
+	}
+	if msgTx.TxHash().String() != signed.TxID {
+		t.Errorf("TxID = %s, want %s", signed.TxID, msgTx.TxHash())
 	}
-	if len(selected) != 2 {
-		t.Errorf("Expected 2 UTXOs, got %d", len(selected))
+
+	// Every input must pass script validation under standard (mempool) rules
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	for i, u := range result.Inputs {
+		prevOuts[msgTx.TxIn[i].PreviousOutPoint] = wire.NewTxOut(u.Value, u.PkScript)
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(msgTx, fetcher)
+	for i, u := range result.Inputs {
+		vm, err := txscript.NewEngine(u.PkScript, msgTx, i, txscript.StandardVerifyFlags, nil, sigHashes, u.Value, fetcher)
+		if err != nil {
+			t.Fatalf("NewEngine input %d: %v", i, err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Errorf("input %d failed validation: %v", i, err)
+		}
+	}
+
+	// A signature from another key must not validate
+	otherKey, _ := btcec.NewPrivateKey()
+	sigs[0].Sig = ecdsa.Sign(otherKey, hashes[0]).Serialize()
+	bad, _ := builder.Finalize(result, sigs)
+	badTx := wire.NewMsgTx(wire.TxVersion)
+	_ = badTx.Deserialize(bytes.NewReader(bad.RawTx))
+	vm, _ := txscript.NewEngine(result.Inputs[0].PkScript, badTx, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(badTx, fetcher), result.Inputs[0].Value, fetcher)
+	if err := vm.Execute(); err == nil {
+		t.Error("Expected validation failure for wrong key")
+	}
+}
+
+func TestBitcoinBuilder_BuildTx_ForeignUTXO(t *testing.T) {
+	other, _ := btcutil.DecodeAddress(mustCreateTestnetAddress(), &chaincfg.TestNet3Params)
+	otherScript, _ := txscript.PayToAddrScript(other)
+
+	builder := &BitcoinBuilder{}
+	req := &TxRequest{
+		Chain: BitcoinTestnet,
+		From:  mustCreateTestnetAddress(),
+		To:    mustCreateTestnetAddress(),
+		Value: big.NewInt(500000),
 	}
-	if change != 50000 {
-		t.Errorf("Expected change=50000, got %d", change)
+	utxos := []UTXO{{TxID: strings.Repeat("aa", 32), VOut: 0, Value: 1000000, PkScript: otherScript}}
+	if _, err := builder.BuildTx(req, BuildOptions{UTXOs: utxos}); err == nil {
+		t.Error("Expected error for UTXO not owned by from address")
 	}
 }
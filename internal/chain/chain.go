@@ -1,24 +1,38 @@
 // chain.go
 package chain
 
-import "errors"
+import (
+	"fmt"
+
+	"andi-custodian/pkg/chains"
+)
 
 // Builder abstracts transaction construction across blockchains.
-// It returns an *unsigned* transaction for signing by the wallet layer.
+// It returns an *unsigned* transaction for signing by the wallet layer,
+// the digests the wallet must sign, and assembles the signed result.
 type Builder interface {
 	BuildTx(req *TxRequest, opts BuildOptions) (*TxResult, error)
+	// SigHashes returns the digests to sign, one per required signature, in order.
+	SigHashes(tx *TxResult) ([][]byte, error)
+	// Finalize places one signature per sighash into the transaction.
+	Finalize(tx *TxResult, sigs []Signature) (*SignedTx, error)
+}
+
+// TokenBuilder is implemented by builders that support fungible token transfers.
+type TokenBuilder interface {
+	BuildTokenTransfer(req *TokenTransferRequest, opts BuildOptions) (*TxResult, error)
 }
 
-// NewBuilder creates a chain-specific builder.
+// NewBuilder creates the builder for a chain's family.
 func NewBuilder(chainType Chain) (Builder, error) {
-	switch chainType {
-	case BitcoinTestnet:
+	switch chainType.Family() {
+	case chains.FamilyUTXO:
 		return &BitcoinBuilder{}, nil
-	case EthereumSepolia, AvalancheFuji:
+	case chains.FamilyEVM:
 		return &EthereumBuilder{}, nil
-	case SolanaDevnet:
+	case chains.FamilySolana:
 		return &SolanaBuilder{}, nil
 	default:
-		return nil, errors.New("unsupported chain: " + string(chainType))
+		return nil, fmt.Errorf("unsupported chain: %w", chains.ErrUnknownChain)
 	}
 }
@@ -0,0 +1,77 @@
+// client.go
+package chain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+)
+
+var (
+	// ErrTxNotFound is returned when a node knows nothing about a transaction.
+	ErrTxNotFound = errors.New("transaction not found")
+	// ErrTxRejected is returned when a node refuses to accept a broadcast transaction.
+	ErrTxRejected = errors.New("transaction rejected")
+	// ErrInputsSpent is returned when a node refuses a transaction because what
+	// it spends is already spent: Bitcoin inputs, or an EVM account's nonce.
+	// The spender may be the transaction itself, confirmed where the node does
+	// not look, so it is not a rejection until that is ruled out.
+	ErrInputsSpent = errors.New("transaction inputs already spent")
+)
+
+// Client is the node access every chain provides.
+type Client interface {
+	// Balance returns the address's confirmed balance in base units.
+	Balance(ctx context.Context, address string) (*big.Int, error)
+	// Broadcast submits a signed transaction and returns its ID as the chain
+	// reports it. Sending a transaction the chain already has succeeds.
+	Broadcast(ctx context.Context, rawTx []byte) (string, error)
+	// TxStatus reports where a transaction is. It returns ErrTxNotFound for
+	// transactions the node has never seen or has dropped.
+	TxStatus(ctx context.Context, txID string) (*TxStatus, error)
+}
+
+// TxStatus is a transaction's inclusion state as seen by one node.
+type TxStatus struct {
+	Confirmations uint64 // 0 while in the mempool
+	BlockHash     string // empty while in the mempool
+	BlockHeight   uint64
+	Failed        bool // included but reverted (EVM) or errored (Solana)
+	Finalized     bool // the chain itself reports finality (Solana "finalized")
+}
+
+// EVMClient is a node client for Ethereum-compatible chains.
+type EVMClient interface {
+	Client
+	// PendingNonce returns the next nonce for the address, counting mempool transactions.
+	PendingNonce(ctx context.Context, address string) (uint64, error)
+	// SuggestFees returns current fee levels.
+	SuggestFees(ctx context.Context) (*EVMFees, error)
+}
+
+// EVMFees are fee suggestions for EVM transactions, in wei.
+type EVMFees struct {
+	GasPrice  *big.Int // legacy transactions
+	GasTipCap *big.Int // EIP-1559 priority fee
+	BaseFee   *big.Int // base fee of the latest block; nil before London
+}
+
+// BitcoinClient is a node client for Bitcoin.
+type BitcoinClient interface {
+	Client
+	// ListUnspent returns the address's confirmed unspent outputs.
+	ListUnspent(ctx context.Context, address string) ([]UTXO, error)
+	// EstimateFeeRate returns the fee rate in sat/vB expected to confirm within confTarget blocks.
+	EstimateFeeRate(ctx context.Context, confTarget int) (int64, error)
+}
+
+// SolanaClient is a node client for Solana.
+type SolanaClient interface {
+	Client
+	// LatestBlockhash returns a recent blockhash, base58 encoded, for new transactions.
+	LatestBlockhash(ctx context.Context) (string, error)
+	// FeeForMessage returns the fee in lamports the cluster charges for a message.
+	FeeForMessage(ctx context.Context, message []byte) (uint64, error)
+	// AccountExists reports whether an account, e.g. a token account, has been created.
+	AccountExists(ctx context.Context, address string) (bool, error)
+}
@@ -0,0 +1,336 @@
+// client_test.go
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rpcStub serves canned JSON-RPC results keyed by method and records the params it saw.
+type rpcStub struct {
+	mu      sync.Mutex
+	results map[string]string // method -> raw JSON result
+	errors  map[string]RPCError
+	params  map[string]json.RawMessage
+}
+
+func newRPCStub(t *testing.T, results map[string]string) (*rpcStub, string) {
+	stub := &rpcStub{results: results, errors: map[string]RPCError{}, params: map[string]json.RawMessage{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.params[req.Method] = req.Params
+		if e, ok := stub.errors[req.Method]; ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]any{"id": req.ID, "result": nil, "error": e})
+			return
+		}
+		result, ok := stub.results[req.Method]
+		if !ok {
+			t.Errorf("unexpected method %s", req.Method)
+			result = "null"
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return stub, srv.URL
+}
+
+func TestEthereumRPCClient(t *testing.T) {
+	stub, url := newRPCStub(t, map[string]string{
+		"eth_getBalance":            `"0xde0b6b3a7640000"`,
+		"eth_getTransactionCount":   `"0x7"`,
+		"eth_gasPrice":              `"0x77359400"`,
+		"eth_maxPriorityFeePerGas":  `"0x3b9aca00"`,
+		"eth_getBlockByNumber":      `{"baseFeePerGas":"0x3b9aca00"}`,
+		"eth_sendRawTransaction":    `"0xabc"`,
+		"eth_getTransactionReceipt": `{"blockHash":"0xb1","blockNumber":"0x10","status":"0x1"}`,
+		"eth_blockNumber":           `"0x12"`,
+	})
+	client := NewEthereumRPCClient(url, nil)
+	ctx := context.Background()
+
+	balance, err := client.Balance(ctx, "0x742d35Cc6634C0532925a3b844Bc9dbd8b5E8a18")
+	require.NoError(t, err)
+	assert.Equal(t, "1000000000000000000", balance.String())
+
+	nonce, err := client.PendingNonce(ctx, "0x742d35Cc6634C0532925a3b844Bc9dbd8b5E8a18")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), nonce)
+	assert.JSONEq(t, `["0x742d35Cc6634C0532925a3b844Bc9dbd8b5E8a18","pending"]`, string(stub.params["eth_getTransactionCount"]))
+
+	fees, err := client.SuggestFees(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(2_000_000_000), fees.GasPrice)
+	assert.Equal(t, big.NewInt(1_000_000_000), fees.GasTipCap)
+	assert.Equal(t, big.NewInt(1_000_000_000), fees.BaseFee)
+
+	txID, err := client.Broadcast(ctx, []byte{0x02, 0xf8})
+	require.NoError(t, err)
+	assert.Equal(t, "0xabc", txID)
+	assert.JSONEq(t, `["0x02f8"]`, string(stub.params["eth_sendRawTransaction"]))
+
+	status, err := client.TxStatus(ctx, "0xabc")
+	require.NoError(t, err)
+	assert.Equal(t, &TxStatus{Confirmations: 3, BlockHash: "0xb1", BlockHeight: 16}, status)
+}
+
+func TestEthereumRPCClient_TxStatus_PendingAndUnknown(t *testing.T) {
+	stub, url := newRPCStub(t, map[string]string{
+		"eth_getTransactionReceipt": `null`,
+		"eth_getTransactionByHash":  `{"hash":"0xabc"}`,
+	})
+	client := NewEthereumRPCClient(url, nil)
+
+	status, err := client.TxStatus(context.Background(), "0xabc")
+	require.NoError(t, err)
+	assert.Zero(t, status.Confirmations)
+
+	stub.results["eth_getTransactionByHash"] = `null`
+	_, err = client.TxStatus(context.Background(), "0xabc")
+	assert.ErrorIs(t, err, ErrTxNotFound)
+}
+
+func TestEthereumRPCClient_BroadcastErrors(t *testing.T) {
+	stub, url := newRPCStub(t, map[string]string{})
+	client := NewEthereumRPCClient(url, nil)
+	ctx := context.Background()
+	raw := []byte{0x02, 0xf8, 0x6f}
+
+	for msg, want := range map[string]error{
+		"nonce too low: next nonce 5, tx nonce 4":       ErrInputsSpent,
+		"insufficient funds for gas * price + value":    ErrTxRejected,
+		"intrinsic gas too low: have 20000, want 21000": ErrTxRejected,
+	} {
+		stub.errors["eth_sendRawTransaction"] = RPCError{Code: -32000, Message: msg}
+		_, err := client.Broadcast(ctx, raw)
+		assert.ErrorIs(t, err, want, msg)
+	}
+	stub.errors["eth_sendRawTransaction"] = RPCError{Code: -32000, Message: "nonce too low"}
+	_, err := client.Broadcast(ctx, raw)
+	assert.NotErrorIs(t, err, ErrTxRejected)
+
+	// A transaction the pool already holds was sent before
+	stub.errors["eth_sendRawTransaction"] = RPCError{Code: -32000, Message: "already known"}
+	txID, err := client.Broadcast(ctx, raw)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(raw).Hex(), txID)
+
+	// Anything else may pass on a later attempt
+	stub.errors["eth_sendRawTransaction"] = RPCError{Code: -32005, Message: "rate limit exceeded"}
+	_, err = client.Broadcast(ctx, raw)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrTxRejected)
+}
+
+func TestBitcoinRPCClient(t *testing.T) {
+	stub, url := newRPCStub(t, map[string]string{
+		"listunspent": `[
+			{"txid":"aa","vout":1,"amount":0.1,"scriptPubKey":"0014ab"},
+			{"txid":"bb","vout":0,"amount":0.00000294,"scriptPubKey":"0014cd"}
+		]`,
+		"estimatesmartfee":   `{"feerate":0.00012345,"blocks":6}`,
+		"sendrawtransaction": `"cc"`,
+		"getrawtransaction":  `{"confirmations":3,"blockhash":"00ff"}`,
+		"getblockcount":      `102`,
+	})
+	client := NewBitcoinRPCClient(url, "user", "pass", nil)
+	ctx := context.Background()
+
+	utxos, err := client.ListUnspent(ctx, "tb1q...")
+	require.NoError(t, err)
+	assert.Equal(t, []UTXO{
+		{TxID: "aa", VOut: 1, Value: 10_000_000, PkScript: []byte{0x00, 0x14, 0xab}},
+		{TxID: "bb", VOut: 0, Value: 294, PkScript: []byte{0x00, 0x14, 0xcd}},
+	}, utxos)
+
+	balance, err := client.Balance(ctx, "tb1q...")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10_000_294), balance.Int64())
+
+	// 12345 sat/kvB rounds up to 13 sat/vB
+	rate, err := client.EstimateFeeRate(ctx, 6)
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), rate)
+
+	txID, err := client.Broadcast(ctx, []byte{0x01, 0x02})
+	require.NoError(t, err)
+	assert.Equal(t, "cc", txID)
+	assert.JSONEq(t, `["0102"]`, string(stub.params["sendrawtransaction"]))
+
+	status, err := client.TxStatus(ctx, "cc")
+	require.NoError(t, err)
+	assert.Equal(t, &TxStatus{Confirmations: 3, BlockHash: "00ff", BlockHeight: 100}, status)
+}
+
+func TestBitcoinRPCClient_Errors(t *testing.T) {
+	stub, url := newRPCStub(t, map[string]string{})
+	stub.errors["sendrawtransaction"] = RPCError{Code: -26, Message: "min relay fee not met"}
+	stub.errors["getrawtransaction"] = RPCError{Code: -5, Message: "No such mempool or blockchain transaction"}
+	stub.errors["gettransaction"] = RPCError{Code: -5, Message: "Invalid or non-wallet transaction id"}
+	client := NewBitcoinRPCClient(url, "user", "pass", nil)
+	ctx := context.Background()
+
+	_, err := client.Broadcast(ctx, []byte{0x01})
+	assert.ErrorIs(t, err, ErrTxRejected)
+	assert.ErrorContains(t, err, "min relay fee not met")
+
+	// Spent inputs are not a rejection until the spender is known
+	stub.errors["sendrawtransaction"] = RPCError{Code: -25, Message: "bad-txns-inputs-missingorspent"}
+	_, err = client.Broadcast(ctx, []byte{0x01})
+	assert.ErrorIs(t, err, ErrInputsSpent)
+	assert.NotErrorIs(t, err, ErrTxRejected)
+
+	// A transaction already in the chain was broadcast before
+	raw, txID := testBitcoinTx(t)
+	stub.errors["sendrawtransaction"] = RPCError{Code: -27, Message: "Transaction already in block chain"}
+	got, err := client.Broadcast(ctx, raw)
+	require.NoError(t, err)
+	assert.Equal(t, txID, got)
+
+	_, err = client.TxStatus(ctx, "cc")
+	assert.ErrorIs(t, err, ErrTxNotFound)
+}
+
+func TestBitcoinRPCClient_TxStatus_WalletFallback(t *testing.T) {
+	stub, url := newRPCStub(t, map[string]string{
+		"gettransaction": `{"confirmations":4,"blockhash":"00ff","blockheight":99}`,
+	})
+	stub.errors["getrawtransaction"] = RPCError{Code: -5, Message: "No such mempool transaction. Use -txindex"}
+	client := NewBitcoinRPCClient(url, "user", "pass", nil)
+	ctx := context.Background()
+
+	// Without -txindex a confirmed transaction is only in the wallet
+	status, err := client.TxStatus(ctx, "cc")
+	require.NoError(t, err)
+	assert.Equal(t, &TxStatus{Confirmations: 4, BlockHash: "00ff", BlockHeight: 99}, status)
+	assert.JSONEq(t, `["cc",true]`, string(stub.params["gettransaction"]))
+
+	// Unconfirmed or conflicted wallet transactions may be gone from the mempool
+	for _, confirmations := range []string{"0", "-2"} {
+		stub.results["gettransaction"] = `{"confirmations":` + confirmations + `}`
+		_, err = client.TxStatus(ctx, "cc")
+		assert.ErrorIs(t, err, ErrTxNotFound, confirmations)
+	}
+}
+
+// testBitcoinTx returns a serialized one-input transaction and its ID.
+func testBitcoinTx(t *testing.T) ([]byte, string) {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x00, 0x14}))
+	var buf bytes.Buffer
+	require.NoError(t, tx.Serialize(&buf))
+	return buf.Bytes(), tx.TxHash().String()
+}
+
+func TestSolanaRPCClient(t *testing.T) {
+	stub, url := newRPCStub(t, map[string]string{
+		"getBalance":         `{"context":{"slot":1},"value":1500000000}`,
+		"getLatestBlockhash": `{"context":{"slot":1},"value":{"blockhash":"EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N","lastValidBlockHeight":200}}`,
+		"getFeeForMessage":   `{"context":{"slot":1},"value":5000}`,
+		"getAccountInfo":     `{"context":{"slot":1},"value":null}`,
+		"sendTransaction":    `"5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW"`,
+		"getSignatureStatuses": `{"context":{"slot":1},"value":[
+			{"slot":72,"confirmations":10,"err":null,"confirmationStatus":"confirmed"}
+		]}`,
+	})
+	client := NewSolanaRPCClient(url, nil)
+	ctx := context.Background()
+
+	balance, err := client.Balance(ctx, "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1_500_000_000), balance.Int64())
+
+	hash, err := client.LatestBlockhash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N", hash)
+
+	fee, err := client.FeeForMessage(ctx, []byte{1, 0, 1})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5000), fee)
+
+	exists, err := client.AccountExists(ctx, "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	stub.results["getAccountInfo"] = `{"context":{"slot":1},"value":{"lamports":2039280,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","data":["","base64"],"executable":false}}`
+	exists, err = client.AccountExists(ctx, "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	sig, err := client.Broadcast(ctx, []byte{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW", sig)
+	assert.JSONEq(t, `["AQID",{"encoding":"base64"}]`, string(stub.params["sendTransaction"]))
+
+	status, err := client.TxStatus(ctx, sig)
+	require.NoError(t, err)
+	assert.Equal(t, &TxStatus{Confirmations: 11, BlockHeight: 72}, status)
+
+	// Rooted transactions report no confirmation count
+	stub.results["getSignatureStatuses"] = `{"context":{"slot":1},"value":[
+		{"slot":72,"confirmations":null,"err":{"InstructionError":[0,"Custom"]},"confirmationStatus":"finalized"}
+	]}`
+	status, err = client.TxStatus(ctx, sig)
+	require.NoError(t, err)
+	assert.True(t, status.Finalized)
+	assert.True(t, status.Failed)
+
+	stub.results["getSignatureStatuses"] = `{"context":{"slot":1},"value":[null]}`
+	_, err = client.TxStatus(ctx, sig)
+	assert.ErrorIs(t, err, ErrTxNotFound)
+}
+
+func TestSolanaRPCClient_BroadcastErrors(t *testing.T) {
+	stub, url := newRPCStub(t, map[string]string{})
+	client := NewSolanaRPCClient(url, nil)
+	ctx := context.Background()
+	var sig [64]byte
+	sig[0] = 7
+	raw := append(append([]byte{1}, sig[:]...), 1, 0, 1)
+
+	preflight := func(txErr string) RPCError {
+		return RPCError{Code: -32002, Message: "Transaction simulation failed", Data: json.RawMessage(`{"err":` + txErr + `,"logs":[]}`)}
+	}
+	for name, tc := range map[string]struct {
+		err      RPCError
+		rejected bool
+	}{
+		"instruction error": {preflight(`{"InstructionError":[0,{"Custom":1}]}`), true},
+		"insufficient fee":  {preflight(`"InsufficientFundsForFee"`), true},
+		"bad signature":     {RPCError{Code: -32003, Message: "Transaction signature verification failure"}, true},
+		"blockhash unknown": {preflight(`"BlockhashNotFound"`), false},
+		"node unhealthy":    {RPCError{Code: -32005, Message: "Node is unhealthy"}, false},
+		"rate limited":      {RPCError{Code: 429, Message: "Too many requests"}, false},
+	} {
+		stub.errors["sendTransaction"] = tc.err
+		_, err := client.Broadcast(ctx, raw)
+		require.Error(t, err, name)
+		assert.Equal(t, tc.rejected, errors.Is(err, ErrTxRejected), name)
+	}
+
+	// Already processed: the ID is the transaction's first signature
+	stub.errors["sendTransaction"] = preflight(`"AlreadyProcessed"`)
+	txID, err := client.Broadcast(ctx, raw)
+	require.NoError(t, err)
+	assert.Equal(t, base58.Encode(sig[:]), txID)
+}
@@ -0,0 +1,147 @@
+// coinselect.go
+package chain
+
+import (
+	"errors"
+	"sort"
+)
+
+// Virtual sizes (BIP-141 weight / 4, rounded up) used to estimate P2WPKH spends.
+const (
+	txOverheadVSize  = 11 // version, locktime, input/output counts, segwit marker and flag
+	p2wpkhInputVSize = 68 // outpoint, sequence, empty scriptSig, <sig> <pubkey> witness
+
+	// DustThreshold is the smallest P2WPKH output relayed under the default
+	// dust relay fee (3 sat/vbyte); smaller change is given to the miner instead.
+	DustThreshold int64 = 294
+
+	// DefaultFeeRate applies when BuildOptions.FeeRate is zero, in sat/vbyte.
+	DefaultFeeRate int64 = 10
+
+	// DefaultLongTermFeeRate is the expected future fee rate used to price
+	// spending an output later (sat/vbyte), as in Bitcoin Core's waste metric.
+	DefaultLongTermFeeRate int64 = 10
+)
+
+// ErrDustOutput is returned when the recipient would receive less than the dust threshold.
+var ErrDustOutput = errors.New("output below dust threshold")
+
+// OutputVSize returns the virtual size of an output paying to pkScript.
+func OutputVSize(pkScript []byte) int64 {
+	return 8 + 1 + int64(len(pkScript)) // value, script length, script
+}
+
+// SelectionParams describes a spend for fee-aware coin selection.
+type SelectionParams struct {
+	Target      int64 // amount to pay, in satoshis
+	FeeRate     int64 // sat/vbyte
+	BaseVSize   int64 // transaction without inputs and change: overhead plus recipient output
+	InputVSize  int64 // added per input
+	ChangeVSize int64 // added by a change output
+	SubtractFee bool  // pay the fee out of Target instead of on top of it (sweeps)
+	NoChange    bool  // never create change; any excess goes to the fee
+
+	LongTermFeeRate int64 // sat/vbyte; zero means DefaultLongTermFeeRate
+}
+
+// LongTerm returns the long-term fee rate, applying the default.
+func (p SelectionParams) LongTerm() int64 {
+	if p.LongTermFeeRate > 0 {
+		return p.LongTermFeeRate
+	}
+	return DefaultLongTermFeeRate
+}
+
+// InputFee returns the fee to spend one input at the current fee rate.
+func (p SelectionParams) InputFee() int64 {
+	return p.InputVSize * p.FeeRate
+}
+
+// CostOfChange returns the fee to create a change output now plus the
+// expected fee to spend it later.
+func (p SelectionParams) CostOfChange() int64 {
+	return p.ChangeVSize*p.FeeRate + p.InputVSize*p.LongTerm()
+}
+
+// CoinSelector selects UTXOs to fund a transaction.
+type CoinSelector interface {
+	Select(utxos []UTXO, params SelectionParams) (*Selection, error)
+}
+
+// Selection is the outcome of coin selection.
+type Selection struct {
+	Inputs []UTXO
+	Amount int64 // paid to the recipient; less than Target when the fee is subtracted
+	Fee    int64
+	Change int64 // zero when no change output is created
+}
+
+// Total returns the sum of the selected inputs.
+func (s *Selection) Total() int64 {
+	return sumValues(s.Inputs)
+}
+
+// NewSelection computes the fee, change and recipient amount for a fixed set of inputs.
+// Change below DustThreshold is dropped and added to the fee. It returns
+// ErrInsufficientFunds if the inputs cannot pay the target and the fee.
+func NewSelection(inputs []UTXO, p SelectionParams) (*Selection, error) {
+	total := sumValues(inputs)
+	feeNoChange := (p.BaseVSize + int64(len(inputs))*p.InputVSize) * p.FeeRate
+	feeWithChange := feeNoChange + p.ChangeVSize*p.FeeRate
+
+	sel := &Selection{Inputs: inputs}
+	if p.SubtractFee {
+		if total < p.Target {
+			return nil, ErrInsufficientFunds
+		}
+		if change := total - p.Target; change >= DustThreshold && !p.NoChange {
+			sel.Fee, sel.Change = feeWithChange, change
+		} else {
+			sel.Fee = feeNoChange + change
+		}
+		sel.Amount = total - sel.Fee - sel.Change
+	} else {
+		if total < p.Target+feeNoChange {
+			return nil, ErrInsufficientFunds
+		}
+		sel.Amount = p.Target
+		if change := total - p.Target - feeWithChange; change >= DustThreshold && !p.NoChange {
+			sel.Fee, sel.Change = feeWithChange, change
+		} else {
+			sel.Fee = total - p.Target
+		}
+	}
+
+	if sel.Amount < DustThreshold {
+		return nil, ErrDustOutput
+	}
+	return sel, nil
+}
+
+// LargestFirstSelector adds the largest UTXOs until they cover the target and the fee.
+// It is the builder's default when BuildOptions.Selector is nil.
+type LargestFirstSelector struct{}
+
+func (LargestFirstSelector) Select(utxos []UTXO, p SelectionParams) (*Selection, error) {
+	sorted := make([]UTXO, len(utxos))
+	copy(sorted, utxos)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Value > sorted[j].Value })
+
+	lastErr := ErrInsufficientFunds
+	for n := 1; n <= len(sorted); n++ {
+		sel, err := NewSelection(sorted[:n], p)
+		if err == nil {
+			return sel, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func sumValues(utxos []UTXO) int64 {
+	var total int64
+	for _, u := range utxos {
+		total += u.Value
+	}
+	return total
+}
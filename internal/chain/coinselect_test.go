@@ -0,0 +1,79 @@
+// coinselect_test.go
+package chain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSelectionParams = SelectionParams{
+	FeeRate:     2,
+	BaseVSize:   42,
+	InputVSize:  68,
+	ChangeVSize: 31,
+}
+
+func TestNewSelection_Change(t *testing.T) {
+	p := testSelectionParams
+	p.Target = 60_000
+
+	sel, err := NewSelection([]UTXO{{Value: 100_000}}, p)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(60_000), sel.Amount)
+	assert.Equal(t, int64((42+68+31)*2), sel.Fee)
+	assert.Equal(t, int64(100_000-60_000-(42+68+31)*2), sel.Change)
+}
+
+func TestNewSelection_DustChangeGoesToFee(t *testing.T) {
+	p := testSelectionParams
+	feeNoChange := int64((42 + 68) * 2)
+	p.Target = 100_000 - feeNoChange - 100 // 100 sats left over: dust
+
+	sel, err := NewSelection([]UTXO{{Value: 100_000}}, p)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), sel.Change)
+	assert.Equal(t, feeNoChange+100, sel.Fee)
+	assert.Equal(t, sel.Total(), sel.Amount+sel.Fee)
+}
+
+func TestNewSelection_SubtractFee(t *testing.T) {
+	p := testSelectionParams
+	p.SubtractFee = true
+
+	// Sweep: the whole balance goes to the recipient minus the fee
+	p.Target = 150_000
+	sel, err := NewSelection([]UTXO{{Value: 100_000}, {Value: 50_000}}, p)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), sel.Change)
+	assert.Equal(t, int64((42+2*68)*2), sel.Fee)
+	assert.Equal(t, 150_000-sel.Fee, sel.Amount)
+
+	// Partial spend: change is untouched, the fee comes out of the amount
+	p.Target = 80_000
+	sel, err = NewSelection([]UTXO{{Value: 100_000}}, p)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20_000), sel.Change)
+	assert.Equal(t, 80_000-sel.Fee, sel.Amount)
+}
+
+func TestNewSelection_Errors(t *testing.T) {
+	p := testSelectionParams
+	p.Target = 100_000
+	_, err := NewSelection([]UTXO{{Value: 100_000}}, p)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	p.Target = 100
+	_, err = NewSelection([]UTXO{{Value: 100_000}}, p)
+	assert.ErrorIs(t, err, ErrDustOutput)
+
+	p.Target = 300
+	p.SubtractFee = true
+	_, err = NewSelection([]UTXO{{Value: 300}}, p)
+	assert.ErrorIs(t, err, ErrDustOutput)
+}
+
+func TestOutputVSize(t *testing.T) {
+	assert.Equal(t, int64(31), OutputVSize(make([]byte, 22))) // P2WPKH
+	assert.Equal(t, int64(43), OutputVSize(make([]byte, 34))) // P2TR / P2WSH
+}
@@ -15,12 +15,27 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// FeeModel selects how an EVM transaction pays for gas.
+type FeeModel int
+
+const (
+	// FeeModelDynamic builds EIP-1559 (type-2) transactions with a max fee and priority fee.
+	FeeModelDynamic FeeModel = iota
+	// FeeModelLegacy builds EIP-155 legacy transactions with a single gas price.
+	FeeModelLegacy
+)
+
+// defaultGasTipCap is the priority fee used when BuildOptions does not set one.
+var defaultGasTipCap = big.NewInt(1_000_000_000) // 1 gwei
+
 // EthereumBuilder constructs unsigned Ethereum transactions.
 type EthereumBuilder struct{}
 
+// BuildTx builds an unsigned native coin transfer on any configured EVM chain.
 func (e *EthereumBuilder) BuildTx(req *TxRequest, opts BuildOptions) (*TxResult, error) {
-	if req.Chain != EthereumSepolia {
-		return nil, errors.New("EthereumBuilder: invalid chain")
+	cfg, err := evmChain(req.Chain)
+	if err != nil {
+		return nil, err
 	}
 
 	if !common.IsHexAddress(req.From) {
@@ -30,47 +45,37 @@ func (e *EthereumBuilder) BuildTx(req *TxRequest, opts BuildOptions) (*TxResult,
 		return nil, fmt.Errorf("invalid to address: %w", ErrInvalidAddress)
 	}
 
-	// Create legacy transaction (Sepolia supports EIP-155)
-	tx := types.NewTransaction(
-		opts.Nonce,
-		common.HexToAddress(req.To),
-		req.Value,
-		21000,                     // gas limit
-		big.NewInt(2_000_000_000), // 2 gwei gas price (simulation)
-		nil,                       // no data for simple transfer
-	)
+	// Simple value transfer, no calldata
+	return buildEVMTx(cfg, opts, common.HexToAddress(req.To), req.Value, 21000, nil)
+}
 
-	// Encode as RLP (unsigned)
-	rawTx, err := rlp.EncodeToBytes(tx)
+// BuildTokenTransfer builds an unsigned ERC-20 transfer transaction.
+func (e *EthereumBuilder) BuildTokenTransfer(req *TokenTransferRequest, opts BuildOptions) (*TxResult, error) {
+	cfg, err := evmChain(req.Chain)
 	if err != nil {
 		return nil, err
 	}
-
-	// Estimate fee
-	gasLimit := big.NewInt(21000)
-	gasPrice := big.NewInt(2_000_000_000)
-	fee := new(big.Int).Mul(gasLimit, gasPrice)
-
-	return &TxResult{
-		RawTx:        rawTx,
-		EstimatedFee: fee.Int64(),
-	}, nil
-}
-
-// BuildTokenTransfer builds an unsigned ERC-20 transfer transaction.
-// internal/chain/ethereum.go
-func (e *EthereumBuilder) BuildTokenTransfer(req *TokenTransferRequest, nonce uint64) (*TxResult, error) {
-	token, ok := tokens.GetTokenBySymbol(string(req.Chain), req.Token)
+	token, ok := tokens.GetTokenBySymbol(req.Chain, req.Token)
 	if !ok {
 		return nil, fmt.Errorf("unsupported token: %s on %s", req.Token, req.Chain)
 	}
 	if token.IsNative() {
 		return nil, errors.New("use BuildTx for native coins")
 	}
+	if !common.IsHexAddress(req.From) {
+		return nil, fmt.Errorf("invalid from address: %w", ErrInvalidAddress)
+	}
+	if !common.IsHexAddress(req.To) {
+		return nil, fmt.Errorf("invalid to address: %w", ErrInvalidAddress)
+	}
 
-	amount, err := token.ParseAmount(req.AmountStr)
-	if err != nil {
-		return nil, err
+	amount := req.Amount
+	if amount == nil {
+		var err error
+		amount, err = token.ParseAmount(req.AmountStr)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Build ERC-20 transfer calldata
@@ -80,48 +85,167 @@ func (e *EthereumBuilder) BuildTokenTransfer(req *TokenTransferRequest, nonce ui
 		return nil, err
 	}
 
-	// Create transaction to token contract
-	tx := types.NewTransaction(
-		nonce,
-		token.Contract,
-		big.NewInt(0),
-		65000, // gas limit for ERC-20 transfer
-		GetGasPrice(req.Chain),
-		calldata,
+	// Transaction to token contract, 65000 gas limit for ERC-20 transfer
+	return buildEVMTx(cfg, opts, token.Contract, big.NewInt(0), 65000, calldata)
+}
+
+// buildEVMTx creates an unsigned transaction using the fee model selected for the chain
+// and returns it RLP-encoded with the worst-case fee (gas limit × max price per gas).
+func buildEVMTx(cfg EVMConfig, opts BuildOptions, to common.Address, value *big.Int, gasLimit uint64, data []byte) (*TxResult, error) {
+	var (
+		tx       *types.Transaction
+		maxPrice *big.Int
 	)
 
+	switch feeModel(cfg, opts) {
+	case FeeModelLegacy:
+		maxPrice = opts.GasPrice
+		if maxPrice == nil {
+			maxPrice = cfg.GasPrice
+		}
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    opts.Nonce,
+			GasPrice: maxPrice,
+			Gas:      gasLimit,
+			To:       &to,
+			Value:    value,
+			Data:     data,
+		})
+
+	case FeeModelDynamic:
+		maxPrice = opts.GasFeeCap
+		if maxPrice == nil {
+			maxPrice = cfg.GasPrice
+		}
+		tip := opts.GasTipCap
+		if tip == nil {
+			tip = defaultGasTipCap
+		}
+		if tip.Cmp(maxPrice) > 0 {
+			return nil, fmt.Errorf("priority fee %s exceeds max fee %s: %w", tip, maxPrice, ErrInvalidFee)
+		}
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   cfg.ChainID,
+			Nonce:     opts.Nonce,
+			GasTipCap: tip,
+			GasFeeCap: maxPrice,
+			Gas:       gasLimit,
+			To:        &to,
+			Value:     value,
+			Data:      data,
+		})
+
+	default:
+		return nil, fmt.Errorf("unknown fee model for %s", cfg.Chain)
+	}
+
+	// Encode as RLP (unsigned); typed transactions are wrapped as an RLP string
 	rawTx, err := rlp.EncodeToBytes(tx)
 	if err != nil {
 		return nil, err
 	}
 
-	fee := new(big.Int).Mul(big.NewInt(65000), GetGasPrice(req.Chain))
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), maxPrice)
 	return &TxResult{
+		Chain:        cfg.Chain,
 		RawTx:        rawTx,
 		EstimatedFee: fee.Int64(),
 	}, nil
 }
 
-// GetChainID returns the correct chain ID for EVM chains.
-func GetChainID(chainType Chain) *big.Int {
-	switch chainType {
-	case EthereumSepolia:
-		return big.NewInt(11155111) // Sepolia
-	case AvalancheFuji:
-		return big.NewInt(43113) // Fuji Testnet
-	default:
-		return big.NewInt(1) // mainnet fallback
+// SigHashes returns the single transaction hash to sign.
+func (e *EthereumBuilder) SigHashes(tx *TxResult) ([][]byte, error) {
+	cfg, err := evmChain(tx.Chain)
+	if err != nil {
+		return nil, err
 	}
+	hash, err := EthereumSigHash(tx.RawTx, cfg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{hash}, nil
 }
 
-// GetGasPrice returns a simulated gas price (in wei).
-func GetGasPrice(chainType Chain) *big.Int {
-	switch chainType {
-	case AvalancheFuji:
-		return big.NewInt(25_000_000_000) // 25 gwei (Avalanche is cheaper)
-	default:
-		return big.NewInt(2_000_000_000) // 2 gwei
+// Finalize applies the signature and returns the broadcast encoding and transaction hash.
+func (e *EthereumBuilder) Finalize(tx *TxResult, sigs []Signature) (*SignedTx, error) {
+	if len(sigs) != 1 {
+		return nil, ErrSignatureCount
+	}
+	cfg, err := evmChain(tx.Chain)
+	if err != nil {
+		return nil, err
+	}
+	raw, hash, err := AssembleEthereumTx(tx.RawTx, sigs[0].Sig, cfg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedTx{RawTx: raw, TxID: hash.Hex()}, nil
+}
+
+// feeModel returns the fee model to use, honouring a per-request override.
+func feeModel(cfg EVMConfig, opts BuildOptions) FeeModel {
+	if opts.FeeModel != nil {
+		return *opts.FeeModel
+	}
+	return cfg.FeeModel
+}
+
+// EthereumSigHash decodes an unsigned transaction and returns the hash to sign.
+// The London signer covers legacy EIP-155 as well as EIP-1559 transactions.
+func EthereumSigHash(rawTx []byte, chainID *big.Int) ([]byte, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(rawTx, tx); err != nil {
+		return nil, err
+	}
+	return types.NewLondonSigner(chainID).Hash(tx).Bytes(), nil
+}
+
+// AssembleEthereumTx applies a 65-byte [R || S || V] signature (V = 0/1) to an unsigned
+// transaction. It returns the broadcast-ready encoding (typed envelope for EIP-1559) and
+// the transaction hash.
+func AssembleEthereumTx(rawTx, sig []byte, chainID *big.Int) ([]byte, common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(rawTx, tx); err != nil {
+		return nil, common.Hash{}, err
+	}
+	signed, err := tx.WithSignature(types.NewLondonSigner(chainID), sig)
+	if err != nil {
+		return nil, common.Hash{}, fmt.Errorf("apply signature: %w", err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	return raw, signed.Hash(), nil
+}
+
+// GetChainID returns the chain ID of an EVM network. Unknown chains are an
+// error rather than a default, which would defeat EIP-155 replay protection.
+func GetChainID(chainType Chain) (*big.Int, error) {
+	cfg, err := LookupEVMChain(chainType)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.ChainID, nil
+}
+
+// GetGasPrice returns the configured default gas price (in wei).
+// For EIP-1559 chains it is used as the default max fee per gas.
+func GetGasPrice(chainType Chain) (*big.Int, error) {
+	cfg, err := LookupEVMChain(chainType)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GasPrice, nil
+}
+
+// GetFeeModel returns the default fee model for an EVM chain.
+func GetFeeModel(chainType Chain) (FeeModel, error) {
+	cfg, err := LookupEVMChain(chainType)
+	if err != nil {
+		return 0, err
 	}
+	return cfg.FeeModel, nil
 }
 
 var erc20ABIJson = []byte(`[
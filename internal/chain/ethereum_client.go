@@ -0,0 +1,151 @@
+// ethereum_client.go
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EthereumRPCClient is an EVMClient over the standard Ethereum JSON-RPC API.
+// It works against any EVM node (Geth, Avalanche C-Chain, hosted providers).
+type EthereumRPCClient struct {
+	rpc *rpcClient
+}
+
+var _ EVMClient = (*EthereumRPCClient)(nil)
+
+// NewEthereumRPCClient returns a client for the node at url. A nil httpClient
+// uses http.DefaultClient.
+func NewEthereumRPCClient(url string, httpClient *http.Client) *EthereumRPCClient {
+	return &EthereumRPCClient{rpc: newRPCClient(url, httpClient)}
+}
+
+func (c *EthereumRPCClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	var balance hexutil.Big
+	if err := c.rpc.call(ctx, &balance, "eth_getBalance", address, "latest"); err != nil {
+		return nil, err
+	}
+	return balance.ToInt(), nil
+}
+
+func (c *EthereumRPCClient) PendingNonce(ctx context.Context, address string) (uint64, error) {
+	var nonce hexutil.Uint64
+	if err := c.rpc.call(ctx, &nonce, "eth_getTransactionCount", address, "pending"); err != nil {
+		return 0, err
+	}
+	return uint64(nonce), nil
+}
+
+func (c *EthereumRPCClient) SuggestFees(ctx context.Context) (*EVMFees, error) {
+	var gasPrice, tip hexutil.Big
+	if err := c.rpc.call(ctx, &gasPrice, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	if err := c.rpc.call(ctx, &tip, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	var head struct {
+		BaseFee *hexutil.Big `json:"baseFeePerGas"`
+	}
+	if err := c.rpc.call(ctx, &head, "eth_getBlockByNumber", "latest", false); err != nil {
+		return nil, err
+	}
+	fees := &EVMFees{GasPrice: gasPrice.ToInt(), GasTipCap: tip.ToInt()}
+	if head.BaseFee != nil {
+		fees.BaseFee = head.BaseFee.ToInt()
+	}
+	return fees, nil
+}
+
+func (c *EthereumRPCClient) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	var hash string
+	err := c.rpc.call(ctx, &hash, "eth_sendRawTransaction", hexutil.Encode(rawTx))
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		// Nodes report every pool error under one code; only the message tells them apart
+		msg := strings.ToLower(rpcErr.Message)
+		switch {
+		case containsAny(msg, evmKnownTxErrors):
+			// The typed transaction envelope hashes to the transaction hash
+			return crypto.Keccak256Hash(rawTx).Hex(), nil
+		case strings.Contains(msg, "nonce too low"):
+			return "", fmt.Errorf("%w: %s", ErrInputsSpent, rpcErr.Message)
+		case containsAny(msg, evmRejectedTxErrors):
+			return "", fmt.Errorf("%w: %s", ErrTxRejected, rpcErr.Message)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// Pool errors, lowercased, as Geth, Erigon, Nethermind and Avalanche word them.
+var (
+	// evmKnownTxErrors mean the node already has the transaction.
+	evmKnownTxErrors = []string{"already known", "known transaction", "alreadyknown"}
+	// evmRejectedTxErrors mean the transaction can never be accepted as signed.
+	evmRejectedTxErrors = []string{
+		"insufficient funds",
+		"intrinsic gas too low",
+		"invalid sender",
+		"exceeds block gas limit",
+		"transaction type not supported",
+		"oversized data",
+		"max priority fee per gas higher than max fee per gas",
+		"invalid chain id",
+	}
+)
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *EthereumRPCClient) TxStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	var receipt *struct {
+		BlockHash   string         `json:"blockHash"`
+		BlockNumber hexutil.Uint64 `json:"blockNumber"`
+		Status      hexutil.Uint64 `json:"status"`
+	}
+	if err := c.rpc.call(ctx, &receipt, "eth_getTransactionReceipt", txID); err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		// No receipt: either pending in the mempool or unknown
+		var tx json.RawMessage
+		if err := c.rpc.call(ctx, &tx, "eth_getTransactionByHash", txID); err != nil {
+			return nil, err
+		}
+		if len(tx) == 0 || string(tx) == "null" {
+			return nil, ErrTxNotFound
+		}
+		return &TxStatus{}, nil
+	}
+
+	var head hexutil.Uint64
+	if err := c.rpc.call(ctx, &head, "eth_blockNumber"); err != nil {
+		return nil, err
+	}
+	status := &TxStatus{
+		BlockHash:   receipt.BlockHash,
+		BlockHeight: uint64(receipt.BlockNumber),
+		Failed:      receipt.Status == 0,
+	}
+	if uint64(head) >= status.BlockHeight {
+		status.Confirmations = uint64(head) - status.BlockHeight + 1
+	}
+	return status, nil
+}
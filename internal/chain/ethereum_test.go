@@ -5,6 +5,7 @@ import (
 	"andi-custodian/pkg/tokens"
 	"errors"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 	"math/big"
@@ -75,7 +76,7 @@ func TestEthereumBuilder_BuildTokenTransfer(t *testing.T) {
 		ID:        "token-tx-1",
 	}
 
-	result, err := builder.BuildTokenTransfer(req, 5) // nonce = 5
+	result, err := builder.BuildTokenTransfer(req, BuildOptions{Nonce: 5})
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.NotEmpty(t, result.RawTx, "RawTx should not be empty")
@@ -86,12 +87,15 @@ func TestEthereumBuilder_BuildTokenTransfer(t *testing.T) {
 	tx, err := decodeTransaction(result.RawTx)
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(5), tx.Nonce())
-	assert.Equal(t, expectedContract, tx.To(), "Transaction should be sent to token contract")
+	assert.Equal(t, expectedContract, *tx.To(), "Transaction should be sent to token contract")
 	assert.Equal(t, big.NewInt(0), tx.Value(), "Token transfers have 0 ETH value")
 
-	// Validate gas limit and price
+	// Validate gas limit and max fee (Sepolia defaults to EIP-1559)
+	assert.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
 	assert.Equal(t, uint64(65000), tx.Gas())
-	gasPrice := GetGasPrice(EthereumSepolia)
+	gasPrice, err := GetGasPrice(EthereumSepolia)
+	assert.NoError(t, err)
+	assert.Equal(t, gasPrice, tx.GasFeeCap())
 	expectedFee := new(big.Int).Mul(big.NewInt(65000), gasPrice)
 	assert.Equal(t, expectedFee.Int64(), result.EstimatedFee)
 }
@@ -106,11 +110,23 @@ func TestEthereumBuilder_BuildTokenTransfer_InvalidToken(t *testing.T) {
 		AmountStr: "1",
 	}
 
-	_, err := builder.BuildTokenTransfer(req, 0)
+	_, err := builder.BuildTokenTransfer(req, BuildOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported token")
 }
 
+func TestEthereumBuilder_BuildTokenTransfer_InvalidAddress(t *testing.T) {
+	builder := &EthereumBuilder{}
+	for _, req := range []*TokenTransferRequest{
+		{Chain: EthereumSepolia, From: EthereumSepoliaFrom, To: "", Token: "USDC", AmountStr: "1"},
+		{Chain: EthereumSepolia, From: EthereumSepoliaFrom, To: "0x742d35", Token: "USDC", AmountStr: "1"},
+		{Chain: EthereumSepolia, From: "0x123", To: EthereumSepoliaTo, Token: "USDC", AmountStr: "1"},
+	} {
+		_, err := builder.BuildTokenTransfer(req, BuildOptions{})
+		assert.ErrorIs(t, err, ErrInvalidAddress)
+	}
+}
+
 func TestEthereumBuilder_BuildTx_AvalancheFuji(t *testing.T) {
 	builder := &EthereumBuilder{}
 	req := &TxRequest{
@@ -126,7 +142,110 @@ func TestEthereumBuilder_BuildTx_AvalancheFuji(t *testing.T) {
 	assert.NotEmpty(t, result.RawTx)
 
 	// Fee should reflect Avalanche gas price (25 gwei)
-	assert.Equal(t, int64(525000), result.EstimatedFee) // 21000 * 25e9
+	assert.Equal(t, int64(525_000_000_000_000), result.EstimatedFee) // 21000 * 25e9
+
+	// Signed for Fuji's chain ID
+	tx := new(types.Transaction)
+	assert.NoError(t, rlp.DecodeBytes(result.RawTx, tx))
+	assert.Equal(t, big.NewInt(43113), tx.ChainId())
+	assert.Equal(t, uint64(5), tx.Nonce())
+}
+
+func TestEthereumBuilder_BuildTx_DynamicFee(t *testing.T) {
+	builder := &EthereumBuilder{}
+	req := &TxRequest{
+		Chain: EthereumSepolia,
+		From:  EthereumSepoliaFrom,
+		To:    EthereumSepoliaTo,
+		Value: big.NewInt(1_000),
+	}
+
+	opts := BuildOptions{
+		Nonce:     7,
+		GasFeeCap: big.NewInt(30_000_000_000),
+		GasTipCap: big.NewInt(2_000_000_000),
+	}
+	result, err := builder.BuildTx(req, opts)
+	assert.NoError(t, err)
+
+	tx, err := decodeTransaction(result.RawTx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+	assert.Equal(t, big.NewInt(11155111), tx.ChainId())
+	assert.Equal(t, opts.GasFeeCap, tx.GasFeeCap())
+	assert.Equal(t, opts.GasTipCap, tx.GasTipCap())
+	assert.Equal(t, int64(21000*30_000_000_000), result.EstimatedFee)
+}
+
+func TestEthereumBuilder_BuildTx_Legacy(t *testing.T) {
+	builder := &EthereumBuilder{}
+	req := &TxRequest{
+		Chain: EthereumSepolia,
+		From:  EthereumSepoliaFrom,
+		To:    EthereumSepoliaTo,
+		Value: big.NewInt(1_000),
+	}
+
+	legacy := FeeModelLegacy
+	result, err := builder.BuildTx(req, BuildOptions{FeeModel: &legacy, GasPrice: big.NewInt(3_000_000_000)})
+	assert.NoError(t, err)
+
+	tx, err := decodeTransaction(result.RawTx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(types.LegacyTxType), tx.Type())
+	assert.Equal(t, big.NewInt(3_000_000_000), tx.GasPrice())
+	assert.Equal(t, int64(21000*3_000_000_000), result.EstimatedFee)
+}
+
+func TestEthereumBuilder_BuildTx_TipAboveFeeCap(t *testing.T) {
+	builder := &EthereumBuilder{}
+	req := &TxRequest{
+		Chain: EthereumSepolia,
+		From:  EthereumSepoliaFrom,
+		To:    EthereumSepoliaTo,
+		Value: big.NewInt(1_000),
+	}
+
+	_, err := builder.BuildTx(req, BuildOptions{GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(2)})
+	assert.ErrorIs(t, err, ErrInvalidFee)
+}
+
+func TestAssembleEthereumTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	chainID, err := GetChainID(EthereumSepolia)
+	assert.NoError(t, err)
+
+	for _, model := range []FeeModel{FeeModelDynamic, FeeModelLegacy} {
+		model := model
+		result, err := (&EthereumBuilder{}).BuildTx(&TxRequest{
+			Chain: EthereumSepolia,
+			From:  from.Hex(),
+			To:    EthereumSepoliaTo,
+			Value: big.NewInt(42),
+		}, BuildOptions{Nonce: 3, FeeModel: &model})
+		assert.NoError(t, err)
+
+		hash, err := EthereumSigHash(result.RawTx, chainID)
+		assert.NoError(t, err)
+		sig, err := crypto.Sign(hash, key)
+		assert.NoError(t, err)
+
+		raw, txHash, err := AssembleEthereumTx(result.RawTx, sig, chainID)
+		assert.NoError(t, err)
+
+		// The broadcast encoding must decode and recover the sender
+		signed := new(types.Transaction)
+		assert.NoError(t, signed.UnmarshalBinary(raw))
+		assert.Equal(t, txHash, signed.Hash())
+		sender, err := types.Sender(types.NewLondonSigner(chainID), signed)
+		assert.NoError(t, err)
+		assert.Equal(t, from, sender)
+		if model == FeeModelDynamic {
+			assert.Equal(t, byte(types.DynamicFeeTxType), raw[0], "typed envelope prefix")
+		}
+	}
 }
 
 // decodeTransaction decodes RLP-encoded transaction bytes.
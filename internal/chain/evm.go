@@ -0,0 +1,69 @@
+// evm.go
+package chain
+
+import (
+	"fmt"
+	"math/big"
+
+	"andi-custodian/pkg/networks"
+	"andi-custodian/pkg/tokens"
+)
+
+// EVMConfig is what building, signing and monitoring need to know about an
+// EVM network. EthereumBuilder, the custody service's nonce handling and the
+// signer treat every network in the networks registry alike.
+type EVMConfig struct {
+	Chain         Chain
+	ChainID       *big.Int
+	FeeModel      FeeModel
+	GasPrice      *big.Int      // default gas price, or max fee per gas under EIP-1559, in wei
+	NativeToken   *tokens.Token // the coin gas is paid in
+	Confirmations uint64        // depth after which a transaction is treated as final
+}
+
+// LookupEVMChain returns the configuration of a registered EVM network, or an
+// error wrapping networks.ErrUnknownNetwork. There is no fallback: a transaction
+// signed for a guessed chain ID could be replayed on the wrong network.
+func LookupEVMChain(c Chain) (EVMConfig, error) {
+	n, err := networks.Get(string(c))
+	if err != nil {
+		return EVMConfig{}, err
+	}
+	native, ok := tokens.NativeToken(c)
+	if !ok {
+		return EVMConfig{}, fmt.Errorf("no native token for %s", n.Name)
+	}
+	model := FeeModelDynamic
+	if n.FeeStrategy == networks.FeeLegacy {
+		model = FeeModelLegacy
+	}
+	return EVMConfig{
+		Chain:         c,
+		ChainID:       new(big.Int).SetUint64(n.ChainID),
+		FeeModel:      model,
+		GasPrice:      n.GasPrice,
+		NativeToken:   native,
+		Confirmations: n.FinalityDepth,
+	}, nil
+}
+
+// EVMChain returns the configuration of an EVM network.
+func EVMChain(c Chain) (EVMConfig, bool) {
+	cfg, err := LookupEVMChain(c)
+	return cfg, err == nil
+}
+
+// IsEVM reports whether c is a registered EVM network.
+func IsEVM(c Chain) bool {
+	_, ok := EVMChain(c)
+	return ok
+}
+
+// evmChain is LookupEVMChain for builders, which reject other chains.
+func evmChain(c Chain) (EVMConfig, error) {
+	cfg, err := LookupEVMChain(c)
+	if err != nil {
+		return EVMConfig{}, fmt.Errorf("EthereumBuilder: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,74 @@
+// evm_test.go
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"andi-custodian/pkg/networks"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupEVMChain_Registered(t *testing.T) {
+	const amoy Chain = "polygon-amoy"
+	assert.False(t, IsEVM(amoy))
+
+	require.NoError(t, networks.Register(networks.Network{
+		Name:           string(amoy),
+		ChainID:        80002,
+		FeeStrategy:    networks.FeeLegacy,
+		GasPrice:       big.NewInt(30_000_000_000),
+		FinalityDepth:  20,
+		Testnet:        true,
+		NativeCurrency: networks.Currency{Name: "Polygon", Symbol: "POL", Decimals: 18},
+	}))
+	cfg, err := LookupEVMChain(amoy)
+	require.NoError(t, err)
+	assert.Equal(t, FeeModelLegacy, cfg.FeeModel)
+	assert.Equal(t, uint64(20), cfg.Confirmations)
+	assert.Equal(t, "POL", cfg.NativeToken.Symbol)
+
+	// The shared builder serves the new network end to end
+	builder, err := NewBuilder(amoy)
+	require.NoError(t, err)
+	tx, err := builder.BuildTx(&TxRequest{
+		Chain: amoy,
+		From:  EthereumSepoliaFrom,
+		To:    EthereumSepoliaTo,
+		Value: big.NewInt(1),
+	}, BuildOptions{Nonce: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(21000*30_000_000_000), tx.EstimatedFee)
+
+	key, _ := crypto.GenerateKey()
+	hashes, err := builder.SigHashes(tx)
+	require.NoError(t, err)
+	sig, _ := crypto.Sign(hashes[0], key)
+	signed, err := builder.Finalize(tx, []Signature{{Sig: sig}})
+	require.NoError(t, err)
+
+	decoded := new(types.Transaction)
+	require.NoError(t, decoded.UnmarshalBinary(signed.RawTx))
+	assert.Equal(t, big.NewInt(80002), decoded.ChainId())
+	sender, err := types.LatestSignerForChainID(big.NewInt(80002)).Sender(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), sender)
+}
+
+func TestLookupEVMChain_Unknown(t *testing.T) {
+	// No fallback chain ID: unknown networks are an error everywhere
+	_, err := GetChainID("ethereum-mainnet-typo")
+	assert.ErrorIs(t, err, networks.ErrUnknownNetwork)
+	_, err = GetGasPrice(BitcoinTestnet)
+	assert.ErrorIs(t, err, networks.ErrUnknownNetwork)
+	_, err = GetFeeModel(SolanaDevnet)
+	assert.ErrorIs(t, err, networks.ErrUnknownNetwork)
+
+	_, err = (&EthereumBuilder{}).BuildTx(&TxRequest{Chain: BitcoinTestnet, From: EthereumSepoliaFrom, To: EthereumSepoliaTo, Value: big.NewInt(1)}, BuildOptions{})
+	assert.ErrorIs(t, err, networks.ErrUnknownNetwork)
+	_, err = (&EthereumBuilder{}).SigHashes(&TxResult{Chain: "unknown"})
+	assert.ErrorIs(t, err, networks.ErrUnknownNetwork)
+}
@@ -0,0 +1,515 @@
+// fakenode.go
+// In-memory chain nodes for tests and offline demos. They are deterministic:
+// nothing is mined until Mine is called.
+package chain
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeLedger tracks blocks and transaction inclusion for the fake nodes.
+// Methods with a lowercase name expect the caller to hold mu.
+type fakeLedger struct {
+	mu      sync.Mutex
+	height  uint64
+	blocks  map[uint64]string // height -> block hash on the current chain
+	forks   uint64            // reorgs so far; makes replacement blocks hash differently
+	mined   map[string]uint64 // tx ID -> block height
+	pending []string          // tx IDs in the mempool, in arrival order
+	failed  map[string]bool
+}
+
+func (l *fakeLedger) init() {
+	if l.mined == nil {
+		l.mined = make(map[string]uint64)
+		l.failed = make(map[string]bool)
+		l.blocks = make(map[uint64]string)
+	}
+}
+
+// Mine includes every mempool transaction in the next block, then adds n-1
+// empty blocks on top.
+func (l *fakeLedger) Mine(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.init()
+	for i := 0; i < n; i++ {
+		l.height++
+		l.blocks[l.height] = fakeBlockHash(l.height, l.forks)
+		for _, id := range l.pending {
+			l.mined[id] = l.height
+		}
+		l.pending = nil
+	}
+}
+
+// Reorg orphans the top depth blocks. Their transactions go back to the
+// mempool, ahead of anything broadcast since, and are mined again by the next
+// Mine into blocks with new hashes.
+func (l *fakeLedger) Reorg(depth int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.init()
+	if uint64(depth) > l.height {
+		depth = int(l.height)
+	}
+	fork := l.height - uint64(depth)
+	var orphaned []string
+	for id, h := range l.mined {
+		if h > fork {
+			orphaned = append(orphaned, id)
+			delete(l.mined, id)
+		}
+	}
+	sort.Slice(orphaned, func(i, j int) bool { return orphaned[i] < orphaned[j] })
+	for h := fork + 1; h <= l.height; h++ {
+		delete(l.blocks, h)
+	}
+	l.pending = append(orphaned, l.pending...)
+	l.height = fork
+	l.forks++
+}
+
+// Height returns the current block height.
+func (l *fakeLedger) Height() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.height
+}
+
+func (l *fakeLedger) accept(id string) {
+	l.init()
+	l.pending = append(l.pending, id)
+}
+
+func (l *fakeLedger) isMined(id string) bool {
+	_, ok := l.mined[id]
+	return ok
+}
+
+func (l *fakeLedger) status(id string) (*TxStatus, error) {
+	l.init()
+	if h, ok := l.mined[id]; ok {
+		return &TxStatus{
+			Confirmations: l.height - h + 1,
+			BlockHash:     l.blocks[h],
+			BlockHeight:   h,
+			Failed:        l.failed[id],
+		}, nil
+	}
+	for _, p := range l.pending {
+		if p == id {
+			return &TxStatus{}, nil
+		}
+	}
+	return nil, ErrTxNotFound
+}
+
+func fakeBlockHash(height, fork uint64) string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], height)
+	binary.BigEndian.PutUint64(b[8:], fork)
+	sum := sha256.Sum256(b[:])
+	return fmt.Sprintf("%x", sum)
+}
+
+// drop removes a mempool transaction and reports whether it was there.
+func (l *fakeLedger) drop(id string) bool {
+	for i, p := range l.pending {
+		if p == id {
+			l.pending = append(l.pending[:i], l.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// FakeEVMNode is an in-memory EVMClient. It checks signatures, nonces and
+// balances on broadcast and moves value immediately.
+type FakeEVMNode struct {
+	fakeLedger
+	signer   types.Signer
+	balances map[common.Address]*big.Int
+	nonces   map[common.Address]uint64
+	Fees     EVMFees
+}
+
+var _ EVMClient = (*FakeEVMNode)(nil)
+
+// NewFakeEVMNode returns an empty node for the given chain ID.
+func NewFakeEVMNode(chainID *big.Int) *FakeEVMNode {
+	return &FakeEVMNode{
+		signer:   types.LatestSignerForChainID(chainID),
+		balances: make(map[common.Address]*big.Int),
+		nonces:   make(map[common.Address]uint64),
+		Fees: EVMFees{
+			GasPrice:  big.NewInt(2_000_000_000),
+			GasTipCap: big.NewInt(1_000_000_000),
+			BaseFee:   big.NewInt(1_000_000_000),
+		},
+	}
+}
+
+// SetBalance credits an address with wei.
+func (n *FakeEVMNode) SetBalance(address string, wei *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.balances[common.HexToAddress(address)] = new(big.Int).Set(wei)
+}
+
+func (n *FakeEVMNode) Balance(ctx context.Context, address string) (*big.Int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.balanceOf(common.HexToAddress(address)), nil
+}
+
+func (n *FakeEVMNode) balanceOf(addr common.Address) *big.Int {
+	if b, ok := n.balances[addr]; ok {
+		return new(big.Int).Set(b)
+	}
+	return new(big.Int)
+}
+
+func (n *FakeEVMNode) PendingNonce(ctx context.Context, address string) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.nonces[common.HexToAddress(address)], nil
+}
+
+func (n *FakeEVMNode) SuggestFees(ctx context.Context) (*EVMFees, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	fees := n.Fees
+	return &fees, nil
+}
+
+func (n *FakeEVMNode) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(rawTx); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTxRejected, err)
+	}
+	from, err := types.Sender(n.signer, tx)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid sender: %v", ErrTxRejected, err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if want := n.nonces[from]; tx.Nonce() != want {
+		return "", fmt.Errorf("%w: nonce %d, expected %d", ErrTxRejected, tx.Nonce(), want)
+	}
+	balance := n.balanceOf(from)
+	if balance.Cmp(tx.Cost()) < 0 {
+		return "", fmt.Errorf("%w: insufficient funds for gas * price + value", ErrTxRejected)
+	}
+
+	n.balances[from] = balance.Sub(balance, tx.Cost())
+	if to := tx.To(); to != nil {
+		n.balances[*to] = n.balanceOf(*to).Add(n.balanceOf(*to), tx.Value())
+	}
+	n.nonces[from]++
+	id := tx.Hash().Hex()
+	n.accept(id)
+	return id, nil
+}
+
+func (n *FakeEVMNode) TxStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.status(common.HexToHash(txID).Hex())
+}
+
+// FakeBitcoinNode is an in-memory BitcoinClient. Broadcast verifies every
+// input script against the output it spends, so a transaction it accepts
+// would be valid on the real network.
+type FakeBitcoinNode struct {
+	fakeLedger
+	params  *chaincfg.Params
+	outputs map[wire.OutPoint]fakeOutput
+	spends  map[chainhash.Hash]map[wire.OutPoint]fakeOutput // tx -> outputs it spent
+	funded  uint64
+	FeeRate int64 // sat/vB returned by EstimateFeeRate
+}
+
+type fakeOutput struct {
+	txOut   *wire.TxOut
+	address string
+}
+
+var _ BitcoinClient = (*FakeBitcoinNode)(nil)
+
+// NewFakeBitcoinNode returns an empty node for params' network.
+func NewFakeBitcoinNode(params *chaincfg.Params) *FakeBitcoinNode {
+	return &FakeBitcoinNode{
+		params:  params,
+		outputs: make(map[wire.OutPoint]fakeOutput),
+		spends:  make(map[chainhash.Hash]map[wire.OutPoint]fakeOutput),
+		FeeRate: DefaultFeeRate,
+	}
+}
+
+// Fund creates a confirmed output of value satoshis paying address.
+func (n *FakeBitcoinNode) Fund(address string, value int64) (UTXO, error) {
+	addr, err := btcutil.DecodeAddress(address, n.params)
+	if err != nil || !addr.IsForNet(n.params) {
+		return UTXO{}, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	script, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return UTXO{}, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.init()
+	n.funded++
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], n.funded)
+	hash := chainhash.DoubleHashH(seed[:])
+	op := wire.OutPoint{Hash: hash, Index: 0}
+	n.outputs[op] = fakeOutput{txOut: wire.NewTxOut(value, script), address: address}
+	n.mined[hash.String()] = n.height
+	return UTXO{TxID: hash.String(), VOut: 0, Value: value, PkScript: script}, nil
+}
+
+func (n *FakeBitcoinNode) Balance(ctx context.Context, address string) (*big.Int, error) {
+	utxos, err := n.ListUnspent(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return big.NewInt(sumValues(utxos)), nil
+}
+
+func (n *FakeBitcoinNode) ListUnspent(ctx context.Context, address string) ([]UTXO, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.init()
+	var utxos []UTXO
+	for op, out := range n.outputs {
+		if out.address != address || !n.isMined(op.Hash.String()) {
+			continue
+		}
+		utxos = append(utxos, UTXO{
+			TxID:     op.Hash.String(),
+			VOut:     op.Index,
+			Value:    out.txOut.Value,
+			PkScript: out.txOut.PkScript,
+		})
+	}
+	sort.Slice(utxos, func(i, j int) bool {
+		if utxos[i].TxID != utxos[j].TxID {
+			return utxos[i].TxID < utxos[j].TxID
+		}
+		return utxos[i].VOut < utxos[j].VOut
+	})
+	return utxos, nil
+}
+
+func (n *FakeBitcoinNode) EstimateFeeRate(ctx context.Context, confTarget int) (int64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.FeeRate, nil
+}
+
+func (n *FakeBitcoinNode) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	if err := msgTx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTxRejected, err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.init()
+
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(msgTx.TxIn))
+	var in, out int64
+	for _, txIn := range msgTx.TxIn {
+		prev, ok := n.outputs[txIn.PreviousOutPoint]
+		if !ok {
+			return "", fmt.Errorf("%w: missing or spent input %v", ErrTxRejected, txIn.PreviousOutPoint)
+		}
+		prevOuts[txIn.PreviousOutPoint] = prev.txOut
+		in += prev.txOut.Value
+	}
+	for _, txOut := range msgTx.TxOut {
+		out += txOut.Value
+	}
+	if out > in {
+		return "", fmt.Errorf("%w: outputs exceed inputs", ErrTxRejected)
+	}
+
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(msgTx, fetcher)
+	for i, txIn := range msgTx.TxIn {
+		prev := prevOuts[txIn.PreviousOutPoint]
+		vm, err := txscript.NewEngine(prev.PkScript, msgTx, i, txscript.StandardVerifyFlags,
+			nil, sigHashes, prev.Value, fetcher)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: input %d: %v", ErrTxRejected, i, err)
+		}
+	}
+
+	txHash := msgTx.TxHash()
+	spent := make(map[wire.OutPoint]fakeOutput, len(msgTx.TxIn))
+	for _, txIn := range msgTx.TxIn {
+		spent[txIn.PreviousOutPoint] = n.outputs[txIn.PreviousOutPoint]
+		delete(n.outputs, txIn.PreviousOutPoint)
+	}
+	n.spends[txHash] = spent
+	for i, txOut := range msgTx.TxOut {
+		var address string
+		if _, addrs, _, err := txscript.ExtractPkScriptAddrs(txOut.PkScript, n.params); err == nil && len(addrs) == 1 {
+			address = addrs[0].EncodeAddress()
+		}
+		n.outputs[wire.OutPoint{Hash: txHash, Index: uint32(i)}] = fakeOutput{txOut: txOut, address: address}
+	}
+	n.accept(txHash.String())
+	return txHash.String(), nil
+}
+
+// Drop evicts a mempool transaction as if it had expired: its outputs vanish
+// and the outputs it spent become unspent again.
+func (n *FakeBitcoinNode) Drop(txID string) error {
+	hash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.drop(txID) {
+		return ErrTxNotFound
+	}
+	for op := range n.outputs {
+		if op.Hash == *hash {
+			delete(n.outputs, op)
+		}
+	}
+	for op, out := range n.spends[*hash] {
+		n.outputs[op] = out
+	}
+	delete(n.spends, *hash)
+	return nil
+}
+
+func (n *FakeBitcoinNode) TxStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.status(txID)
+}
+
+// FakeSolanaNode is an in-memory SolanaClient. Broadcast checks the signature
+// envelope and rejects replays; it does not execute instructions.
+type FakeSolanaNode struct {
+	fakeLedger
+	balances        map[string]uint64
+	accounts        map[string]bool
+	Blockhash       string // returned by LatestBlockhash
+	FeePerSignature uint64
+}
+
+var _ SolanaClient = (*FakeSolanaNode)(nil)
+
+// NewFakeSolanaNode returns an empty cluster with a fixed blockhash.
+func NewFakeSolanaNode() *FakeSolanaNode {
+	hash := sha256.Sum256([]byte("fake solana blockhash"))
+	return &FakeSolanaNode{
+		balances:        make(map[string]uint64),
+		accounts:        make(map[string]bool),
+		Blockhash:       base58.Encode(hash[:]),
+		FeePerSignature: 5000,
+	}
+}
+
+// SetBalance credits an account with lamports.
+func (n *FakeSolanaNode) SetBalance(address string, lamports uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.balances[address] = lamports
+	n.accounts[address] = true
+}
+
+// CreateAccount marks an account, such as a token account, as existing.
+func (n *FakeSolanaNode) CreateAccount(address string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.accounts[address] = true
+}
+
+func (n *FakeSolanaNode) AccountExists(ctx context.Context, address string) (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.accounts[address], nil
+}
+
+func (n *FakeSolanaNode) Balance(ctx context.Context, address string) (*big.Int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return new(big.Int).SetUint64(n.balances[address]), nil
+}
+
+func (n *FakeSolanaNode) LatestBlockhash(ctx context.Context) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.Blockhash, nil
+}
+
+func (n *FakeSolanaNode) FeeForMessage(ctx context.Context, message []byte) (uint64, error) {
+	sigs, err := solanaRequiredSignatures(message)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrTxRejected, err)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return uint64(sigs) * n.FeePerSignature, nil
+}
+
+func (n *FakeSolanaNode) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	count, used, err := readCompactU16(rawTx)
+	if err != nil || count == 0 {
+		return "", fmt.Errorf("%w: missing signatures", ErrTxRejected)
+	}
+	sigEnd := used + count*ed25519.SignatureSize
+	if len(rawTx) <= sigEnd {
+		return "", fmt.Errorf("%w: truncated transaction", ErrTxRejected)
+	}
+	id := base58.Encode(rawTx[used : used+ed25519.SignatureSize])
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, err := n.status(id); err == nil {
+		return "", fmt.Errorf("%w: already processed", ErrTxRejected)
+	}
+	n.accept(id)
+	return id, nil
+}
+
+func (n *FakeSolanaNode) TxStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	status, err := n.status(txID)
+	if err != nil {
+		return nil, err
+	}
+	status.Finalized = status.Confirmations >= solanaRootDepth
+	return status, nil
+}
@@ -0,0 +1,206 @@
+// fakenode_test.go
+package chain
+
+import (
+	"context"
+	"crypto/ed25519"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeEVMNode_Broadcast(t *testing.T) {
+	ctx := context.Background()
+	chainID := big.NewInt(11155111)
+	node := NewFakeEVMNode(chainID)
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x742d35Cc6634C0532925a3b844Bc9dbd8b5E8a18")
+	node.SetBalance(from.Hex(), big.NewInt(1e18))
+
+	signer := types.LatestSignerForChainID(chainID)
+	send := func(nonce uint64) (string, error) {
+		tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID: chainID, Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10),
+			Gas: 21000, To: &to, Value: big.NewInt(1000),
+		})
+		raw, _ := tx.MarshalBinary()
+		return node.Broadcast(ctx, raw)
+	}
+
+	// Out-of-order nonces are rejected
+	_, err := send(1)
+	assert.ErrorIs(t, err, ErrTxRejected)
+
+	txID, err := send(0)
+	require.NoError(t, err)
+	nonce, _ := node.PendingNonce(ctx, from.Hex())
+	assert.Equal(t, uint64(1), nonce)
+	received, _ := node.Balance(ctx, to.Hex())
+	assert.Equal(t, int64(1000), received.Int64())
+
+	status, err := node.TxStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Zero(t, status.Confirmations)
+
+	node.Mine(3)
+	status, err = node.TxStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), status.Confirmations)
+	assert.Equal(t, uint64(1), status.BlockHeight)
+
+	_, err = node.TxStatus(ctx, common.Hash{}.Hex())
+	assert.ErrorIs(t, err, ErrTxNotFound)
+}
+
+func TestFakeBitcoinNode_Broadcast(t *testing.T) {
+	ctx := context.Background()
+	node := NewFakeBitcoinNode(&chaincfg.TestNet3Params)
+
+	privKey, _ := btcec.NewPrivateKey()
+	pubKey := privKey.PubKey().SerializeCompressed()
+	fromAddr, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+	from := fromAddr.EncodeAddress()
+	_, err := node.Fund(from, 1_000_000)
+	require.NoError(t, err)
+
+	utxos, err := node.ListUnspent(ctx, from)
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+
+	builder := &BitcoinBuilder{}
+	tx, err := builder.BuildTx(&TxRequest{
+		Chain: BitcoinTestnet,
+		From:  from,
+		To:    mustCreateTestnetAddress(),
+		Value: big.NewInt(400_000),
+	}, BuildOptions{UTXOs: utxos})
+	require.NoError(t, err)
+	hashes, _ := builder.SigHashes(tx)
+
+	// A bad signature is refused
+	wrongKey, _ := btcec.NewPrivateKey()
+	bad, _ := builder.Finalize(tx, []Signature{{Sig: ecdsa.Sign(wrongKey, hashes[0]).Serialize(), PubKey: pubKey}})
+	_, err = node.Broadcast(ctx, bad.RawTx)
+	assert.ErrorIs(t, err, ErrTxRejected)
+
+	signed, _ := builder.Finalize(tx, []Signature{{Sig: ecdsa.Sign(privKey, hashes[0]).Serialize(), PubKey: pubKey}})
+	txID, err := node.Broadcast(ctx, signed.RawTx)
+	require.NoError(t, err)
+	assert.Equal(t, signed.TxID, txID)
+
+	// The input is spent; the change is unconfirmed until mined
+	_, err = node.Broadcast(ctx, signed.RawTx)
+	assert.ErrorIs(t, err, ErrTxRejected)
+	utxos, _ = node.ListUnspent(ctx, from)
+	assert.Empty(t, utxos)
+
+	node.Mine(1)
+	balance, err := node.Balance(ctx, from)
+	require.NoError(t, err)
+	assert.Equal(t, 1_000_000-400_000-tx.EstimatedFee, balance.Int64())
+	status, err := node.TxStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), status.Confirmations)
+}
+
+func TestFakeSolanaNode_Broadcast(t *testing.T) {
+	ctx := context.Background()
+	node := NewFakeSolanaNode()
+	_, priv, _ := ed25519.GenerateKey(nil)
+
+	message := []byte{1, 0, 1, 0xaa}
+	sig := ed25519.Sign(priv, message)
+	signed, err := (&SolanaBuilder{}).Finalize(&TxResult{RawTx: message}, []Signature{{Sig: sig}})
+	require.NoError(t, err)
+
+	fee, err := node.FeeForMessage(ctx, message)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5000), fee)
+
+	txID, err := node.Broadcast(ctx, signed.RawTx)
+	require.NoError(t, err)
+	assert.Equal(t, signed.TxID, txID)
+	_, err = node.Broadcast(ctx, signed.RawTx)
+	assert.ErrorIs(t, err, ErrTxRejected)
+
+	node.Mine(solanaRootDepth)
+	status, err := node.TxStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.True(t, status.Finalized)
+}
+
+func TestFakeLedger_Reorg(t *testing.T) {
+	ctx := context.Background()
+	node := NewFakeSolanaNode()
+	_, priv, _ := ed25519.GenerateKey(nil)
+	message := []byte{1, 0, 1, 0xbb}
+	signed, _ := (&SolanaBuilder{}).Finalize(&TxResult{RawTx: message}, []Signature{{Sig: ed25519.Sign(priv, message)}})
+	txID, err := node.Broadcast(ctx, signed.RawTx)
+	require.NoError(t, err)
+
+	node.Mine(2)
+	before, err := node.TxStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), before.Confirmations)
+
+	// Orphaning both blocks returns the transaction to the mempool
+	node.Reorg(2)
+	assert.Equal(t, uint64(0), node.Height())
+	status, err := node.TxStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Zero(t, status.Confirmations)
+
+	node.Mine(1)
+	after, err := node.TxStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Equal(t, before.BlockHeight, after.BlockHeight)
+	assert.NotEqual(t, before.BlockHash, after.BlockHash)
+}
+
+func TestFakeBitcoinNode_Drop(t *testing.T) {
+	ctx := context.Background()
+	node := NewFakeBitcoinNode(&chaincfg.TestNet3Params)
+
+	privKey, _ := btcec.NewPrivateKey()
+	pubKey := privKey.PubKey().SerializeCompressed()
+	fromAddr, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+	from := fromAddr.EncodeAddress()
+	_, err := node.Fund(from, 1_000_000)
+	require.NoError(t, err)
+	utxos, _ := node.ListUnspent(ctx, from)
+
+	builder := &BitcoinBuilder{}
+	tx, err := builder.BuildTx(&TxRequest{
+		Chain: BitcoinTestnet,
+		From:  from,
+		To:    mustCreateTestnetAddress(),
+		Value: big.NewInt(400_000),
+	}, BuildOptions{UTXOs: utxos})
+	require.NoError(t, err)
+	hashes, _ := builder.SigHashes(tx)
+	signed, _ := builder.Finalize(tx, []Signature{{Sig: ecdsa.Sign(privKey, hashes[0]).Serialize(), PubKey: pubKey}})
+	txID, err := node.Broadcast(ctx, signed.RawTx)
+	require.NoError(t, err)
+
+	require.NoError(t, node.Drop(txID))
+	_, err = node.TxStatus(ctx, txID)
+	assert.ErrorIs(t, err, ErrTxNotFound)
+	assert.ErrorIs(t, node.Drop(txID), ErrTxNotFound)
+
+	// The funding output is spendable again, so the same transaction is accepted
+	utxos, _ = node.ListUnspent(ctx, from)
+	assert.Len(t, utxos, 1)
+	_, err = node.Broadcast(ctx, signed.RawTx)
+	assert.NoError(t, err)
+}
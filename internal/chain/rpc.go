@@ -0,0 +1,96 @@
+// rpc.go
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// RPCError is an error object returned by a JSON-RPC server.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"` // server-specific detail
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// rpcClient speaks JSON-RPC over HTTP. Bitcoin Core, EVM nodes and Solana
+// validators all accept the same request envelope.
+type rpcClient struct {
+	url        string
+	user, pass string // HTTP basic auth, used by Bitcoin Core
+	http       *http.Client
+	nextID     atomic.Uint64
+}
+
+func newRPCClient(url string, httpClient *http.Client) *rpcClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &rpcClient{url: url, http: httpClient}
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// call invokes method and decodes the result into out, which may be nil.
+func (c *rpcClient) call(ctx context.Context, out any, method string, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: c.nextID.Add(1), Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.pass)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	// Bitcoin Core reports RPC errors with a non-200 status and a JSON body
+	var res rpcResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return fmt.Errorf("%s: http %d: %w", method, resp.StatusCode, err)
+	}
+	if res.Error != nil {
+		return fmt.Errorf("%s: %w", method, res.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(res.Result, out); err != nil {
+		return fmt.Errorf("%s: decode result: %w", method, err)
+	}
+	return nil
+}
@@ -2,31 +2,134 @@
 package chain
 
 import (
+	"crypto/ed25519"
 	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
 )
 
-// SolanaBuilder constructs unsigned Solana transactions (mock for simulation).
+// solanaLamportsPerSignature is the base fee the cluster charges per transaction signature.
+const solanaLamportsPerSignature = 5000
+
+// SolanaBuilder constructs unsigned Solana transactions.
 type SolanaBuilder struct{}
 
-// BuildTx returns a mock Solana transaction message.
-// In production, this would serialize a real Solana Message using solana-go.
+// BuildTx compiles a System Program transfer into a message signed by req.From,
+// which also pays the fee. The message is v0 when opts.LookupTables is set.
 func (s *SolanaBuilder) BuildTx(req *TxRequest, opts BuildOptions) (*TxResult, error) {
 	if req.Chain != SolanaDevnet {
 		return nil, errors.New("SolanaBuilder: invalid chain")
 	}
 
-	// Validate addresses (basic length check)
-	if len(req.From) != 44 || len(req.To) != 44 {
-		return nil, errors.New("invalid Solana address length")
+	from, err := ParseSolanaPublicKey(req.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	to, err := ParseSolanaPublicKey(req.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to address: %w", err)
+	}
+	if req.Value == nil || req.Value.Sign() <= 0 || !req.Value.IsUint64() {
+		return nil, fmt.Errorf("value out of range: %w", ErrInvalidAmount)
 	}
 
-	// For simulation: return a deterministic mock message
-	// In production: build real transaction with instructions, recent blockhash, etc.
-	mockMsg := []byte("solana-tx-mock-" + req.ID)
+	return buildSolanaTx(req.Chain, from, []SolanaInstruction{
+		SolanaTransferInstruction(from, to, req.Value.Uint64()),
+	}, opts)
+}
 
-	// Estimated fee: 5000 lamports (standard for simple transfer)
+// buildSolanaTx compiles instructions paid for by payer into an unsigned
+// transaction anchored to opts.RecentBlockhash.
+func buildSolanaTx(c Chain, payer SolanaPublicKey, instructions []SolanaInstruction, opts BuildOptions) (*TxResult, error) {
+	blockhash, err := ParseSolanaPublicKey(opts.RecentBlockhash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recent blockhash %q", opts.RecentBlockhash)
+	}
+	msg, err := CompileSolanaMessage(payer, instructions, blockhash, opts.LookupTables)
+	if err != nil {
+		return nil, err
+	}
 	return &TxResult{
-		RawTx:        mockMsg,
-		EstimatedFee: 5000,
+		Chain:        c,
+		RawTx:        msg.Serialize(),
+		EstimatedFee: int64(msg.Header.NumRequiredSignatures) * solanaLamportsPerSignature,
+	}, nil
+}
+
+// SigHashes returns the message once per required signature: Ed25519 signs the
+// serialized message, not a digest.
+func (s *SolanaBuilder) SigHashes(tx *TxResult) ([][]byte, error) {
+	n, err := solanaRequiredSignatures(tx.RawTx)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([][]byte, n)
+	for i := range hashes {
+		hashes[i] = tx.RawTx
+	}
+	return hashes, nil
+}
+
+// Finalize prepends the signatures to the message: compact-u16 count || signatures || message.
+// The transaction ID is the base58-encoded first signature.
+func (s *SolanaBuilder) Finalize(tx *TxResult, sigs []Signature) (*SignedTx, error) {
+	n, err := solanaRequiredSignatures(tx.RawTx)
+	if err != nil {
+		return nil, err
+	}
+	if len(sigs) == 0 || len(sigs) != n {
+		return nil, ErrSignatureCount
+	}
+
+	raw := appendCompactU16(nil, len(sigs))
+	for _, sig := range sigs {
+		if len(sig.Sig) != ed25519.SignatureSize {
+			return nil, errors.New("invalid Ed25519 signature length")
+		}
+		raw = append(raw, sig.Sig...)
+	}
+	raw = append(raw, tx.RawTx...)
+
+	return &SignedTx{
+		RawTx: raw,
+		TxID:  base58.Encode(sigs[0].Sig),
 	}, nil
 }
+
+// appendCompactU16 appends Solana's compact-u16 (shortvec) encoding of n.
+func appendCompactU16(b []byte, n int) []byte {
+	for {
+		elem := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(b, elem)
+		}
+		b = append(b, elem|0x80)
+	}
+}
+
+// solanaSignature returns a signed transaction's ID: its first signature.
+func solanaSignature(rawTx []byte) (string, error) {
+	count, used, err := readCompactU16(rawTx)
+	if err != nil || count == 0 || len(rawTx) < used+ed25519.SignatureSize {
+		return "", fmt.Errorf("%w: missing signatures", ErrTxRejected)
+	}
+	return base58.Encode(rawTx[used : used+ed25519.SignatureSize]), nil
+}
+
+// readCompactU16 decodes a compact-u16 from the start of b and returns the
+// value and the number of bytes it used.
+func readCompactU16(b []byte) (int, int, error) {
+	var n int
+	for i := 0; i < 3; i++ {
+		if i >= len(b) {
+			return 0, 0, errors.New("truncated compact-u16")
+		}
+		n |= int(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return n, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("compact-u16 too long")
+}
@@ -0,0 +1,147 @@
+// solana_client.go
+package chain
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+)
+
+// Solana JSON-RPC error codes the client maps onto chain errors. Anything
+// else, such as an unhealthy node (-32005) or a rate limit, may pass.
+const (
+	solanaRPCPreflightFailure = -32002 // simulation failed; data.err says why
+	solanaRPCSignatureFailure = -32003 // signature verification failed
+	solanaRPCInvalidParams    = -32602 // malformed transaction
+)
+
+// solanaRootDepth is reported as the confirmation count of rooted
+// transactions, for which the cluster stops counting (MAX_LOCKOUT_HISTORY).
+const solanaRootDepth = 32
+
+// SolanaRPCClient is a SolanaClient over the Solana JSON-RPC API.
+type SolanaRPCClient struct {
+	rpc *rpcClient
+}
+
+var _ SolanaClient = (*SolanaRPCClient)(nil)
+
+// NewSolanaRPCClient returns a client for the cluster endpoint at url. A nil
+// httpClient uses http.DefaultClient.
+func NewSolanaRPCClient(url string, httpClient *http.Client) *SolanaRPCClient {
+	return &SolanaRPCClient{rpc: newRPCClient(url, httpClient)}
+}
+
+// solanaContext wraps results of RPC methods that report the slot they were evaluated at.
+type solanaContext[T any] struct {
+	Value T `json:"value"`
+}
+
+func (c *SolanaRPCClient) Balance(ctx context.Context, address string) (*big.Int, error) {
+	var res solanaContext[uint64]
+	if err := c.rpc.call(ctx, &res, "getBalance", address); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(res.Value), nil
+}
+
+func (c *SolanaRPCClient) LatestBlockhash(ctx context.Context) (string, error) {
+	var res solanaContext[struct {
+		Blockhash string `json:"blockhash"`
+	}]
+	if err := c.rpc.call(ctx, &res, "getLatestBlockhash"); err != nil {
+		return "", err
+	}
+	return res.Value.Blockhash, nil
+}
+
+func (c *SolanaRPCClient) FeeForMessage(ctx context.Context, message []byte) (uint64, error) {
+	var res solanaContext[*uint64]
+	if err := c.rpc.call(ctx, &res, "getFeeForMessage", base64.StdEncoding.EncodeToString(message)); err != nil {
+		return 0, err
+	}
+	if res.Value == nil {
+		return 0, errors.New("getFeeForMessage: blockhash expired")
+	}
+	return *res.Value, nil
+}
+
+func (c *SolanaRPCClient) AccountExists(ctx context.Context, address string) (bool, error) {
+	var res solanaContext[json.RawMessage]
+	if err := c.rpc.call(ctx, &res, "getAccountInfo", address, map[string]string{"encoding": "base64"}); err != nil {
+		return false, err
+	}
+	return len(res.Value) > 0 && string(res.Value) != "null", nil
+}
+
+func (c *SolanaRPCClient) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	var sig string
+	err := c.rpc.call(ctx, &sig, "sendTransaction",
+		base64.StdEncoding.EncodeToString(rawTx), map[string]string{"encoding": "base64"})
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case solanaRPCPreflightFailure:
+			return preflightError(rawTx, rpcErr, err)
+		case solanaRPCSignatureFailure, solanaRPCInvalidParams:
+			return "", fmt.Errorf("%w: %s", ErrTxRejected, rpcErr.Message)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return sig, nil
+}
+
+// preflightError classifies a failed preflight simulation by its transaction
+// error. A transaction already processed was sent before, and an unknown
+// blockhash may only mean the node is behind; everything else is rejected.
+func preflightError(rawTx []byte, rpcErr *RPCError, err error) (string, error) {
+	var data struct {
+		Err json.RawMessage `json:"err"`
+	}
+	json.Unmarshal(rpcErr.Data, &data)
+	var txErr string
+	json.Unmarshal(data.Err, &txErr) // instruction errors are objects
+	switch txErr {
+	case "AlreadyProcessed":
+		return solanaSignature(rawTx)
+	case "BlockhashNotFound":
+		return "", err
+	}
+	return "", fmt.Errorf("%w: %s", ErrTxRejected, rpcErr.Message)
+}
+
+func (c *SolanaRPCClient) TxStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	var res solanaContext[[]*struct {
+		Slot               uint64          `json:"slot"`
+		Confirmations      *uint64         `json:"confirmations"` // null once rooted
+		Err                json.RawMessage `json:"err"`
+		ConfirmationStatus string          `json:"confirmationStatus"`
+	}]
+	if err := c.rpc.call(ctx, &res, "getSignatureStatuses",
+		[]string{txID}, map[string]bool{"searchTransactionHistory": true}); err != nil {
+		return nil, err
+	}
+	if len(res.Value) == 0 || res.Value[0] == nil {
+		return nil, ErrTxNotFound
+	}
+	st := res.Value[0]
+
+	// A status only exists once the transaction is in a block, so it has at least one confirmation
+	status := &TxStatus{
+		BlockHeight: st.Slot,
+		Failed:      len(st.Err) > 0 && string(st.Err) != "null",
+		Finalized:   st.ConfirmationStatus == "finalized",
+	}
+	if st.Confirmations != nil {
+		status.Confirmations = *st.Confirmations + 1
+	} else {
+		status.Confirmations = solanaRootDepth
+	}
+	return status, nil
+}
@@ -0,0 +1,292 @@
+// solana_message.go
+package chain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// SolanaPublicKey is an Ed25519 public key or program address.
+type SolanaPublicKey [32]byte
+
+// SolanaSystemProgramID is the System Program, which owns wallet accounts and moves lamports.
+var SolanaSystemProgramID = SolanaPublicKey{}
+
+// ParseSolanaPublicKey decodes a base58 address, which must be exactly 32 bytes.
+func ParseSolanaPublicKey(s string) (SolanaPublicKey, error) {
+	var key SolanaPublicKey
+	b := base58.Decode(s)
+	if len(b) != len(key) {
+		return key, fmt.Errorf("%q is not a 32-byte base58 key: %w", s, ErrInvalidAddress)
+	}
+	copy(key[:], b)
+	return key, nil
+}
+
+func (k SolanaPublicKey) String() string {
+	return base58.Encode(k[:])
+}
+
+// SolanaAccountMeta is an account an instruction reads or writes.
+type SolanaAccountMeta struct {
+	PubKey     SolanaPublicKey
+	IsSigner   bool
+	IsWritable bool
+}
+
+// SolanaInstruction is a program call before its accounts are compiled to indexes.
+type SolanaInstruction struct {
+	ProgramID SolanaPublicKey
+	Accounts  []SolanaAccountMeta
+	Data      []byte
+}
+
+// solanaSystemTransfer is the System Program's Transfer instruction index.
+const solanaSystemTransfer = 2
+
+// SolanaTransferInstruction moves lamports between two System-owned accounts.
+func SolanaTransferInstruction(from, to SolanaPublicKey, lamports uint64) SolanaInstruction {
+	data := binary.LittleEndian.AppendUint32(nil, solanaSystemTransfer)
+	data = binary.LittleEndian.AppendUint64(data, lamports)
+	return SolanaInstruction{
+		ProgramID: SolanaSystemProgramID,
+		Accounts: []SolanaAccountMeta{
+			{PubKey: from, IsSigner: true, IsWritable: true},
+			{PubKey: to, IsWritable: true},
+		},
+		Data: data,
+	}
+}
+
+// SolanaLookupTable is an on-chain address lookup table a v0 message can load
+// accounts from instead of listing them.
+type SolanaLookupTable struct {
+	Key       SolanaPublicKey
+	Addresses []SolanaPublicKey
+}
+
+// SolanaMessageHeader counts the signer and read-only accounts at the front and
+// back of the account key list.
+type SolanaMessageHeader struct {
+	NumRequiredSignatures       uint8
+	NumReadonlySignedAccounts   uint8
+	NumReadonlyUnsignedAccounts uint8
+}
+
+// SolanaCompiledInstruction refers to its program and accounts by index into
+// the message's account keys, followed by any accounts loaded from lookup tables.
+type SolanaCompiledInstruction struct {
+	ProgramIDIndex uint8
+	Accounts       []uint8
+	Data           []byte
+}
+
+// SolanaAddressTableLookup loads accounts from one lookup table by index.
+type SolanaAddressTableLookup struct {
+	AccountKey      SolanaPublicKey
+	WritableIndexes []uint8
+	ReadonlyIndexes []uint8
+}
+
+// SolanaMessage is the part of a transaction the signatures cover.
+type SolanaMessage struct {
+	Versioned           bool // v0 message; legacy when false
+	Header              SolanaMessageHeader
+	AccountKeys         []SolanaPublicKey
+	RecentBlockhash     [32]byte
+	Instructions        []SolanaCompiledInstruction
+	AddressTableLookups []SolanaAddressTableLookup // v0 only
+}
+
+// solanaMaxAccounts is the number of accounts an instruction's u8 indexes can address.
+const solanaMaxAccounts = 256
+
+// solanaVersionPrefix marks a versioned message; the low bits hold the version.
+const solanaVersionPrefix = 0x80
+
+// CompileSolanaMessage orders the accounts of instructions into a message paid
+// for by payer. Accounts are listed writable signers first (payer leading),
+// then read-only signers, writable non-signers and read-only non-signers.
+// When lookup tables are given the message is v0, and non-signer accounts
+// found in a table are loaded from it instead of being listed; program IDs
+// are always listed.
+func CompileSolanaMessage(payer SolanaPublicKey, instructions []SolanaInstruction, recentBlockhash [32]byte, lookupTables []SolanaLookupTable) (*SolanaMessage, error) {
+	type keyMeta struct {
+		signer, writable, invoked bool
+	}
+	var order []SolanaPublicKey
+	metas := make(map[SolanaPublicKey]*keyMeta)
+	add := func(key SolanaPublicKey, m keyMeta) {
+		existing, ok := metas[key]
+		if !ok {
+			order = append(order, key)
+			metas[key] = &m
+			return
+		}
+		existing.signer = existing.signer || m.signer
+		existing.writable = existing.writable || m.writable
+		existing.invoked = existing.invoked || m.invoked
+	}
+	add(payer, keyMeta{signer: true, writable: true})
+	for _, ix := range instructions {
+		add(ix.ProgramID, keyMeta{invoked: true})
+		for _, acc := range ix.Accounts {
+			add(acc.PubKey, keyMeta{signer: acc.IsSigner, writable: acc.IsWritable})
+		}
+	}
+
+	// Split off accounts that can be loaded from a lookup table, remembering which
+	tableOf := make(map[SolanaPublicKey]int)
+	var static []SolanaPublicKey
+	for _, key := range order {
+		m := metas[key]
+		if !m.signer && !m.invoked {
+			if table, ok := findInLookupTables(lookupTables, key); ok {
+				tableOf[key] = table
+				continue
+			}
+		}
+		static = append(static, key)
+	}
+
+	msg := &SolanaMessage{Versioned: lookupTables != nil, RecentBlockhash: recentBlockhash}
+	for _, group := range []keyMeta{{signer: true, writable: true}, {signer: true}, {writable: true}, {}} {
+		for _, key := range static {
+			m := metas[key]
+			if m.signer != group.signer || m.writable != group.writable {
+				continue
+			}
+			msg.AccountKeys = append(msg.AccountKeys, key)
+			switch {
+			case m.signer && m.writable:
+				msg.Header.NumRequiredSignatures++
+			case m.signer:
+				msg.Header.NumRequiredSignatures++
+				msg.Header.NumReadonlySignedAccounts++
+			case !m.writable:
+				msg.Header.NumReadonlyUnsignedAccounts++
+			}
+		}
+	}
+
+	// Loaded accounts follow the listed ones: writable from every table, then read-only
+	loaded := make([][2][]SolanaPublicKey, len(lookupTables))
+	for _, key := range order {
+		table, ok := tableOf[key]
+		if !ok {
+			continue
+		}
+		rw := 1
+		if metas[key].writable {
+			rw = 0
+		}
+		loaded[table][rw] = append(loaded[table][rw], key)
+	}
+	all := append([]SolanaPublicKey(nil), msg.AccountKeys...)
+	for rw := 0; rw < 2; rw++ {
+		for table := range lookupTables {
+			all = append(all, loaded[table][rw]...)
+		}
+	}
+	if len(all) > solanaMaxAccounts {
+		return nil, fmt.Errorf("message references %d accounts, at most %d allowed", len(all), solanaMaxAccounts)
+	}
+	for table, keys := range loaded {
+		if len(keys[0]) == 0 && len(keys[1]) == 0 {
+			continue
+		}
+		lookup := SolanaAddressTableLookup{AccountKey: lookupTables[table].Key}
+		for _, key := range keys[0] {
+			lookup.WritableIndexes = append(lookup.WritableIndexes, indexInTable(lookupTables[table], key))
+		}
+		for _, key := range keys[1] {
+			lookup.ReadonlyIndexes = append(lookup.ReadonlyIndexes, indexInTable(lookupTables[table], key))
+		}
+		msg.AddressTableLookups = append(msg.AddressTableLookups, lookup)
+	}
+
+	index := make(map[SolanaPublicKey]uint8, len(all))
+	for i, key := range all {
+		index[key] = uint8(i)
+	}
+	for _, ix := range instructions {
+		compiled := SolanaCompiledInstruction{ProgramIDIndex: index[ix.ProgramID], Data: ix.Data}
+		for _, acc := range ix.Accounts {
+			compiled.Accounts = append(compiled.Accounts, index[acc.PubKey])
+		}
+		msg.Instructions = append(msg.Instructions, compiled)
+	}
+	return msg, nil
+}
+
+// findInLookupTables returns the first table holding key.
+func findInLookupTables(tables []SolanaLookupTable, key SolanaPublicKey) (int, bool) {
+	for i, table := range tables {
+		for j, addr := range table.Addresses {
+			if addr == key && j < solanaMaxAccounts {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func indexInTable(table SolanaLookupTable, key SolanaPublicKey) uint8 {
+	for i, addr := range table.Addresses {
+		if addr == key {
+			return uint8(i)
+		}
+	}
+	panic("solana: key not in lookup table")
+}
+
+// Serialize encodes the message in Solana's wire format, the bytes that are signed.
+func (m *SolanaMessage) Serialize() []byte {
+	var b []byte
+	if m.Versioned {
+		b = append(b, solanaVersionPrefix) // version 0
+	}
+	b = append(b, m.Header.NumRequiredSignatures, m.Header.NumReadonlySignedAccounts, m.Header.NumReadonlyUnsignedAccounts)
+	b = appendCompactU16(b, len(m.AccountKeys))
+	for _, key := range m.AccountKeys {
+		b = append(b, key[:]...)
+	}
+	b = append(b, m.RecentBlockhash[:]...)
+	b = appendCompactU16(b, len(m.Instructions))
+	for _, ix := range m.Instructions {
+		b = append(b, ix.ProgramIDIndex)
+		b = appendCompactU16(b, len(ix.Accounts))
+		b = append(b, ix.Accounts...)
+		b = appendCompactU16(b, len(ix.Data))
+		b = append(b, ix.Data...)
+	}
+	if m.Versioned {
+		b = appendCompactU16(b, len(m.AddressTableLookups))
+		for _, lookup := range m.AddressTableLookups {
+			b = append(b, lookup.AccountKey[:]...)
+			b = appendCompactU16(b, len(lookup.WritableIndexes))
+			b = append(b, lookup.WritableIndexes...)
+			b = appendCompactU16(b, len(lookup.ReadonlyIndexes))
+			b = append(b, lookup.ReadonlyIndexes...)
+		}
+	}
+	return b
+}
+
+// solanaRequiredSignatures reads the signature count from a serialized
+// message's header, legacy or versioned.
+func solanaRequiredSignatures(message []byte) (int, error) {
+	if len(message) > 0 && message[0]&solanaVersionPrefix != 0 {
+		if version := message[0] &^ solanaVersionPrefix; version != 0 {
+			return 0, fmt.Errorf("unsupported Solana message version %d", version)
+		}
+		message = message[1:]
+	}
+	if len(message) < 3 {
+		return 0, errors.New("truncated Solana message header")
+	}
+	return int(message[0]), nil
+}
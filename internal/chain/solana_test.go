@@ -3,36 +3,230 @@ package chain
 
 import (
 	"math/big"
+	"strings"
 	"testing"
 
+	"andi-custodian/pkg/tokens"
+
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/stretchr/testify/assert"
 )
 
+const (
+	testSolanaFrom      = "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM"
+	testSolanaTo        = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	testSolanaBlockhash = "EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N"
+)
+
 func TestSolanaBuilder_BuildTx_Success(t *testing.T) {
 	builder := &SolanaBuilder{}
 	req := &TxRequest{
 		Chain: SolanaDevnet,
-		From:  "7UX2Kk87yue12Gc5cW4Hb6j6g1qX1JvQjG5Y8z9W5tq", // 44 chars
-		To:    "9fXw3Kk87yue12Gc5cW4Hb6j6g1qX1JvQjG5Y8z9W8kR",
+		From:  testSolanaFrom,
+		To:    testSolanaTo,
 		Value: big.NewInt(1_000_000_000), // 1 SOL
 		ID:    "sol-tx-1",
 	}
 
-	result, err := builder.BuildTx(req, BuildOptions{})
+	result, err := builder.BuildTx(req, BuildOptions{RecentBlockhash: testSolanaBlockhash})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, result.RawTx)
 	assert.Equal(t, int64(5000), result.EstimatedFee)
+
+	// header || 3 keys || blockhash || 1 instruction: system(from, to) Transfer(1 SOL)
+	want := []byte{1, 0, 1, 3}
+	want = append(want, base58.Decode(testSolanaFrom)...)
+	want = append(want, base58.Decode(testSolanaTo)...)
+	want = append(want, make([]byte, 32)...) // System Program
+	want = append(want, base58.Decode(testSolanaBlockhash)...)
+	want = append(want, 1, 2, 2, 0, 1, 12, 2, 0, 0, 0, 0x00, 0xca, 0x9a, 0x3b, 0, 0, 0, 0)
+	assert.Equal(t, want, result.RawTx)
 }
 
-func TestSolanaBuilder_BuildTx_InvalidAddress(t *testing.T) {
+func TestSolanaBuilder_BuildTx_V0(t *testing.T) {
+	to, _ := ParseSolanaPublicKey(testSolanaTo)
+	other, _ := ParseSolanaPublicKey(testSolanaBlockhash)
+	table := SolanaLookupTable{Key: SolanaPublicKey{9}, Addresses: []SolanaPublicKey{other, to}}
+
+	result, err := (&SolanaBuilder{}).BuildTx(&TxRequest{
+		Chain: SolanaDevnet,
+		From:  testSolanaFrom,
+		To:    testSolanaTo,
+		Value: big.NewInt(1),
+	}, BuildOptions{RecentBlockhash: testSolanaBlockhash, LookupTables: []SolanaLookupTable{table}})
+	assert.NoError(t, err)
+
+	// The recipient is loaded from the table as the third account (index 2)
+	want := []byte{0x80, 1, 0, 1, 2}
+	want = append(want, base58.Decode(testSolanaFrom)...)
+	want = append(want, make([]byte, 32)...)
+	want = append(want, base58.Decode(testSolanaBlockhash)...)
+	want = append(want, 1, 1, 2, 0, 2, 12, 2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0)
+	want = append(want, 1)
+	want = append(want, table.Key[:]...)
+	want = append(want, 1, 1, 0)
+	assert.Equal(t, want, result.RawTx)
+}
+
+func TestCompileSolanaMessage_AccountOrder(t *testing.T) {
+	payer, signer, writable, readonly, program := SolanaPublicKey{1}, SolanaPublicKey{2}, SolanaPublicKey{3}, SolanaPublicKey{4}, SolanaPublicKey{5}
+	msg, err := CompileSolanaMessage(payer, []SolanaInstruction{{
+		ProgramID: program,
+		Accounts: []SolanaAccountMeta{
+			{PubKey: readonly},
+			{PubKey: signer, IsSigner: true},
+			{PubKey: writable, IsWritable: true},
+			{PubKey: payer, IsSigner: true, IsWritable: true},
+		},
+		Data: []byte{7},
+	}}, [32]byte{}, nil)
+	assert.NoError(t, err)
+
+	assert.False(t, msg.Versioned)
+	assert.Equal(t, []SolanaPublicKey{payer, signer, writable, program, readonly}, msg.AccountKeys)
+	assert.Equal(t, SolanaMessageHeader{NumRequiredSignatures: 2, NumReadonlySignedAccounts: 1, NumReadonlyUnsignedAccounts: 2}, msg.Header)
+	assert.Equal(t, []SolanaCompiledInstruction{{ProgramIDIndex: 3, Accounts: []uint8{4, 1, 2, 0}, Data: []byte{7}}}, msg.Instructions)
+}
+
+func TestSolanaBuilder_BuildTx_Invalid(t *testing.T) {
 	builder := &SolanaBuilder{}
-	req := &TxRequest{
+	tests := []struct {
+		name      string
+		from, to  string
+		value     *big.Int
+		blockhash string
+		want      error
+	}{
+		{"short address", "short", testSolanaTo, big.NewInt(1), testSolanaBlockhash, ErrInvalidAddress},
+		{"44 chars but over 32 bytes", strings.Repeat("z", 44), testSolanaTo, big.NewInt(1), testSolanaBlockhash, ErrInvalidAddress},
+		{"not base58", testSolanaFrom, "0OIl" + testSolanaTo[4:], big.NewInt(1), testSolanaBlockhash, ErrInvalidAddress},
+		{"zero value", testSolanaFrom, testSolanaTo, big.NewInt(0), testSolanaBlockhash, ErrInvalidAmount},
+		{"over u64", testSolanaFrom, testSolanaTo, new(big.Int).Lsh(big.NewInt(1), 64), testSolanaBlockhash, ErrInvalidAmount},
+		{"no blockhash", testSolanaFrom, testSolanaTo, big.NewInt(1), "", nil},
+	}
+	for _, tt := range tests {
+		_, err := builder.BuildTx(&TxRequest{Chain: SolanaDevnet, From: tt.from, To: tt.to, Value: tt.value},
+			BuildOptions{RecentBlockhash: tt.blockhash})
+		assert.Error(t, err, tt.name)
+		if tt.want != nil {
+			assert.ErrorIs(t, err, tt.want, tt.name)
+		}
+	}
+}
+
+func TestSolanaBuilder_Finalize(t *testing.T) {
+	builder := &SolanaBuilder{}
+	tx, err := builder.BuildTx(&TxRequest{
 		Chain: SolanaDevnet,
-		From:  "short",
-		To:    "9fXw3Kk87yue12Gc5cW4Hb6j6g1qX1JvQjG5Y8z9W8kR",
-		Value: big.NewInt(1_000_000_000),
+		From:  testSolanaFrom,
+		To:    testSolanaTo,
+		Value: big.NewInt(1),
+	}, BuildOptions{RecentBlockhash: testSolanaBlockhash})
+	assert.NoError(t, err)
+
+	hashes, err := builder.SigHashes(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{tx.RawTx}, hashes)
+
+	sig := make([]byte, 64)
+	sig[0] = 1
+	signed, err := builder.Finalize(tx, []Signature{{Sig: sig}})
+	assert.NoError(t, err)
+	assert.Equal(t, byte(1), signed.RawTx[0], "compact-u16 signature count")
+	assert.Equal(t, sig, signed.RawTx[1:65])
+	assert.Equal(t, tx.RawTx, signed.RawTx[65:])
+	assert.Equal(t, base58.Encode(sig), signed.TxID)
+
+	_, err = builder.Finalize(tx, []Signature{{Sig: []byte("short")}})
+	assert.Error(t, err)
+	_, err = builder.Finalize(tx, []Signature{{Sig: sig}, {Sig: sig}})
+	assert.ErrorIs(t, err, ErrSignatureCount)
+}
+
+func TestAppendCompactU16(t *testing.T) {
+	assert.Equal(t, []byte{0x00}, appendCompactU16(nil, 0))
+	assert.Equal(t, []byte{0x7f}, appendCompactU16(nil, 127))
+	assert.Equal(t, []byte{0x80, 0x01}, appendCompactU16(nil, 128))
+	assert.Equal(t, []byte{0xff, 0xff, 0x03}, appendCompactU16(nil, 65535))
+}
+
+func TestFindSolanaProgramAddress(t *testing.T) {
+	program := SolanaAssociatedTokenProgramID
+	seeds := [][]byte{[]byte("vault"), {1, 2, 3}}
+
+	addr, bump, err := FindSolanaProgramAddress(seeds, program)
+	assert.NoError(t, err)
+	again, err := CreateSolanaProgramAddress(append(seeds, []byte{bump}), program)
+	assert.NoError(t, err)
+	assert.Equal(t, addr, again)
+	assert.Len(t, seeds, 2, "caller's seeds are not modified")
+
+	// Every bump above the canonical one landed on the curve
+	for b := 255; b > int(bump); b-- {
+		_, err := CreateSolanaProgramAddress(append(seeds, []byte{byte(b)}), program)
+		assert.ErrorIs(t, err, ErrOnCurve)
+	}
+
+	_, err = CreateSolanaProgramAddress([][]byte{make([]byte, 33)}, program)
+	assert.Error(t, err)
+}
+
+func TestSolanaAssociatedTokenAddress(t *testing.T) {
+	owner, _ := ParseSolanaPublicKey(testSolanaFrom)
+	other, _ := ParseSolanaPublicKey(testSolanaTo)
+	mint := SolanaPublicKey(tokens.USDC_SolanaDevnet.Mint)
+
+	ata, err := SolanaAssociatedTokenAddress(owner, mint)
+	assert.NoError(t, err)
+	otherATA, _ := SolanaAssociatedTokenAddress(other, mint)
+	assert.NotEqual(t, ata, otherATA)
+	assert.NotEqual(t, owner, ata)
+}
+
+func TestSolanaBuilder_BuildTokenTransfer(t *testing.T) {
+	builder := &SolanaBuilder{}
+	req := &TokenTransferRequest{
+		Chain:     SolanaDevnet,
+		From:      testSolanaFrom,
+		To:        testSolanaTo,
+		Token:     "USDC",
+		AmountStr: "2.5",
 	}
+	owner, _ := ParseSolanaPublicKey(testSolanaFrom)
+	recipient, _ := ParseSolanaPublicKey(testSolanaTo)
+	mint := SolanaPublicKey(tokens.USDC_SolanaDevnet.Mint)
+	source, _ := SolanaAssociatedTokenAddress(owner, mint)
+	destination, _ := SolanaAssociatedTokenAddress(recipient, mint)
+
+	tx, err := builder.BuildTokenTransfer(req, BuildOptions{RecentBlockhash: testSolanaBlockhash})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5000), tx.EstimatedFee)
+	blockhash, _ := ParseSolanaPublicKey(testSolanaBlockhash)
+	want, _ := CompileSolanaMessage(owner, []SolanaInstruction{{
+		ProgramID: SolanaTokenProgramID,
+		Accounts: []SolanaAccountMeta{
+			{PubKey: source, IsWritable: true},
+			{PubKey: mint},
+			{PubKey: destination, IsWritable: true},
+			{PubKey: owner, IsSigner: true},
+		},
+		// TransferChecked, 2_500_000 base units, 6 decimals
+		Data: []byte{12, 0xa0, 0x25, 0x26, 0, 0, 0, 0, 0, 6},
+	}}, blockhash, nil)
+	assert.Equal(t, want.Serialize(), tx.RawTx)
+
+	// Creating the recipient's account adds the instruction and its rent
+	tx, err = builder.BuildTokenTransfer(req, BuildOptions{RecentBlockhash: testSolanaBlockhash, CreateTokenAccount: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5000+2_039_280), tx.EstimatedFee)
+	create, _ := SolanaCreateAssociatedTokenAccountInstruction(owner, recipient, mint)
+	want, _ = CompileSolanaMessage(owner, []SolanaInstruction{
+		create,
+		SolanaTransferCheckedInstruction(source, mint, destination, owner, 2_500_000, 6),
+	}, blockhash, nil)
+	assert.Equal(t, want.Serialize(), tx.RawTx)
+	assert.Equal(t, 1, int(want.Header.NumRequiredSignatures))
 
-	_, err := builder.BuildTx(req, BuildOptions{})
+	_, err = builder.BuildTokenTransfer(&TokenTransferRequest{Chain: SolanaDevnet, From: testSolanaFrom, To: testSolanaTo, Token: "SOL", AmountStr: "1"},
+		BuildOptions{RecentBlockhash: testSolanaBlockhash})
 	assert.Error(t, err)
 }
@@ -0,0 +1,201 @@
+// solana_token.go
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"andi-custodian/pkg/tokens"
+
+	"filippo.io/edwards25519"
+)
+
+var (
+	// SolanaTokenProgramID is the SPL Token program, which owns mints and token accounts.
+	SolanaTokenProgramID = mustSolanaPublicKey("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	// SolanaAssociatedTokenProgramID creates the canonical token account of an owner for a mint.
+	SolanaAssociatedTokenProgramID = mustSolanaPublicKey("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL")
+)
+
+// ErrOnCurve is returned when seeds hash to a valid Ed25519 key, which a
+// program-derived address must not be: nobody may hold its private key.
+var ErrOnCurve = errors.New("program address is on the ed25519 curve")
+
+const (
+	solanaPDAMarker     = "ProgramDerivedAddress"
+	solanaMaxSeeds      = 16
+	solanaMaxSeedLength = 32
+
+	// splTransferChecked is the SPL Token TransferChecked instruction index.
+	splTransferChecked = 12
+	// ataCreateIdempotent is the Associated Token Account program's CreateIdempotent
+	// instruction, which succeeds when the account already exists.
+	ataCreateIdempotent = 1
+
+	// solanaTokenAccountRent is the rent-exempt balance of a 165-byte token
+	// account, paid by whoever creates it.
+	solanaTokenAccountRent = 2_039_280
+)
+
+func mustSolanaPublicKey(s string) SolanaPublicKey {
+	key, err := ParseSolanaPublicKey(s)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// CreateSolanaProgramAddress derives the address program controls for seeds:
+// sha256(seeds || program || "ProgramDerivedAddress"), which must be off the curve.
+func CreateSolanaProgramAddress(seeds [][]byte, program SolanaPublicKey) (SolanaPublicKey, error) {
+	if len(seeds) > solanaMaxSeeds {
+		return SolanaPublicKey{}, fmt.Errorf("%d seeds, at most %d allowed", len(seeds), solanaMaxSeeds)
+	}
+	h := sha256.New()
+	for _, seed := range seeds {
+		if len(seed) > solanaMaxSeedLength {
+			return SolanaPublicKey{}, fmt.Errorf("seed of %d bytes, at most %d allowed", len(seed), solanaMaxSeedLength)
+		}
+		h.Write(seed)
+	}
+	h.Write(program[:])
+	h.Write([]byte(solanaPDAMarker))
+
+	var addr SolanaPublicKey
+	copy(addr[:], h.Sum(nil))
+	if _, err := new(edwards25519.Point).SetBytes(addr[:]); err == nil {
+		return SolanaPublicKey{}, ErrOnCurve
+	}
+	return addr, nil
+}
+
+// FindSolanaProgramAddress searches bump seeds from 255 down for the first that
+// puts the derived address off the curve, and returns the address and its bump.
+func FindSolanaProgramAddress(seeds [][]byte, program SolanaPublicKey) (SolanaPublicKey, uint8, error) {
+	withBump := append(append([][]byte(nil), seeds...), nil)
+	for bump := 255; bump >= 0; bump-- {
+		withBump[len(seeds)] = []byte{byte(bump)}
+		addr, err := CreateSolanaProgramAddress(withBump, program)
+		if err == nil {
+			return addr, uint8(bump), nil
+		}
+		if !errors.Is(err, ErrOnCurve) {
+			return SolanaPublicKey{}, 0, err
+		}
+	}
+	return SolanaPublicKey{}, 0, errors.New("no viable bump seed")
+}
+
+// SolanaAssociatedTokenAddress returns owner's canonical token account for mint.
+func SolanaAssociatedTokenAddress(owner, mint SolanaPublicKey) (SolanaPublicKey, error) {
+	addr, _, err := FindSolanaProgramAddress(
+		[][]byte{owner[:], SolanaTokenProgramID[:], mint[:]}, SolanaAssociatedTokenProgramID)
+	return addr, err
+}
+
+// SolanaTransferCheckedInstruction moves amount base units between token
+// accounts. The program checks mint and decimals against the accounts, so a
+// wrong registry entry fails the transaction instead of moving a wrong amount.
+func SolanaTransferCheckedInstruction(source, mint, destination, owner SolanaPublicKey, amount uint64, decimals uint8) SolanaInstruction {
+	data := append([]byte{splTransferChecked}, binary.LittleEndian.AppendUint64(nil, amount)...)
+	data = append(data, decimals)
+	return SolanaInstruction{
+		ProgramID: SolanaTokenProgramID,
+		Accounts: []SolanaAccountMeta{
+			{PubKey: source, IsWritable: true},
+			{PubKey: mint},
+			{PubKey: destination, IsWritable: true},
+			{PubKey: owner, IsSigner: true},
+		},
+		Data: data,
+	}
+}
+
+// SolanaCreateAssociatedTokenAccountInstruction creates owner's associated
+// token account for mint, funded by payer. It is a no-op when the account exists.
+func SolanaCreateAssociatedTokenAccountInstruction(payer, owner, mint SolanaPublicKey) (SolanaInstruction, error) {
+	account, err := SolanaAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		return SolanaInstruction{}, err
+	}
+	return SolanaInstruction{
+		ProgramID: SolanaAssociatedTokenProgramID,
+		Accounts: []SolanaAccountMeta{
+			{PubKey: payer, IsSigner: true, IsWritable: true},
+			{PubKey: account, IsWritable: true},
+			{PubKey: owner},
+			{PubKey: mint},
+			{PubKey: SolanaSystemProgramID},
+			{PubKey: SolanaTokenProgramID},
+		},
+		Data: []byte{ataCreateIdempotent},
+	}, nil
+}
+
+// BuildTokenTransfer builds an SPL TransferChecked between the associated token
+// accounts of req.From and req.To. With opts.CreateTokenAccount the message
+// first creates the recipient's account, and the estimated fee includes its rent.
+func (s *SolanaBuilder) BuildTokenTransfer(req *TokenTransferRequest, opts BuildOptions) (*TxResult, error) {
+	if req.Chain != SolanaDevnet {
+		return nil, errors.New("SolanaBuilder: invalid chain")
+	}
+	token, ok := tokens.GetTokenBySymbol(req.Chain, req.Token)
+	if !ok {
+		return nil, fmt.Errorf("unsupported token: %s on %s", req.Token, req.Chain)
+	}
+	if token.IsNative() {
+		return nil, errors.New("use BuildTx for native coins")
+	}
+
+	amount := req.Amount
+	if amount == nil {
+		var err error
+		amount, err = token.ParseAmount(req.AmountStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if amount.Sign() <= 0 || !amount.IsUint64() {
+		return nil, fmt.Errorf("amount out of range: %w", ErrInvalidAmount)
+	}
+
+	owner, err := ParseSolanaPublicKey(req.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	recipient, err := ParseSolanaPublicKey(req.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to address: %w", err)
+	}
+	mint := SolanaPublicKey(token.Mint)
+	source, err := SolanaAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := SolanaAssociatedTokenAddress(recipient, mint)
+	if err != nil {
+		return nil, err
+	}
+
+	var instructions []SolanaInstruction
+	if opts.CreateTokenAccount {
+		create, err := SolanaCreateAssociatedTokenAccountInstruction(owner, recipient, mint)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, create)
+	}
+	instructions = append(instructions,
+		SolanaTransferCheckedInstruction(source, mint, destination, owner, amount.Uint64(), uint8(token.Decimals)))
+
+	tx, err := buildSolanaTx(req.Chain, owner, instructions, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.CreateTokenAccount {
+		tx.EstimatedFee += solanaTokenAccountRent
+	}
+	return tx, nil
+}
@@ -3,17 +3,23 @@ package chain
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
+
+	"andi-custodian/pkg/chains"
 )
 
-// Chain represents a supported blockchain.
-type Chain string
+// Chain represents a supported blockchain; it is the shared chains.Chain.
+type Chain = chains.Chain
 
 const (
-	BitcoinTestnet  Chain = "bitcoin-testnet"
-	EthereumSepolia Chain = "ethereum-sepolia"
-	SolanaDevnet    Chain = "solana-devnet"
-	AvalancheFuji   Chain = "avalanche-fuji"
+	BitcoinMainnet  = chains.BitcoinMainnet
+	BitcoinTestnet  = chains.BitcoinTestnet
+	BitcoinSignet   = chains.BitcoinSignet
+	BitcoinRegtest  = chains.BitcoinRegtest
+	EthereumSepolia = chains.EthereumSepolia
+	SolanaDevnet    = chains.SolanaDevnet
+	AvalancheFuji   = chains.AvalancheFuji
 )
 
 // TxRequest is a cross-chain transaction request.
@@ -27,8 +33,22 @@ type TxRequest struct {
 
 // TxResult is the output of transaction building.
 type TxResult struct {
+	Chain        Chain
 	RawTx        []byte // unsigned serialized transaction
 	EstimatedFee int64  // in native units (satoshis or wei)
+	Inputs       []UTXO // Bitcoin: spent outputs in input order, needed for sighashes
+}
+
+// Signature is a signature over one sighash.
+type Signature struct {
+	Sig    []byte // DER for Bitcoin, [R || S || V] for EVM, Ed25519 for Solana
+	PubKey []byte // compressed public key; required for Bitcoin witnesses only
+}
+
+// SignedTx is a fully signed, broadcast-ready transaction.
+type SignedTx struct {
+	RawTx []byte
+	TxID  string
 }
 
 // TokenTransferRequest is a cross-chain token transaction request
@@ -36,15 +56,32 @@ type TokenTransferRequest struct {
 	Chain     Chain
 	From      string
 	To        string
-	Token     string // e.g., "USDC"
-	AmountStr string // e.g., "1.000000"
-	ID        string // for idempotency
+	Token     string   // e.g., "USDC"
+	AmountStr string   // e.g., "1.000000"
+	Amount    *big.Int // base units; takes precedence over AmountStr when set
+	ID        string   // for idempotency
 }
 
 // BuildOptions provides chain-specific context (e.g., UTXOs for BTC, nonce for ETH)
 type BuildOptions struct {
 	UTXOs []UTXO // for Bitcoin
 	Nonce uint64 // for Ethereum
+
+	// Bitcoin fees and coin selection
+	FeeRate               int64        // sat/vbyte; zero means DefaultFeeRate
+	SubtractFeeFromAmount bool         // recipient pays the fee, e.g. when sweeping a wallet
+	Selector              CoinSelector // nil means LargestFirstSelector
+
+	// EVM fees, in wei. Nil values fall back to the chain defaults.
+	FeeModel  *FeeModel // overrides the chain's default fee model
+	GasPrice  *big.Int  // legacy gas price
+	GasFeeCap *big.Int  // EIP-1559 max fee per gas
+	GasTipCap *big.Int  // EIP-1559 max priority fee per gas
+
+	// Solana
+	RecentBlockhash    string              // base58; required, the transaction expires ~150 blocks after it
+	LookupTables       []SolanaLookupTable // when set, a v0 message loads accounts from these tables
+	CreateTokenAccount bool                // create the recipient's associated token account first
 }
 
 // UTXO represents an unspent output (Bitcoin only)
@@ -55,8 +92,26 @@ type UTXO struct {
 	PkScript []byte
 }
 
+// OutPoint returns the reference to this output.
+func (u UTXO) OutPoint() OutPoint {
+	return OutPoint{TxID: u.TxID, VOut: u.VOut}
+}
+
+// OutPoint identifies a transaction output.
+type OutPoint struct {
+	TxID string
+	VOut uint32
+}
+
+func (o OutPoint) String() string {
+	return fmt.Sprintf("%s:%d", o.TxID, o.VOut)
+}
+
 // Errors
 var (
 	ErrInsufficientFunds = errors.New("insufficient funds")
 	ErrInvalidAddress    = errors.New("invalid address")
+	ErrInvalidAmount     = errors.New("invalid amount")
+	ErrInvalidFee        = errors.New("invalid fee")
+	ErrSignatureCount    = errors.New("signature count does not match sighashes")
 )
@@ -0,0 +1,152 @@
+// deposit.go
+package custody
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"andi-custodian/internal/chain"
+	"andi-custodian/internal/store"
+	"andi-custodian/internal/wallet"
+	"andi-custodian/pkg/chains"
+)
+
+// depositAccount is the HD account deposit addresses are derived under.
+const depositAccount = 0
+
+var (
+	// ErrNoWallet is returned when issuing deposit addresses without an HD wallet.
+	ErrNoWallet = errors.New("no HD wallet configured for deposit addresses")
+	// ErrNotOwned is returned when a transfer's source address is not the signer's.
+	ErrNotOwned = errors.New("source address is not controlled by the signer")
+)
+
+// IssueDepositAddress derives a fresh deposit address for a customer on a
+// chain and records it with the path that produced it. Every call returns a
+// new address on the receiving branch; the store allocates the indexes, so
+// replicas sharing a store never hand out the same one.
+func (s *Service) IssueDepositAddress(ctx context.Context, customerID string, c chains.Chain) (*store.DepositAddress, error) {
+	if s.deriver == nil {
+		return nil, ErrNoWallet
+	}
+	if customerID == "" {
+		return nil, errors.New("customer ID is required")
+	}
+	if _, err := chains.Lookup(c); err != nil {
+		return nil, err
+	}
+	// Derive the account's first address before allocating, so a chain the
+	// deriver has no key for, e.g. Solana or a watch-only wallet without the
+	// account, does not use up an index
+	if _, err := s.deriver.Derive(c, depositAccount, wallet.ChangeExternal, 0); err != nil {
+		return nil, fmt.Errorf("derive deposit address failed: %w", err)
+	}
+
+	idx, err := s.store.NextAddressIndex(ctx, string(c), depositAccount)
+	if err != nil {
+		return nil, fmt.Errorf("allocate address index failed: %w", err)
+	}
+	derived, err := s.deriver.Derive(c, depositAccount, wallet.ChangeExternal, idx)
+	if err != nil {
+		return nil, fmt.Errorf("derive deposit address failed: %w", err)
+	}
+	addr := &store.DepositAddress{
+		Chain:      string(c),
+		Address:    derived.Address,
+		CustomerID: customerID,
+		Path:       derived.Path.String(),
+		Account:    derived.Account,
+		Index:      derived.Index,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.store.SaveDepositAddress(ctx, addr); err != nil {
+		return nil, fmt.Errorf("record deposit address failed: %w", err)
+	}
+	return addr, nil
+}
+
+// DepositAddresses returns the addresses issued to a customer, oldest first.
+func (s *Service) DepositAddresses(ctx context.Context, customerID string) ([]*store.DepositAddress, error) {
+	return s.store.ListDepositAddresses(ctx, customerID)
+}
+
+// DepositOwner returns the record of an issued deposit address, e.g. to credit
+// an incoming payment, or nil if the address was never issued.
+func (s *Service) DepositOwner(ctx context.Context, c chains.Chain, address string) (*store.DepositAddress, error) {
+	return s.store.GetDepositAddress(ctx, string(c), address)
+}
+
+// signingPath returns the derivation path of the key that controls from: the
+// recorded path when from is an issued deposit address, and nil, the signer's
+// default address, otherwise. The signer's key at that path must encode to
+// from, so a transfer never signs for an address the wallet does not hold.
+func (s *Service) signingPath(ctx context.Context, c chains.Chain, from string) (wallet.DerivationPath, error) {
+	addr, err := s.store.GetDepositAddress(ctx, string(c), from)
+	if err != nil {
+		return nil, fmt.Errorf("deposit address lookup failed: %w", err)
+	}
+	var path wallet.DerivationPath
+	if addr != nil {
+		if path, err = wallet.ParseDerivationPath(addr.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	provider, ok := s.signer.(wallet.PublicKeyProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w: signer cannot show it controls %s", ErrNotOwned, from)
+	}
+	pubKey, err := provider.PublicKeyAt(ctx, c, path)
+	if err != nil {
+		return nil, fmt.Errorf("public key lookup failed: %w", err)
+	}
+	controlled, err := addressOf(c, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	same := controlled == from
+	if c.Family() != chains.FamilySolana {
+		// EVM checksums and bech32 are case-insensitive; base58 is not
+		same = strings.EqualFold(controlled, from)
+	}
+	if !same {
+		return nil, fmt.Errorf("%w: %s, the signer's key there is %s", ErrNotOwned, from, controlled)
+	}
+	return path, nil
+}
+
+// addressOf encodes the address a public key controls on c: P2WPKH on Bitcoin,
+// the Keccak address on EVM chains and the key itself on Solana.
+func addressOf(c chains.Chain, pubKey []byte) (string, error) {
+	switch c.Family() {
+	case chains.FamilyUTXO:
+		n, err := chain.BitcoinNetwork(c)
+		if err != nil {
+			return "", err
+		}
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), n.Params)
+		if err != nil {
+			return "", err
+		}
+		return addr.EncodeAddress(), nil
+	case chains.FamilyEVM:
+		pub, err := crypto.DecompressPubkey(pubKey)
+		if err != nil {
+			return "", fmt.Errorf("invalid signer key: %w", err)
+		}
+		return crypto.PubkeyToAddress(*pub).Hex(), nil
+	case chains.FamilySolana:
+		if len(pubKey) != len(chain.SolanaPublicKey{}) {
+			return "", fmt.Errorf("invalid signer key: %d bytes", len(pubKey))
+		}
+		return chain.SolanaPublicKey(pubKey).String(), nil
+	default:
+		return "", fmt.Errorf("unsupported chain: %s", c)
+	}
+}
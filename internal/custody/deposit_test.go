@@ -0,0 +1,206 @@
+// deposit_test.go
+package custody
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/tyler-smith/go-bip39"
+
+	"andi-custodian/internal/chain"
+	"andi-custodian/internal/store"
+	"andi-custodian/internal/wallet"
+	"andi-custodian/pkg/chains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDepositService(t *testing.T) *Service {
+	w, err := wallet.NewWallet("slab lonely fish push bomb festival open oval empower federal slot hotel")
+	require.NoError(t, err)
+	return NewService(&MockSigner{}, store.NewInMemoryStore(), WithWallet(w))
+}
+
+func TestService_IssueDepositAddress(t *testing.T) {
+	service := newDepositService(t)
+	ctx := context.Background()
+
+	first, err := service.IssueDepositAddress(ctx, "alice", chain.BitcoinTestnet)
+	require.NoError(t, err)
+	assert.Equal(t, "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm", first.Address)
+	assert.Equal(t, "m/84'/1'/0'/0/0", first.Path)
+
+	second, err := service.IssueDepositAddress(ctx, "bob", chain.BitcoinTestnet)
+	require.NoError(t, err)
+	assert.Equal(t, "m/84'/1'/0'/0/1", second.Path)
+	assert.NotEqual(t, first.Address, second.Address)
+
+	// Each chain has its own index sequence
+	eth, err := service.IssueDepositAddress(ctx, "alice", chain.EthereumSepolia)
+	require.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/0'/0/0", eth.Path)
+
+	alice, err := service.DepositAddresses(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []*store.DepositAddress{first, eth}, alice)
+
+	owner, err := service.DepositOwner(ctx, chain.BitcoinTestnet, second.Address)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", owner.CustomerID)
+
+	// The recorded path recovers the address
+	w, _ := wallet.NewWallet("slab lonely fish push bomb festival open oval empower federal slot hotel")
+	derived, err := w.Derive(chain.BitcoinTestnet, second.Account, wallet.ChangeExternal, second.Index)
+	require.NoError(t, err)
+	assert.Equal(t, second.Address, derived.Address)
+}
+
+func TestService_IssueDepositAddress_Concurrent(t *testing.T) {
+	service := newDepositService(t)
+	ctx := context.Background()
+	const customers = 10
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		addrs = make(map[string]bool)
+	)
+	for i := 0; i < customers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			addr, err := service.IssueDepositAddress(ctx, string(rune('a'+i)), chain.BitcoinTestnet)
+			assert.NoError(t, err)
+			mu.Lock()
+			addrs[addr.Address] = true
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, addrs, customers)
+}
+
+func TestService_IssueDepositAddress_Errors(t *testing.T) {
+	ctx := context.Background()
+	_, err := newTestService(t, &MockSigner{}).IssueDepositAddress(ctx, "alice", chain.BitcoinTestnet)
+	assert.ErrorIs(t, err, ErrNoWallet)
+
+	service := newDepositService(t)
+	_, err = service.IssueDepositAddress(ctx, "", chain.BitcoinTestnet)
+	assert.Error(t, err)
+	_, err = service.IssueDepositAddress(ctx, "alice", "dogecoin-mainnet")
+	assert.ErrorIs(t, err, chains.ErrUnknownChain)
+	_, err = service.IssueDepositAddress(ctx, "alice", chain.SolanaDevnet)
+	assert.Error(t, err)
+	// The failed issue allocated no index
+	idx, err := service.store.NextAddressIndex(ctx, string(chain.SolanaDevnet), depositAccount)
+	require.NoError(t, err)
+	assert.Zero(t, idx)
+}
+
+func TestService_IssueDepositAddress_WatchOnly(t *testing.T) {
+	w, _ := wallet.NewWallet("slab lonely fish push bomb festival open oval empower federal slot hotel")
+	vpub, err := w.ExportXPub(chain.BitcoinTestnet, 0, wallet.XPubSLIP132)
+	require.NoError(t, err)
+	watch := wallet.NewWatchOnlyWallet()
+	_, err = watch.AddAccount(chain.BitcoinTestnet, vpub)
+	require.NoError(t, err)
+
+	service := NewService(&MockSigner{}, store.NewInMemoryStore(), WithWallet(watch))
+	ctx := context.Background()
+	for i := uint32(0); i < 3; i++ {
+		addr, err := service.IssueDepositAddress(ctx, "alice", chain.BitcoinTestnet)
+		require.NoError(t, err)
+		want, _ := w.Derive(chain.BitcoinTestnet, 0, wallet.ChangeExternal, i)
+		assert.Equal(t, want.Address, addr.Address)
+	}
+
+	// Chains without an imported account key cannot issue, nor use up an index
+	_, err = service.IssueDepositAddress(ctx, "alice", chain.EthereumSepolia)
+	assert.Error(t, err)
+	idx, err := service.store.NextAddressIndex(ctx, string(chain.EthereumSepolia), depositAccount)
+	require.NoError(t, err)
+	assert.Zero(t, idx)
+}
+
+func TestService_Transfer_FromDepositAddress(t *testing.T) {
+	const mnemonic = "slab lonely fish push bomb festival open oval empower federal slot hotel"
+	w, err := wallet.NewWallet(mnemonic)
+	require.NoError(t, err)
+	signer := wallet.NewSimulatedMPCSigner(bip39.NewSeed(mnemonic, ""))
+	utxoStore := store.NewInMemoryStore()
+	service := NewService(signer, utxoStore, WithWallet(w))
+	ctx := context.Background()
+
+	// Skip index 0, the signer's default key, so only the recorded path can sign
+	_, err = service.IssueDepositAddress(ctx, "alice", chain.BitcoinTestnet)
+	require.NoError(t, err)
+	deposit, err := service.IssueDepositAddress(ctx, "bob", chain.BitcoinTestnet)
+	require.NoError(t, err)
+	require.Equal(t, "m/84'/1'/0'/0/1", deposit.Path)
+
+	funding := chain.UTXO{TxID: testUTXOTxID, VOut: 0, Value: 2000000000}
+	require.NoError(t, utxoStore.SaveUTXOs(ctx, deposit.Address, []chain.UTXO{funding}))
+	res, err := service.Transfer(ctx, &TransferRequest{
+		ID:    "sweep-1",
+		Chain: chains.BitcoinTestnet,
+		From:  deposit.Address,
+		To:    "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm",
+		Value: "0.015",
+	})
+	require.NoError(t, err)
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	require.NoError(t, msgTx.Deserialize(bytes.NewReader(res.RawTx)))
+	from, err := btcutil.DecodeAddress(deposit.Address, &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	pkScript, _ := txscript.PayToAddrScript(from)
+	fetcher := txscript.NewCannedPrevOutputFetcher(pkScript, 2000000000)
+	vm, err := txscript.NewEngine(pkScript, msgTx, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(msgTx, fetcher), 2000000000, fetcher)
+	require.NoError(t, err)
+	assert.NoError(t, vm.Execute())
+}
+
+func TestService_Transfer_FromForeignAddress(t *testing.T) {
+	const mnemonic = "slab lonely fish push bomb festival open oval empower federal slot hotel"
+	// Deposit addresses come from another seed than the signer's
+	other, err := wallet.NewWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	require.NoError(t, err)
+	signer := wallet.NewSimulatedMPCSigner(bip39.NewSeed(mnemonic, ""))
+	utxoStore := store.NewInMemoryStore()
+	service := NewService(signer, utxoStore, WithWallet(other))
+	ctx := context.Background()
+
+	deposit, err := service.IssueDepositAddress(ctx, "alice", chain.BitcoinTestnet)
+	require.NoError(t, err)
+	unissued, err := other.Derive(chain.BitcoinTestnet, depositAccount, wallet.ChangeExternal, 7)
+	require.NoError(t, err)
+	for i, from := range []string{
+		unissued.Address, // not issued, so checked against the signer's default key
+		deposit.Address,  // issued, but the signer's key at its path is another
+	} {
+		funding := chain.UTXO{TxID: testUTXOTxID, VOut: uint32(i), Value: 2000000000}
+		require.NoError(t, utxoStore.SaveUTXOs(ctx, from, []chain.UTXO{funding}))
+		_, err := service.Transfer(ctx, &TransferRequest{
+			ID:    fmt.Sprintf("foreign-%d", i),
+			Chain: chains.BitcoinTestnet,
+			From:  from,
+			To:    "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm",
+			Value: "0.015",
+		})
+		assert.ErrorIs(t, err, ErrNotOwned, from)
+
+		// Nothing was signed, so the input is spendable again
+		spendable, err := utxoStore.GetSpendableUTXOs(ctx, from)
+		require.NoError(t, err)
+		assert.Len(t, spendable, 1)
+	}
+}
@@ -0,0 +1,314 @@
+// finality.go
+package custody
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"andi-custodian/internal/chain"
+	"andi-custodian/internal/store"
+)
+
+// defaultFinalityDepth is the confirmation count at which a transfer is
+// considered final on chains without a network configuration; EVM and
+// Bitcoin networks carry theirs. Solana also finalizes when the cluster
+// reports the transaction rooted.
+var defaultFinalityDepth = map[chain.Chain]uint64{
+	chain.SolanaDevnet: 32,
+}
+
+// simulatedConfirmDelay is how long a transfer on a chain without a node
+// client takes to "confirm", and then to reach finality.
+const simulatedConfirmDelay = 5 * time.Second
+
+// monitoredStates are the states a transfer is watched in.
+var monitoredStates = []string{
+	string(StateSigned), string(StateBroadcast), string(StateInMempool), string(StateConfirmed),
+}
+
+// finalityMonitor follows broadcast transactions on their nodes until they are
+// final, recording confirmation progress on the transfer and moving it back to
+// the mempool state when a reorg drops it from its block.
+type finalityMonitor struct {
+	s *Service
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	active map[string]bool // transfer IDs being watched
+}
+
+func newFinalityMonitor(s *Service) *finalityMonitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &finalityMonitor{s: s, ctx: ctx, cancel: cancel, active: make(map[string]bool)}
+}
+
+// watch starts following a transfer unless it is already being followed.
+func (m *finalityMonitor) watch(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active[id] || m.ctx.Err() != nil {
+		return
+	}
+	m.active[id] = true
+	m.wg.Add(1)
+	go m.run(id)
+}
+
+// stop ends all watches and waits for them to return.
+func (m *finalityMonitor) stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+func (m *finalityMonitor) run(id string) {
+	defer m.wg.Done()
+	defer func() {
+		m.mu.Lock()
+		delete(m.active, id)
+		m.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(m.s.pollInterval)
+	defer ticker.Stop()
+	for {
+		// Reload every round: another replica may have moved the transfer on
+		rec, err := m.s.store.GetTransferResult(m.ctx, id)
+		if err == nil && rec != nil {
+			if done, _ := m.step(m.ctx, rec); done {
+				return
+			}
+		}
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// step checks a transfer once, records any progress, and reports whether it
+// needs no further watching.
+func (m *finalityMonitor) step(ctx context.Context, rec *store.TransferResult) (bool, error) {
+	state := TransferState(rec.Status)
+	if state.IsTerminal() {
+		return true, nil
+	}
+	client, ok := m.s.clients[chain.Chain(rec.Chain)]
+	if !ok {
+		return m.simulate(ctx, rec)
+	}
+	if state == StateSigned {
+		return m.resumeBroadcast(ctx, rec, client)
+	}
+
+	status, err := client.TxStatus(ctx, rec.TxID)
+	if errors.Is(err, chain.ErrTxNotFound) {
+		// Evicted from the mempool, or orphaned and not yet back: send it again
+		if err := m.s.send(ctx, rec, client); err != nil {
+			if errors.Is(err, chain.ErrTxRejected) {
+				// Its inputs are free again, or the next UTXO sync drops them
+				_ = m.s.store.ReleaseUTXOs(ctx, rec.ID)
+				return true, m.s.transition(ctx, rec.ID, state, StateFailed, "dropped and rejected on rebroadcast: "+err.Error())
+			}
+			return false, err
+		}
+		// Accepted, or already confirmed where the node cannot look it up:
+		// either way the next poll tells, so leave the state as it is
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if status.Failed {
+		return true, m.s.transition(ctx, rec.ID, state, StateFailed,
+			fmt.Sprintf("failed on chain in block %d", status.BlockHeight))
+	}
+
+	// A confirmed transaction that lost its block went through a reorg
+	moved := rec.BlockHash != "" && status.BlockHash != rec.BlockHash
+	if state == StateConfirmed && (status.Confirmations == 0 || moved) {
+		reason := fmt.Sprintf("reorg: block %d (%s) no longer includes the transaction", rec.BlockHeight, rec.BlockHash)
+		if err := m.s.transition(ctx, rec.ID, state, StateInMempool, reason); err != nil {
+			return false, err
+		}
+		state = StateInMempool
+	}
+
+	if status.Confirmations == 0 {
+		if state == StateBroadcast {
+			if err := m.s.transition(ctx, rec.ID, state, StateInMempool, "seen in mempool"); err != nil {
+				return false, err
+			}
+			state = StateInMempool
+		}
+		return false, m.saveProgress(ctx, rec, state, status)
+	}
+
+	if state != StateConfirmed {
+		reason := fmt.Sprintf("included in block %d (%s)", status.BlockHeight, status.BlockHash)
+		if err := m.s.transition(ctx, rec.ID, state, StateConfirmed, reason); err != nil {
+			return false, err
+		}
+		state = StateConfirmed
+	}
+	if err := m.saveProgress(ctx, rec, state, status); err != nil {
+		return false, err
+	}
+
+	depth := m.s.finalityDepth(chain.Chain(rec.Chain))
+	if status.Confirmations >= depth || status.Finalized {
+		reason := fmt.Sprintf("%d confirmations", status.Confirmations)
+		return true, m.s.transition(ctx, rec.ID, state, StateFinalized, reason)
+	}
+	return false, nil
+}
+
+// saveProgress stores the transaction's inclusion details on the transfer if they changed.
+func (m *finalityMonitor) saveProgress(ctx context.Context, rec *store.TransferResult, state TransferState, status *chain.TxStatus) error {
+	if rec.Confirmations == status.Confirmations && rec.BlockHash == status.BlockHash && TransferState(rec.Status) == state {
+		return nil
+	}
+	updated := *rec
+	updated.Status = string(state)
+	updated.Confirmations = status.Confirmations
+	updated.BlockHash = status.BlockHash
+	updated.BlockHeight = status.BlockHeight
+	return m.s.store.SaveTransferResult(ctx, rec.ID, &updated)
+}
+
+// resumeBroadcast sends a transfer that was signed but, as far as the store
+// knows, never broadcast, e.g. because the process stopped in between.
+func (m *finalityMonitor) resumeBroadcast(ctx context.Context, rec *store.TransferResult, client chain.Client) (bool, error) {
+	err := m.s.broadcast(ctx, rec, client)
+	if errors.Is(err, chain.ErrTxRejected) {
+		_ = m.s.store.ReleaseUTXOs(ctx, rec.ID)
+		return true, m.s.transition(ctx, rec.ID, StateSigned, StateFailed, err.Error())
+	}
+	return false, err
+}
+
+// simulate advances a transfer on a chain without a node client: it enters the
+// mempool at once, confirms after the simulated delay and is final after as
+// long again.
+func (m *finalityMonitor) simulate(ctx context.Context, rec *store.TransferResult) (bool, error) {
+	state := TransferState(rec.Status)
+	if state == StateSigned {
+		if err := m.s.broadcast(ctx, rec, nil); err != nil {
+			return false, err
+		}
+		state = StateBroadcast
+	}
+	if state == StateBroadcast {
+		if err := m.s.transition(ctx, rec.ID, state, StateInMempool, "seen in mempool (simulated)"); err != nil {
+			return false, err
+		}
+		state = StateInMempool
+	}
+	if state == StateInMempool {
+		if err := m.wait(ctx); err != nil {
+			return true, err
+		}
+		if err := m.s.transition(ctx, rec.ID, state, StateConfirmed, "included in a block (simulated)"); err != nil {
+			return false, err
+		}
+		state = StateConfirmed
+	}
+	if state != StateConfirmed {
+		return true, nil
+	}
+	if err := m.wait(ctx); err != nil {
+		return true, err
+	}
+	reason := fmt.Sprintf("%d confirmations (simulated)", m.s.finalityDepth(chain.Chain(rec.Chain)))
+	return true, m.s.transition(ctx, rec.ID, state, StateFinalized, reason)
+}
+
+// wait sleeps for the simulated block delay unless ctx ends first.
+func (m *finalityMonitor) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(m.s.simulatedDelay):
+		return nil
+	}
+}
+
+// ResumeMonitoring picks up every transfer that was signed or broadcast but not
+// yet final, e.g. after a restart, and returns how many it is now watching.
+// Signed transfers that never reached the network are broadcast first.
+func (s *Service) ResumeMonitoring(ctx context.Context) (int, error) {
+	pending, err := s.store.ListTransfers(ctx, monitoredStates...)
+	if err != nil {
+		return 0, fmt.Errorf("list transfers failed: %w", err)
+	}
+	for _, rec := range pending {
+		s.monitor.watch(rec.ID)
+	}
+	return len(pending), nil
+}
+
+// Close stops watching transfers. Their progress stays in the store, so
+// ResumeMonitoring on a new Service continues where this one stopped.
+func (s *Service) Close() {
+	s.monitor.stop()
+}
+
+// broadcast sends a signed transfer to its node, or simulates that when client
+// is nil, settles the UTXOs it spends and records the broadcast.
+func (s *Service) broadcast(ctx context.Context, rec *store.TransferResult, client chain.Client) error {
+	reason := "broadcast (simulated)"
+	if client != nil {
+		if err := s.send(ctx, rec, client); err != nil {
+			return fmt.Errorf("broadcast failed: %w", err)
+		}
+		reason = "broadcast to " + rec.Chain
+	}
+	// The spent inputs leave the wallet for good; a no-op without a reservation
+	if err := s.store.CommitUTXOs(ctx, rec.ID); err != nil {
+		return fmt.Errorf("commit utxos failed: %w", err)
+	}
+	return s.transition(ctx, rec.ID, StateSigned, StateBroadcast, reason)
+}
+
+// send broadcasts a transfer's transaction. When the node finds what it
+// spends already spent, the transaction itself is looked up: if the node
+// knows it, it is the spender and the transfer carries on; otherwise another
+// transaction took its inputs or nonce, and it is rejected for good.
+func (s *Service) send(ctx context.Context, rec *store.TransferResult, client chain.Client) error {
+	_, err := client.Broadcast(ctx, rec.RawTx)
+	if !errors.Is(err, chain.ErrInputsSpent) {
+		return err
+	}
+	_, lookupErr := client.TxStatus(ctx, rec.TxID)
+	switch {
+	case lookupErr == nil:
+		return nil
+	case errors.Is(lookupErr, chain.ErrTxNotFound):
+		return fmt.Errorf("%w: spent by another transaction: %w", chain.ErrTxRejected, err)
+	default:
+		return lookupErr
+	}
+}
+
+// finalityDepth returns the confirmations after which a transfer on c is final.
+func (s *Service) finalityDepth(c chain.Chain) uint64 {
+	if depth, ok := s.finality[c]; ok {
+		return depth
+	}
+	if cfg, ok := chain.EVMChain(c); ok {
+		return cfg.Confirmations
+	}
+	if n, err := chain.BitcoinNetwork(c); err == nil {
+		return n.FinalityDepth
+	}
+	if depth, ok := defaultFinalityDepth[c]; ok {
+		return depth
+	}
+	return 1
+}
@@ -0,0 +1,467 @@
+// finality_test.go
+package custody
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"andi-custodian/internal/chain"
+	"andi-custodian/internal/store"
+	"andi-custodian/internal/wallet"
+	"andi-custodian/pkg/networks"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+var sepoliaChainID = big.NewInt(11155111)
+
+// newBitcoinNodeService funds the test signer's address on a fake node and
+// returns a service that polls it quickly.
+func newBitcoinNodeService(t *testing.T, opts ...Option) (*Service, *chain.FakeBitcoinNode, store.Store, string) {
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := wallet.NewSimulatedMPCSigner(seed)
+	pubKey, _ := signer.PublicKey(context.Background(), wallet.BitcoinTestnet)
+	from, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+
+	node := chain.NewFakeBitcoinNode(&chaincfg.TestNet3Params)
+	_, err := node.Fund(from.EncodeAddress(), 5_000_000)
+	require.NoError(t, err)
+
+	transferStore := store.NewInMemoryStore()
+	opts = append([]Option{WithClient(chain.BitcoinTestnet, node), WithPollInterval(5 * time.Millisecond)}, opts...)
+	service := NewService(signer, transferStore, opts...)
+	t.Cleanup(service.Close)
+	return service, node, transferStore, from.EncodeAddress()
+}
+
+func btcTransfer(id, from string) *TransferRequest {
+	return &TransferRequest{
+		ID:    id,
+		Chain: "bitcoin-testnet",
+		From:  from,
+		To:    "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm",
+		Value: "0.01",
+	}
+}
+
+func TestFinalityMonitor_ConfirmationDepth(t *testing.T) {
+	service, node, transferStore, from := newBitcoinNodeService(t)
+	ctx := context.Background()
+
+	_, err := service.Transfer(ctx, btcTransfer("depth-1", from))
+	require.NoError(t, err)
+	waitForStatus(t, transferStore, "depth-1", StateInMempool)
+
+	node.Mine(1)
+	waitForStatus(t, transferStore, "depth-1", StateConfirmed)
+	waitFor(t, func() bool {
+		rec, _ := transferStore.GetTransferResult(ctx, "depth-1")
+		return rec.Confirmations == 1 && rec.BlockHeight == node.Height() && rec.BlockHash != ""
+	})
+
+	// Five more blocks reach Bitcoin's six-confirmation threshold
+	node.Mine(4)
+	waitFor(t, func() bool {
+		rec, _ := transferStore.GetTransferResult(ctx, "depth-1")
+		return rec.Confirmations == 5
+	})
+	node.Mine(1)
+	waitForStatus(t, transferStore, "depth-1", StateFinalized)
+
+	history, _ := service.TransferHistory(ctx, "depth-1")
+	last := history[len(history)-1]
+	assert.Equal(t, string(StateConfirmed), last.From)
+	assert.Equal(t, "6 confirmations", last.Reason)
+}
+
+func TestFinalityMonitor_Reorg(t *testing.T) {
+	service, node, transferStore, from := newBitcoinNodeService(t, WithFinalityDepth(chain.BitcoinTestnet, 3))
+	ctx := context.Background()
+
+	_, err := service.Transfer(ctx, btcTransfer("reorg-1", from))
+	require.NoError(t, err)
+	node.Mine(1)
+	waitForStatus(t, transferStore, "reorg-1", StateConfirmed)
+	before, _ := transferStore.GetTransferResult(ctx, "reorg-1")
+
+	// The block is orphaned; the transaction waits in the mempool again
+	node.Reorg(1)
+	waitForStatus(t, transferStore, "reorg-1", StateInMempool)
+
+	node.Mine(3)
+	waitForStatus(t, transferStore, "reorg-1", StateFinalized)
+	after, _ := transferStore.GetTransferResult(ctx, "reorg-1")
+	assert.NotEqual(t, before.BlockHash, after.BlockHash)
+
+	history, _ := service.TransferHistory(ctx, "reorg-1")
+	var reorgs int
+	for _, tr := range history {
+		if tr.From == string(StateConfirmed) && tr.To == string(StateInMempool) {
+			reorgs++
+			assert.True(t, strings.HasPrefix(tr.Reason, "reorg:"), tr.Reason)
+		}
+	}
+	assert.Equal(t, 1, reorgs)
+}
+
+func TestFinalityMonitor_RebroadcastsDropped(t *testing.T) {
+	service, node, transferStore, from := newBitcoinNodeService(t)
+	ctx := context.Background()
+
+	res, err := service.Transfer(ctx, btcTransfer("dropped-1", from))
+	require.NoError(t, err)
+	waitForStatus(t, transferStore, "dropped-1", StateInMempool)
+
+	require.NoError(t, node.Drop(res.TxID))
+	waitFor(t, func() bool {
+		_, err := node.TxStatus(ctx, res.TxID)
+		return err == nil
+	})
+
+	node.Mine(6)
+	waitForStatus(t, transferStore, "dropped-1", StateFinalized)
+}
+
+// bitcoinRPCNode serves Bitcoin Core's JSON-RPC API from a fake node the way
+// a node without -txindex does: getrawtransaction only finds mempool
+// transactions, and confirmed ones are left to the wallet's gettransaction.
+type bitcoinRPCNode struct {
+	*chain.FakeBitcoinNode
+
+	mu           sync.Mutex
+	wallet       bool            // whether gettransaction knows the node's transactions
+	walletMisses int             // gettransaction calls to answer "unknown" first, e.g. while rescanning
+	sendErr      *chain.RPCError // answer to sendrawtransaction instead of relaying
+	sends        int
+}
+
+func (n *bitcoinRPCNode) set(f func(n *bitcoinRPCNode)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	f(n)
+}
+
+func (n *bitcoinRPCNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, rpcErr := n.call(r.Context(), req.Method, req.Params)
+	json.NewEncoder(w).Encode(map[string]any{"result": result, "error": rpcErr})
+}
+
+func (n *bitcoinRPCNode) call(ctx context.Context, method string, params []json.RawMessage) (any, *chain.RPCError) {
+	notFound := &chain.RPCError{Code: -5, Message: "No such mempool or blockchain transaction"}
+	var arg string
+	if len(params) > 0 {
+		json.Unmarshal(params[0], &arg)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch method {
+	case "listunspent":
+		var addrs []string
+		json.Unmarshal(params[2], &addrs)
+		utxos, _ := n.ListUnspent(ctx, addrs[0])
+		unspent := make([]map[string]any, len(utxos))
+		for i, u := range utxos {
+			unspent[i] = map[string]any{
+				"txid": u.TxID, "vout": u.VOut, "amount": btcutil.Amount(u.Value).ToBTC(),
+				"scriptPubKey": hex.EncodeToString(u.PkScript),
+			}
+		}
+		return unspent, nil
+	case "estimatesmartfee":
+		return map[string]any{"feerate": btcutil.Amount(n.FeeRate * 1000).ToBTC()}, nil
+	case "getblockcount":
+		return n.Height(), nil
+	case "getrawtransaction":
+		status, err := n.TxStatus(ctx, arg)
+		if err != nil || status.Confirmations > 0 {
+			return nil, notFound
+		}
+		return map[string]any{"confirmations": 0}, nil
+	case "gettransaction":
+		if !n.wallet || n.walletMisses > 0 {
+			if n.wallet {
+				n.walletMisses--
+			}
+			return nil, &chain.RPCError{Code: -5, Message: "Invalid or non-wallet transaction id"}
+		}
+		status, err := n.TxStatus(ctx, arg)
+		if err != nil {
+			return nil, notFound
+		}
+		return map[string]any{
+			"confirmations": status.Confirmations, "blockhash": status.BlockHash, "blockheight": status.BlockHeight,
+		}, nil
+	case "sendrawtransaction":
+		n.sends++
+		if n.sendErr != nil {
+			return nil, n.sendErr
+		}
+		raw, _ := hex.DecodeString(arg)
+		txID, err := n.Broadcast(ctx, raw)
+		if err != nil {
+			return nil, &chain.RPCError{Code: -26, Message: err.Error()}
+		}
+		return txID, nil
+	}
+	return nil, &chain.RPCError{Code: -32601, Message: "Method not found"}
+}
+
+// newBitcoinRPCService is newBitcoinNodeService with the service talking to
+// the fake node through BitcoinRPCClient.
+func newBitcoinRPCService(t *testing.T) (*Service, *bitcoinRPCNode, store.Store, string) {
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := wallet.NewSimulatedMPCSigner(seed)
+	pubKey, _ := signer.PublicKey(context.Background(), wallet.BitcoinTestnet)
+	from, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+
+	node := &bitcoinRPCNode{FakeBitcoinNode: chain.NewFakeBitcoinNode(&chaincfg.TestNet3Params)}
+	_, err := node.Fund(from.EncodeAddress(), 5_000_000)
+	require.NoError(t, err)
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+
+	transferStore := store.NewInMemoryStore()
+	client := chain.NewBitcoinRPCClient(srv.URL, "user", "pass", nil)
+	service := NewService(signer, transferStore,
+		WithClient(chain.BitcoinTestnet, client), WithPollInterval(5*time.Millisecond))
+	t.Cleanup(service.Close)
+	return service, node, transferStore, from.EncodeAddress()
+}
+
+func TestFinalityMonitor_RebroadcastAlreadyInChain(t *testing.T) {
+	service, node, transferStore, from := newBitcoinRPCService(t)
+	ctx := context.Background()
+
+	_, err := service.Transfer(ctx, btcTransfer("in-chain-1", from))
+	require.NoError(t, err)
+	waitForStatus(t, transferStore, "in-chain-1", StateInMempool)
+
+	// Confirmed, but neither getrawtransaction nor the wallet finds it; the
+	// rebroadcast gets -27 back
+	node.set(func(n *bitcoinRPCNode) {
+		n.sendErr = &chain.RPCError{Code: -27, Message: "Transaction already in block chain"}
+	})
+	node.Mine(1)
+	waitFor(t, func() bool {
+		var sends int
+		node.set(func(n *bitcoinRPCNode) { sends = n.sends })
+		return sends >= 3
+	})
+	rec, _ := transferStore.GetTransferResult(ctx, "in-chain-1")
+	assert.Equal(t, string(StateInMempool), rec.Status)
+
+	node.set(func(n *bitcoinRPCNode) { n.wallet = true })
+	node.Mine(5)
+	waitForStatus(t, transferStore, "in-chain-1", StateFinalized)
+}
+
+func TestFinalityMonitor_RebroadcastInputsSpent(t *testing.T) {
+	service, node, transferStore, from := newBitcoinRPCService(t)
+	ctx := context.Background()
+
+	_, err := service.Transfer(ctx, btcTransfer("spent-1", from))
+	require.NoError(t, err)
+	waitForStatus(t, transferStore, "spent-1", StateInMempool)
+
+	// The wallet misses the confirmed transaction once, so the monitor
+	// rebroadcasts and gets -25; looking the spender up finds the transfer
+	node.set(func(n *bitcoinRPCNode) {
+		n.wallet, n.walletMisses = true, 1
+		n.sendErr = &chain.RPCError{Code: -25, Message: "bad-txns-inputs-missingorspent"}
+	})
+	node.Mine(6)
+	waitForStatus(t, transferStore, "spent-1", StateFinalized)
+	history, _ := service.TransferHistory(ctx, "spent-1")
+	for _, tr := range history {
+		assert.NotEqual(t, string(StateFailed), tr.To)
+	}
+	var sends int
+	node.set(func(n *bitcoinRPCNode) { sends = n.sends })
+	assert.Equal(t, 2, sends)
+}
+
+func TestFinalityMonitor_RebroadcastInputsSpentElsewhere(t *testing.T) {
+	service, node, transferStore, from := newBitcoinRPCService(t)
+	ctx := context.Background()
+
+	res, err := service.Transfer(ctx, btcTransfer("conflict-1", from))
+	require.NoError(t, err)
+	waitForStatus(t, transferStore, "conflict-1", StateInMempool)
+
+	// Evicted, and another transaction spent its inputs meanwhile
+	node.set(func(n *bitcoinRPCNode) {
+		n.wallet = true
+		n.sendErr = &chain.RPCError{Code: -25, Message: "bad-txns-inputs-missingorspent"}
+	})
+	require.NoError(t, node.Drop(res.TxID))
+	waitForStatus(t, transferStore, "conflict-1", StateFailed)
+
+	history, _ := service.TransferHistory(ctx, "conflict-1")
+	assert.Contains(t, history[len(history)-1].Reason, "spent by another transaction")
+}
+
+func TestService_ResumeMonitoring(t *testing.T) {
+	service, node, transferStore, from := newBitcoinNodeService(t)
+	ctx := context.Background()
+
+	_, err := service.Transfer(ctx, btcTransfer("resume-1", from))
+	require.NoError(t, err)
+	waitForStatus(t, transferStore, "resume-1", StateInMempool)
+	service.Close()
+
+	// Blocks arrive while nothing is watching
+	node.Mine(6)
+	time.Sleep(20 * time.Millisecond)
+	rec, _ := transferStore.GetTransferResult(ctx, "resume-1")
+	assert.Equal(t, string(StateInMempool), rec.Status)
+
+	restarted := NewService(service.signer, transferStore,
+		WithClient(chain.BitcoinTestnet, node), WithPollInterval(5*time.Millisecond))
+	defer restarted.Close()
+	n, err := restarted.ResumeMonitoring(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	waitForStatus(t, transferStore, "resume-1", StateFinalized)
+}
+
+func TestService_ResumeMonitoring_BroadcastsSigned(t *testing.T) {
+	ctx := context.Background()
+	node := chain.NewFakeEVMNode(sepoliaChainID)
+	from := crypto.PubkeyToAddress(testKey.PublicKey)
+	node.SetBalance(from.Hex(), big.NewInt(1e18))
+	transferStore := store.NewInMemoryStore()
+
+	// A transfer the previous process signed but stopped before broadcasting
+	tx := types.MustSignNewTx(testKey, types.LatestSignerForChainID(sepoliaChainID), &types.DynamicFeeTx{
+		ChainID: sepoliaChainID, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1e10), Gas: 21000,
+	})
+	raw, _ := tx.MarshalBinary()
+	_, _, err := transferStore.ClaimTransfer(ctx, "signed-1", &store.TransferResult{
+		ID: "signed-1", Chain: "ethereum-sepolia", Status: string(StateRequested),
+	})
+	require.NoError(t, err)
+	for _, step := range [][2]TransferState{
+		{StateRequested, StatePolicyChecked}, {StatePolicyChecked, StateBuilt}, {StateBuilt, StateSigned},
+	} {
+		require.NoError(t, transferStore.RecordTransition(ctx, "signed-1", store.Transition{From: string(step[0]), To: string(step[1])}))
+	}
+	require.NoError(t, transferStore.SaveTransferResult(ctx, "signed-1", &store.TransferResult{
+		ID: "signed-1", Chain: "ethereum-sepolia", Status: string(StateSigned), TxID: tx.Hash().Hex(), RawTx: raw,
+	}))
+
+	service := NewService(&MockSigner{}, transferStore,
+		WithClient(chain.EthereumSepolia, node), WithFinalityDepth(chain.EthereumSepolia, 1),
+		WithPollInterval(5*time.Millisecond))
+	defer service.Close()
+	n, err := service.ResumeMonitoring(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	waitForStatus(t, transferStore, "signed-1", StateInMempool)
+	node.Mine(1)
+	waitForStatus(t, transferStore, "signed-1", StateFinalized)
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFinalityMonitor_SimulatedFinality(t *testing.T) {
+	ctx := context.Background()
+	transferStore := store.NewInMemoryStore()
+	service := NewService(&MockSigner{}, transferStore)
+	service.simulatedDelay = 5 * time.Millisecond
+	defer service.Close()
+
+	_, err := service.Transfer(ctx, &TransferRequest{
+		ID: "sim-1", Chain: "ethereum-sepolia", From: testEthFrom, To: testEthTo, Value: "1",
+	})
+	require.NoError(t, err)
+	waitForStatus(t, transferStore, "sim-1", StateFinalized)
+
+	history, err := service.TransferHistory(ctx, "sim-1")
+	require.NoError(t, err)
+	var states []TransferState
+	for _, tr := range history {
+		states = append(states, TransferState(tr.To))
+	}
+	assert.Equal(t, []TransferState{StateBroadcast, StateInMempool, StateConfirmed, StateFinalized}, states[len(states)-4:])
+
+	// A record left confirmed by a previous process is finalized on resume
+	// rather than picked up again on every restart
+	_, _, err = transferStore.ClaimTransfer(ctx, "sim-2", &store.TransferResult{
+		ID: "sim-2", Chain: "ethereum-sepolia", Status: string(StateConfirmed),
+	})
+	require.NoError(t, err)
+	n, err := service.ResumeMonitoring(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	waitForStatus(t, transferStore, "sim-2", StateFinalized)
+	pending, err := transferStore.ListTransfers(ctx, monitoredStates...)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestService_Transfer_Regtest(t *testing.T) {
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := wallet.NewSimulatedMPCSigner(seed)
+	pubKey, _ := signer.PublicKey(context.Background(), wallet.BitcoinRegtest)
+	from, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.RegressionNetParams)
+	to, _ := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), &chaincfg.RegressionNetParams)
+
+	node := chain.NewFakeBitcoinNode(&chaincfg.RegressionNetParams)
+	_, err := node.Fund(from.EncodeAddress(), 5_000_000)
+	require.NoError(t, err)
+	transferStore := store.NewInMemoryStore()
+	service := NewService(signer, transferStore, WithClient(chain.BitcoinRegtest, node), WithPollInterval(5*time.Millisecond))
+	t.Cleanup(service.Close)
+	ctx := context.Background()
+
+	req := &TransferRequest{ID: "regtest-1", Chain: "bitcoin-regtest", From: from.EncodeAddress(), To: to.EncodeAddress(), Value: "0.01"}
+	_, err = service.Transfer(ctx, req)
+	require.NoError(t, err)
+	waitForStatus(t, transferStore, "regtest-1", StateInMempool)
+
+	// Regtest blocks are mined on demand; one is final
+	node.Mine(1)
+	waitForStatus(t, transferStore, "regtest-1", StateFinalized)
+
+	// A testnet destination is refused before anything is signed
+	_, err = service.Transfer(ctx, &TransferRequest{
+		ID: "regtest-2", Chain: "bitcoin-regtest", From: from.EncodeAddress(),
+		To: "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm", Value: "0.01",
+	})
+	assert.ErrorIs(t, err, networks.ErrWrongNetwork)
+	history, _ := service.TransferHistory(ctx, "regtest-2")
+	require.NotEmpty(t, history)
+	assert.Equal(t, string(StateFailed), history[len(history)-1].To)
+}
@@ -37,3 +37,13 @@ func (nm *NonceManager) Reset(address string, nonce uint64) {
 		nm.nonces[address] = nonce
 	}
 }
+
+// Rollback returns nonce to the pool if it was the last one handed out for the
+// address, e.g. when its transaction never reached the network.
+func (nm *NonceManager) Rollback(address string, nonce uint64) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	if nm.nonces[address] == nonce+1 {
+		nm.nonces[address] = nonce
+	}
+}
@@ -67,3 +67,18 @@ func TestNonceManager_Reset(t *testing.T) {
 		t.Errorf("After reset, nonce = %d, want 5", next)
 	}
 }
+
+func TestNonceManager_Rollback(t *testing.T) {
+	nm := NewNonceManager()
+	addr := "0x123"
+
+	n0 := nm.GetNext(addr)
+	n1 := nm.GetNext(addr)
+
+	// Only the latest nonce can be returned; an earlier one would leave a gap
+	nm.Rollback(addr, n0)
+	nm.Rollback(addr, n1)
+	if got := nm.GetNext(addr); got != n1 {
+		t.Errorf("After rollback, next = %d, want %d", got, n1)
+	}
+}
@@ -0,0 +1,58 @@
+// options.go
+package custody
+
+import (
+	"time"
+
+	"andi-custodian/internal/chain"
+	"andi-custodian/internal/wallet"
+)
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClient connects the service to a node for one chain. Transfers on that
+// chain then take nonces, UTXOs and fees from the node and are broadcast to it;
+// chains without a client keep the simulated behavior.
+func WithClient(c chain.Chain, client chain.Client) Option {
+	return func(s *Service) {
+		s.clients[c] = client
+	}
+}
+
+// WithUTXOSelector replaces the default waste-minimizing coin selection.
+func WithUTXOSelector(selector UTXOSelector) Option {
+	return func(s *Service) {
+		s.utxoSelector = selector
+	}
+}
+
+// WithClaimLease sets how long a caller may take to sign a transfer it
+// claimed before another caller may take the request ID over.
+func WithClaimLease(d time.Duration) Option {
+	return func(s *Service) {
+		s.claimLease = d
+	}
+}
+
+// WithPollInterval sets how often broadcast transactions are checked on their node.
+func WithPollInterval(d time.Duration) Option {
+	return func(s *Service) {
+		s.pollInterval = d
+	}
+}
+
+// WithFinalityDepth sets the confirmations after which transfers on c are final.
+func WithFinalityDepth(c chain.Chain, confirmations uint64) Option {
+	return func(s *Service) {
+		s.finality[c] = confirmations
+	}
+}
+
+// WithWallet sets the HD wallet deposit addresses are derived from. Pass a
+// *wallet.WatchOnlyWallet so the issuing service never holds the seed.
+func WithWallet(w wallet.AddressDeriver) Option {
+	return func(s *Service) {
+		s.deriver = w
+	}
+}
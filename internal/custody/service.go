@@ -5,6 +5,8 @@ import (
 	"andi-custodian/internal/store"
 	"andi-custodian/pkg/tokens"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
@@ -13,123 +15,531 @@ import (
 
 	"andi-custodian/internal/chain"
 	"andi-custodian/internal/wallet"
+	"andi-custodian/pkg/chains"
 )
 
 // TransferRequest defines a custody transfer.
 type TransferRequest struct {
 	ID    string
-	Chain string
+	Chain chains.Chain
 	From  string
 	To    string
-	Asset string // "ETH", "USTC", "EUTC", "BAYC"
+	Asset string // "ETH", "USTC", "EUTC", "BAYC"; empty means the chain's native coin
 	Value string // "1.0", "1.000000", "12345"
 }
 
+const (
+	// defaultUTXOLease bounds how long a transfer may hold its inputs before
+	// they become spendable again, e.g. after a crash between build and broadcast.
+	defaultUTXOLease = 10 * time.Minute
+	// defaultClaimLease bounds how long a request ID stays claimed before its
+	// transfer is signed, e.g. after a crash between claim and signing.
+	defaultClaimLease = 5 * time.Minute
+	// maxReserveAttempts bounds rebuilds when a concurrent transfer wins the inputs.
+	maxReserveAttempts = 3
+	// defaultPollInterval is how often a broadcast transaction is looked up on its node.
+	defaultPollInterval = 15 * time.Second
+	// btcConfTarget is the confirmation target, in blocks, for Bitcoin fee estimates.
+	btcConfTarget = 6
+	// simulatedBlockhash anchors Solana transfers built without a node: 32 zero bytes.
+	simulatedBlockhash = "11111111111111111111111111111111"
+)
+
+var (
+	// ErrIdempotencyConflict is returned when a request ID is replayed with a different payload.
+	ErrIdempotencyConflict = errors.New("request id already used for a different transfer")
+	// ErrTransferInProgress is returned when another caller holds the claim on a request ID.
+	ErrTransferInProgress = errors.New("transfer with this request id is in progress")
+	// ErrTransferFailed is returned when a request ID is replayed after its transfer failed.
+	ErrTransferFailed = errors.New("transfer failed")
+)
+
 // Service orchestrates multi-chain custody operations.
 type Service struct {
 	signer       wallet.Signer
-	store        store.Store   // ← add store dependency
-	nonceManager *NonceManager // optional: or delegate to store
+	deriver      wallet.AddressDeriver // derives deposit addresses; optional
+	store        store.Store           // ← add store dependency
+	nonceManager *NonceManager         // optional: or delegate to store
 	utxoSelector UTXOSelector
-	idempotency  sync.Map
+	utxoLease    time.Duration
+	claimLease   time.Duration
+	clients      map[chain.Chain]chain.Client
+	pollInterval time.Duration
+	// simulatedDelay paces transfers on chains without a node client
+	simulatedDelay time.Duration
+	finality       map[chain.Chain]uint64
+	monitor        *finalityMonitor
 }
 
 // NewService creates a new custody service.
-func NewService(signer wallet.Signer, store store.Store) *Service {
-	return &Service{
-		signer:       signer,
-		store:        store,
-		nonceManager: NewNonceManager(),
-		utxoSelector: &GreedySelector{},
+func NewService(signer wallet.Signer, store store.Store, opts ...Option) *Service {
+	s := &Service{
+		signer:         signer,
+		store:          store,
+		nonceManager:   NewNonceManager(),
+		utxoSelector:   NewWasteSelector(),
+		utxoLease:      defaultUTXOLease,
+		claimLease:     defaultClaimLease,
+		clients:        make(map[chain.Chain]chain.Client),
+		pollInterval:   defaultPollInterval,
+		simulatedDelay: simulatedConfirmDelay,
+		finality:       make(map[chain.Chain]uint64),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.monitor = newFinalityMonitor(s)
+	return s
 }
 
 // Transfer initiates a custody transfer with idempotency.
-func (s *Service) Transfer(ctx context.Context, req *TransferRequest) (*store.TransferResult, error) {
-	// 1. Idempotency check
-	if existing, ok := s.idempotency.Load(req.ID); ok {
-		return existing.(*store.TransferResult), nil
+func (s *Service) Transfer(ctx context.Context, req *TransferRequest) (_ *store.TransferResult, err error) {
+	// 1. Resolve chain and asset, and parse value into base units
+	chainType := req.Chain
+	desc, err := chains.Lookup(chainType)
+	if err != nil {
+		return nil, err
 	}
-
-	// 2. Parse value (simplified: assume satoshis/wei based on chain)
-	// In production, use units package (e.g., 1.5 ETH → 1500000000000000000)
-	var valueInt int64 = 1_000_000_000_000_000_000 // 1 ETH or 0.01 BTC for demo
-
-	// 3. Build transaction
-	//convert string to chain.Chain
-	chainType := chain.Chain(req.Chain)
 	builder, err := chain.NewBuilder(chainType)
 	if err != nil {
 		return nil, err
 	}
 
-	_, ok := tokens.GetTokenBySymbol(req.Chain, req.Asset)
-	if !ok {
-		return nil, fmt.Errorf("unsupported asset: %s on %s", req.Asset, req.Chain)
+	token, err := resolveAsset(req.Chain, req.Asset)
+	if err != nil {
+		return nil, err
+	}
+	value, err := token.ParseAmount(req.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q for %s: %w", req.Value, token.Symbol, err)
+	}
+	if value.Sign() == 0 {
+		return nil, fmt.Errorf("invalid value %q: %w", req.Value, chain.ErrInvalidAmount)
 	}
 
+	// 2. Idempotency: claim the request ID, or replay what it already produced
+	fingerprint := requestFingerprint(req, token, value)
+	now := time.Now()
+	claim := &store.TransferResult{
+		ID:           req.ID,
+		Chain:        string(req.Chain),
+		Status:       string(StateRequested),
+		Timestamp:    now,
+		Fingerprint:  fingerprint,
+		ClaimedUntil: now.Add(s.claimLease),
+	}
+	existing, claimed, err := s.store.ClaimTransfer(ctx, req.ID, claim)
+	if err != nil {
+		return nil, fmt.Errorf("claim transfer failed: %w", err)
+	}
+	if !claimed {
+		state := TransferState(existing.Status)
+		switch {
+		// Records from before fingerprints, or saved without one, replay;
+		// a takeover writes the fingerprint
+		case existing.Fingerprint != "" && existing.Fingerprint != fingerprint:
+			return nil, fmt.Errorf("%s: %w", req.ID, ErrIdempotencyConflict)
+		case state == StateFailed || state == StateCancelled:
+			return nil, fmt.Errorf("%s is %s: %w", req.ID, state, ErrTransferFailed)
+		case state.HasTx():
+			return existing, nil
+		case !claimExpired(existing, now):
+			return nil, fmt.Errorf("%s: %w", req.ID, ErrTransferInProgress)
+		}
+		// Its claimer stopped before signing; of callers racing here one wins
+		tookOver, err := s.store.TakeOverTransfer(ctx, req.ID, existing, claim)
+		if err != nil {
+			return nil, fmt.Errorf("take over transfer failed: %w", err)
+		}
+		if !tookOver {
+			return nil, fmt.Errorf("%s: %w", req.ID, ErrTransferInProgress)
+		}
+	}
+
+	// Every step from here is recorded; an error marks the transfer failed,
+	// unless another caller took it over meanwhile and the cleanup is theirs
+	state := StateRequested
+	ownsClaim := sync.OnceValue(func() bool {
+		return s.holdsClaim(context.WithoutCancel(ctx), claim)
+	})
+	advance := func(to TransferState, reason string) error {
+		if err := s.transition(ctx, req.ID, state, to, reason); err != nil {
+			return err
+		}
+		state = to
+		return nil
+	}
+	defer func() {
+		if err != nil && !state.IsTerminal() && ownsClaim() {
+			_ = s.transition(context.WithoutCancel(ctx), req.ID, state, StateFailed, err.Error())
+		}
+	}()
+	if err := advance(StatePolicyChecked, "request validated"); err != nil {
+		return nil, err
+	}
+
+	// 3. Build transaction
 	var opts chain.BuildOptions
-	switch chainType {
-	case chain.EthereumSepolia:
-		opts.Nonce = s.nonceManager.GetNext(req.From)
-	case chain.BitcoinTestnet:
-		// In production, fetch UTXOs from indexer or store
-		opts.UTXOs = []chain.UTXO{
-			{TxID: "mock", VOut: 0, Value: 2000000000}, // 20 BTC
+	switch desc.Family {
+	case chains.FamilyEVM:
+		if err := s.prepareEVM(ctx, chainType, req.From, &opts); err != nil {
+			return nil, err
+		}
+	case chains.FamilyUTXO:
+		if err := s.prepareBitcoin(ctx, chainType, req.From, &opts); err != nil {
+			return nil, err
+		}
+	case chains.FamilySolana:
+		if err := s.prepareSolana(ctx, chainType, token, req.To, &opts); err != nil {
+			return nil, err
 		}
 	default:
 		return nil, errors.New("unsupported chain")
 	}
+	// A nonce whose transaction never reached the network is handed out again
+	broadcast := false
+	if desc.Supports(chains.CapAccountNonce) {
+		defer func() {
+			if err != nil && !broadcast && ownsClaim() {
+				s.nonceManager.Rollback(nonceKey(chainType, req.From), opts.Nonce)
+			}
+		}()
+	}
 
-	txReq := &chain.TxRequest{
-		Chain: chainType,
-		From:  req.From,
-		To:    req.To,
-		Value: big.NewInt(valueInt),
-		ID:    req.ID,
+	var tx *chain.TxResult
+	if desc.Supports(chains.CapUTXO) {
+		tx, err = s.buildAndReserve(ctx, builder, token, req, value, opts)
+	} else {
+		tx, err = buildTx(builder, token, req, value, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Reserved inputs go back to the spendable set unless the transfer goes out
+	reserved := desc.Supports(chains.CapUTXO)
+	defer func() {
+		if reserved && ownsClaim() {
+			_ = s.store.ReleaseUTXOs(context.WithoutCancel(ctx), req.ID)
+		}
+	}()
+	if err := advance(StateBuilt, fmt.Sprintf("estimated fee %d", tx.EstimatedFee)); err != nil {
+		return nil, err
 	}
 
-	tx, err := builder.BuildTx(txReq, opts)
+	// 4. Sign every sighash and assemble the signed transaction
+	hashes, err := builder.SigHashes(tx)
 	if err != nil {
-		return nil, fmt.Errorf("build tx failed: %w", err)
+		return nil, fmt.Errorf("compute sighash failed: %w", err)
+	}
+	path, err := s.signingPath(ctx, req.Chain, req.From)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := s.witnessPubKey(ctx, chainType, path)
+	if err != nil {
+		return nil, err
+	}
+	// Signing ends with the claim, so a claimer that lost it cannot sign
+	// alongside the caller that took the transfer over
+	signCtx, cancel := context.WithDeadline(ctx, claim.ClaimedUntil)
+	defer cancel()
+	sigs := make([]chain.Signature, len(hashes))
+	for i, hash := range hashes {
+		sig, err := s.signer.Sign(signCtx, wallet.SignRequest{
+			Chain:   req.Chain,
+			Payload: hash,
+			Path:    path,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("signing failed: %w", err)
+		}
+		sigs[i] = chain.Signature{Sig: sig, PubKey: pubKey}
 	}
+	signed, err := builder.Finalize(tx, sigs)
+	if err != nil {
+		return nil, fmt.Errorf("finalize tx failed: %w", err)
+	}
+	if signCtx.Err() != nil {
+		return nil, fmt.Errorf("claim on %s lapsed while signing", req.ID)
+	}
+
+	result := &store.TransferResult{
+		ID:           req.ID,
+		Chain:        string(req.Chain),
+		TxID:         signed.TxID,
+		RawTx:        signed.RawTx,
+		Status:       string(state),
+		Timestamp:    time.Now(),
+		Fingerprint:  fingerprint,
+		ClaimedUntil: claim.ClaimedUntil,
+	}
+	if err := s.store.SaveTransferResult(ctx, req.ID, result); err != nil {
+		return nil, fmt.Errorf("save transfer failed: %w", err)
+	}
+	if err := advance(StateSigned, "signed "+signed.TxID); err != nil {
+		return nil, err
+	}
+	// The inputs are spent from here on, not leased: their lease could lapse
+	// while the monitor retries a broadcast, and another transfer pick them
+	// up. A rejection still releases them.
+	if err := s.store.CommitUTXOs(ctx, req.ID); err != nil {
+		return nil, fmt.Errorf("commit utxos failed: %w", err)
+	}
+
+	// 5. Broadcast. Only a rejection proves the transaction never went out;
+	// after any other failure it may have, so it keeps its nonce and inputs,
+	// stays signed, and the monitor sends it again
+	if err := s.broadcast(ctx, result, s.clients[chainType]); err != nil {
+		if errors.Is(err, chain.ErrTxRejected) {
+			return nil, err
+		}
+		broadcast, reserved = true, false
+		result.Status = string(state)
+		s.monitor.watch(req.ID)
+		return result, nil
+	}
+	broadcast, reserved, state = true, false, StateBroadcast
+	result.Status = string(state)
+
+	// 6. Follow the transaction until it is final (in background)
+	s.monitor.watch(req.ID)
 
-	// 4. Sign transaction
-	// Note: In real system, payload = tx hash (sighash for BTC, keccak256 for ETH)
-	sig, err := s.signer.Sign(ctx, wallet.SignRequest{
-		Chain:   wallet.Chain(req.Chain),
-		Payload: tx.RawTx, // simplified; real system uses tx hash
+	return result, nil
+}
+
+// holdsClaim reports whether the transfer is still under claim, i.e. nobody
+// took it over. When the store cannot tell, the claim is assumed held.
+func (s *Service) holdsClaim(ctx context.Context, claim *store.TransferResult) bool {
+	rec, err := s.store.GetTransferResult(ctx, claim.ID)
+	if err != nil || rec == nil {
+		return true
+	}
+	return rec.ClaimedUntil.Equal(claim.ClaimedUntil)
+}
+
+// claimExpired reports whether a transfer that has no signed transaction yet
+// is claimed by nobody any more: its lease ran out in one of the states a
+// claimer moves through on its own. Transfers from before leases never lapse.
+func claimExpired(rec *store.TransferResult, now time.Time) bool {
+	switch TransferState(rec.Status) {
+	case StateRequested, StatePolicyChecked, StateBuilt:
+		return !rec.ClaimedUntil.IsZero() && now.After(rec.ClaimedUntil)
+	}
+	return false
+}
+
+// TransferHistory returns every recorded state transition of a transfer, oldest first.
+func (s *Service) TransferHistory(ctx context.Context, id string) ([]store.Transition, error) {
+	return s.store.GetTransitions(ctx, id)
+}
+
+// transition moves a transfer between lifecycle states, rejecting moves the
+// lifecycle does not allow, and persists the step with its reason.
+func (s *Service) transition(ctx context.Context, id string, from, to TransferState, reason string) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%s to %s: %w", from, to, ErrInvalidTransition)
+	}
+	return s.store.RecordTransition(ctx, id, store.Transition{
+		From:      string(from),
+		To:        string(to),
+		Reason:    reason,
+		Timestamp: time.Now(),
 	})
+}
+
+// prepareEVM fills in the nonce and, with a node client, current fees.
+func (s *Service) prepareEVM(ctx context.Context, chainType chain.Chain, from string, opts *chain.BuildOptions) error {
+	key := nonceKey(chainType, from)
+	if client, ok := s.clients[chainType].(chain.EVMClient); ok {
+		// The node's pending count covers transactions sent from elsewhere
+		pending, err := client.PendingNonce(ctx, from)
+		if err != nil {
+			return fmt.Errorf("fetch nonce failed: %w", err)
+		}
+		s.nonceManager.Reset(key, pending)
+
+		fees, err := client.SuggestFees(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch fees failed: %w", err)
+		}
+		opts.GasPrice = fees.GasPrice
+		opts.GasTipCap = fees.GasTipCap
+		if fees.BaseFee != nil {
+			// Room for the base fee to double before the transaction stops being includable
+			opts.GasFeeCap = new(big.Int).Add(new(big.Int).Mul(fees.BaseFee, big.NewInt(2)), fees.GasTipCap)
+		}
+	}
+	opts.Nonce = s.nonceManager.GetNext(key)
+	return nil
+}
+
+// nonceKey identifies an account's nonce sequence: the same address has an
+// independent sequence on every EVM chain.
+func nonceKey(chainType chain.Chain, address string) string {
+	return string(chainType) + "/" + address
+}
+
+// prepareBitcoin sets the coin selector and, with a node client, refreshes the
+// address's UTXOs in the store and estimates the fee rate.
+func (s *Service) prepareBitcoin(ctx context.Context, chainType chain.Chain, from string, opts *chain.BuildOptions) error {
+	opts.Selector = s.utxoSelector
+	client, ok := s.clients[chainType].(chain.BitcoinClient)
+	if !ok {
+		return nil
+	}
+	utxos, err := client.ListUnspent(ctx, from)
+	if err != nil {
+		return fmt.Errorf("list utxos failed: %w", err)
+	}
+	if err := s.store.SaveUTXOs(ctx, from, utxos); err != nil {
+		return fmt.Errorf("save utxos failed: %w", err)
+	}
+	feeRate, err := client.EstimateFeeRate(ctx, btcConfTarget)
 	if err != nil {
-		return nil, fmt.Errorf("signing failed: %w", err)
+		return fmt.Errorf("estimate fee rate failed: %w", err)
 	}
+	opts.FeeRate = feeRate
+	return nil
+}
 
-	// 5. Broadcast would happen here (simulated)
-	txID := fmt.Sprintf("mock-tx-%x", sig[:8])
+// prepareSolana sets the recent blockhash the transaction is anchored to and,
+// for tokens, whether the recipient's token account must be created first.
+// Without a node client nothing is sent, so any well-formed blockhash does, and
+// the account is always created: the instruction is a no-op when it exists.
+func (s *Service) prepareSolana(ctx context.Context, chainType chain.Chain, token *tokens.Token, to string, opts *chain.BuildOptions) error {
+	client, ok := s.clients[chainType].(chain.SolanaClient)
+	if !ok {
+		opts.RecentBlockhash = simulatedBlockhash
+		opts.CreateTokenAccount = !token.IsNative()
+		return nil
+	}
+	blockhash, err := client.LatestBlockhash(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch blockhash failed: %w", err)
+	}
+	opts.RecentBlockhash = blockhash
+	if token.IsNative() {
+		return nil
+	}
 
-	result := &store.TransferResult{
-		TxID:      txID,
-		Status:    "pending",
-		Timestamp: time.Now(),
+	recipient, err := chain.ParseSolanaPublicKey(to)
+	if err != nil {
+		return err
+	}
+	account, err := chain.SolanaAssociatedTokenAddress(recipient, chain.SolanaPublicKey(token.Mint))
+	if err != nil {
+		return err
+	}
+	exists, err := client.AccountExists(ctx, account.String())
+	if err != nil {
+		return fmt.Errorf("look up token account failed: %w", err)
 	}
+	opts.CreateTokenAccount = !exists
+	return nil
+}
 
-	// 6. Store for idempotency
-	s.idempotency.Store(req.ID, result)
+// buildTx builds a native transfer or, for tokens, a token transfer.
+func buildTx(builder chain.Builder, token *tokens.Token, req *TransferRequest, value *big.Int, opts chain.BuildOptions) (*chain.TxResult, error) {
+	chainType := req.Chain
+	var (
+		tx  *chain.TxResult
+		err error
+	)
+	if token.IsNative() {
+		tx, err = builder.BuildTx(&chain.TxRequest{
+			Chain: chainType,
+			From:  req.From,
+			To:    req.To,
+			Value: value,
+			ID:    req.ID,
+		}, opts)
+	} else {
+		tokenBuilder, ok := builder.(chain.TokenBuilder)
+		if !ok {
+			return nil, fmt.Errorf("token transfers not supported on %s", req.Chain)
+		}
+		tx, err = tokenBuilder.BuildTokenTransfer(&chain.TokenTransferRequest{
+			Chain:  chainType,
+			From:   req.From,
+			To:     req.To,
+			Token:  token.Symbol,
+			Amount: value,
+			ID:     req.ID,
+		}, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("build tx failed: %w", err)
+	}
+	return tx, nil
+}
 
-	// 7. Start monitoring finality (in background)
-	go s.monitorFinality(chainType, txID, req.ID)
+// buildAndReserve funds a Bitcoin transfer from the sender's spendable UTXOs and leases
+// the selected inputs to the request. When a concurrent transfer reserves one of them
+// first, selection is repeated over what is left.
+func (s *Service) buildAndReserve(ctx context.Context, builder chain.Builder, token *tokens.Token, req *TransferRequest, value *big.Int, opts chain.BuildOptions) (*chain.TxResult, error) {
+	for attempt := 0; ; attempt++ {
+		utxos, err := s.store.GetSpendableUTXOs(ctx, req.From)
+		if err != nil {
+			return nil, fmt.Errorf("load utxos failed: %w", err)
+		}
+		opts.UTXOs = utxos
+		tx, err := buildTx(builder, token, req, value, opts)
+		if err != nil {
+			return nil, err
+		}
+		outpoints := make([]chain.OutPoint, len(tx.Inputs))
+		for i, in := range tx.Inputs {
+			outpoints[i] = in.OutPoint()
+		}
+		err = s.store.ReserveUTXOs(ctx, req.ID, outpoints, s.utxoLease)
+		if err == nil {
+			return tx, nil
+		}
+		if !errors.Is(err, store.ErrUTXOReserved) || attempt+1 == maxReserveAttempts {
+			return nil, fmt.Errorf("reserve utxos failed: %w", err)
+		}
+	}
+}
 
-	return result, nil
+// requestFingerprint hashes the fields that define a transfer. The value is taken in
+// base units so "1" and "1.0" are the same request.
+func requestFingerprint(req *TransferRequest, token *tokens.Token, value *big.Int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%s|%s", req.Chain, req.From, req.To, token.Symbol, value)
+	return hex.EncodeToString(h.Sum(nil))
 }
 
-// monitorFinality simulates finality confirmation.
-func (s *Service) monitorFinality(chain chain.Chain, txID, id string) {
-	// In production: poll RPC, wait for N confirmations
-	time.Sleep(5 * time.Second)
+// witnessPubKey returns the signer's public key on chains whose signed
+// transactions embed it (Bitcoin witnesses), and nil elsewhere.
+func (s *Service) witnessPubKey(ctx context.Context, chainType chain.Chain, path wallet.DerivationPath) ([]byte, error) {
+	if chainType.Family() != chains.FamilyUTXO {
+		return nil, nil
+	}
+	provider, ok := s.signer.(wallet.PublicKeyProvider)
+	if !ok {
+		return nil, errors.New("signer cannot provide the public key required for witnesses")
+	}
+	pubKey, err := provider.PublicKeyAt(ctx, chainType, path)
+	if err != nil {
+		return nil, fmt.Errorf("public key lookup failed: %w", err)
+	}
+	return pubKey, nil
+}
 
-	// Update status (in real system, use a store)
-	if existing, ok := s.idempotency.Load(id); ok {
-		res := existing.(*store.TransferResult)
-		res.Status = "confirmed"
+// resolveAsset looks up the token for a transfer. An empty asset means the chain's native coin.
+func resolveAsset(chainName chains.Chain, asset string) (*tokens.Token, error) {
+	if cfg, ok := chain.EVMChain(chainName); ok && (asset == "" || asset == cfg.NativeToken.Symbol) {
+		return cfg.NativeToken, nil
+	}
+	if asset == "" {
+		if token, ok := tokens.NativeToken(chainName); ok {
+			return token, nil
+		}
+		return nil, fmt.Errorf("no native asset for chain %s", chainName)
+	}
+	token, ok := tokens.GetTokenBySymbol(chainName, asset)
+	if !ok {
+		return nil, fmt.Errorf("unsupported asset: %s on %s", asset, chainName)
 	}
+	return token, nil
 }
@@ -2,15 +2,37 @@
 package custody
 
 import (
+	"andi-custodian/internal/chain"
 	"andi-custodian/internal/store"
+	"andi-custodian/pkg/chains"
+	"andi-custodian/pkg/networks"
+	"andi-custodian/pkg/tokens"
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+	"math/big"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"andi-custodian/internal/wallet"
 )
 
+// testKey is the key MockSigner signs with, so finalized EVM transactions are valid.
+var testKey, _ = crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+
 // MockSigner for testing
 type MockSigner struct {
 	signFunc func(ctx context.Context, req wallet.SignRequest) ([]byte, error)
@@ -20,14 +42,23 @@ func (m *MockSigner) Sign(ctx context.Context, req wallet.SignRequest) ([]byte,
 	if m.signFunc != nil {
 		return m.signFunc(ctx, req)
 	}
-	return []byte("mock-signature"), nil
+	return crypto.Sign(req.Payload, testKey)
+}
+
+func (m *MockSigner) PublicKey(ctx context.Context, chain wallet.Chain) ([]byte, error) {
+	return m.PublicKeyAt(ctx, chain, nil)
+}
+
+// PublicKeyAt returns testKey whatever the path, so it controls testEthFrom.
+func (m *MockSigner) PublicKeyAt(ctx context.Context, chain wallet.Chain, path wallet.DerivationPath) ([]byte, error) {
+	return crypto.CompressPubkey(&testKey.PublicKey), nil
 }
 
 // These are real-looking, valid-length, checksum-compliant addresses.
 // 💡 For Bitcoin tests later, use a valid testnet Bech32 address like:
 // tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm
 const (
-	testEthFrom = "0x8E76C1897e55d208b2b5f45cDb43FD7d403a9a31"
+	testEthFrom = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23" // testKey's address
 	testEthTo   = "0x742d35Cc6634C0532925a3b844Bc9dbd8b5E8a18"
 )
 
@@ -84,7 +115,8 @@ func TestService_Transfer_SigningError(t *testing.T) {
 
 func TestService_Transfer_Monitoring(t *testing.T) {
 	signer := &MockSigner{}
-	service := newTestService(t, signer)
+	transferStore := store.NewInMemoryStore()
+	service := NewService(signer, transferStore)
 
 	req := &TransferRequest{
 		ID:    "req-monitor",
@@ -99,22 +131,261 @@ func TestService_Transfer_Monitoring(t *testing.T) {
 		t.Fatalf("Transfer failed: %v", err)
 	}
 
-	if res.Status != "pending" {
-		t.Errorf("Initial status = %s, want 'pending'", res.Status)
+	if res.Status != string(StateBroadcast) {
+		t.Errorf("Initial status = %s, want %q", res.Status, StateBroadcast)
 	}
 
 	// Wait for simulated finality
 	time.Sleep(6 * time.Second)
 
-	// Check idempotency store for updated status
-	if existing, ok := service.idempotency.Load(req.ID); ok {
-		finalRes := existing.(*store.TransferResult)
-		if finalRes.Status != "confirmed" {
-			t.Errorf("Final status = %s, want 'confirmed'", finalRes.Status)
+	// Check the store for updated status
+	finalRes, err := transferStore.GetTransferResult(context.Background(), req.ID)
+	if err != nil || finalRes == nil {
+		t.Fatalf("Result not found in store: %v", err)
+	}
+	if finalRes.Status != "confirmed" {
+		t.Errorf("Final status = %s, want 'confirmed'", finalRes.Status)
+	}
+}
+
+func TestService_Transfer_IdempotencySurvivesRestart(t *testing.T) {
+	transferStore := store.NewInMemoryStore()
+	req := &TransferRequest{
+		ID:    "req-restart",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Value: "1",
+	}
+
+	res1, err := NewService(&MockSigner{}, transferStore).Transfer(context.Background(), req)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// A fresh service over the same store must not sign again
+	signer := &MockSigner{signFunc: func(ctx context.Context, req wallet.SignRequest) ([]byte, error) {
+		t.Error("replayed request was signed again")
+		return crypto.Sign(req.Payload, testKey)
+	}}
+	res2, err := NewService(signer, transferStore).Transfer(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, res1.TxID, res2.TxID)
+}
+
+func TestService_Transfer_IdempotencyConflict(t *testing.T) {
+	service := newTestService(t, &MockSigner{})
+	req := &TransferRequest{
+		ID:    "req-conflict",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Value: "1",
+	}
+	_, err := service.Transfer(context.Background(), req)
+	assert.NoError(t, err)
+
+	// The same amount written differently is the same request
+	same := *req
+	same.Value = "1.000"
+	_, err = service.Transfer(context.Background(), &same)
+	assert.NoError(t, err)
+
+	changed := *req
+	changed.Value = "2"
+	_, err = service.Transfer(context.Background(), &changed)
+	assert.ErrorIs(t, err, ErrIdempotencyConflict)
+}
+
+func TestService_Transfer_ReplaysWithoutFingerprint(t *testing.T) {
+	ctx := context.Background()
+	transferStore := store.NewInMemoryStore()
+	service := NewService(&MockSigner{}, transferStore)
+	req := &TransferRequest{
+		ID:    "req-legacy",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Value: "1",
+	}
+
+	// A transfer recorded before requests were fingerprinted
+	legacy := &store.TransferResult{ID: req.ID, Chain: "ethereum-sepolia", TxID: "0xabc", Status: string(StateBroadcast)}
+	require.NoError(t, transferStore.SaveTransferResult(ctx, req.ID, legacy))
+
+	res, err := service.Transfer(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "0xabc", res.TxID)
+}
+
+func TestService_Transfer_UnknownChain(t *testing.T) {
+	transferStore := store.NewInMemoryStore()
+	service := NewService(&MockSigner{}, transferStore)
+	_, err := service.Transfer(context.Background(), &TransferRequest{
+		ID: "req-unknown", Chain: "ethereum-mainnet", From: testEthFrom, To: testEthTo, Value: "1",
+	})
+	assert.ErrorIs(t, err, chains.ErrUnknownChain)
+
+	// Nothing is claimed, so the ID stays free
+	rec, _ := transferStore.GetTransferResult(context.Background(), "req-unknown")
+	assert.Nil(t, rec)
+}
+
+func TestService_Transfer_ConcurrentSameID(t *testing.T) {
+	var signs sync.WaitGroup
+	release := make(chan struct{})
+	signer := &MockSigner{signFunc: func(ctx context.Context, req wallet.SignRequest) ([]byte, error) {
+		signs.Done()
+		<-release // hold the claim until every racer has tried
+		return crypto.Sign(req.Payload, testKey)
+	}}
+	signs.Add(1)
+	service := newTestService(t, signer)
+	req := &TransferRequest{
+		ID:    "req-race",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Value: "1",
+	}
+
+	const workers = 8
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Transfer(context.Background(), req)
+			errs <- err
+		}()
+	}
+	signs.Wait() // exactly one caller reaches the signer
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	var ok, inProgress int
+	for err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case errors.Is(err, ErrTransferInProgress):
+			inProgress++
+		default:
+			t.Errorf("unexpected error: %v", err)
 		}
-	} else {
-		t.Error("Result not found in idempotency store")
 	}
+	assert.GreaterOrEqual(t, ok, 1)
+	assert.Equal(t, workers, ok+inProgress)
+}
+
+func TestService_Transfer_TakesOverStaleClaim(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	signer := &MockSigner{signFunc: func(ctx context.Context, req wallet.SignRequest) ([]byte, error) {
+		if calls.Add(1) == 1 {
+			<-release // the first claimer hangs past its lease
+		}
+		return crypto.Sign(req.Payload, testKey)
+	}}
+	transferStore := store.NewInMemoryStore()
+	service := NewService(signer, transferStore, WithClaimLease(100*time.Millisecond))
+	defer service.Close()
+	req := &TransferRequest{
+		ID:    "req-stale",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Value: "1",
+	}
+
+	stale := make(chan error, 1)
+	go func() {
+		_, err := service.Transfer(context.Background(), req)
+		stale <- err
+	}()
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	// Within the lease the claim holds
+	_, err := service.Transfer(context.Background(), req)
+	assert.ErrorIs(t, err, ErrTransferInProgress)
+
+	time.Sleep(150 * time.Millisecond)
+	res, err := service.Transfer(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, string(StateBroadcast), res.Status)
+
+	// The first claimer can no longer sign, nor fail the transfer it lost
+	close(release)
+	assert.Error(t, <-stale)
+	rec, err := transferStore.GetTransferResult(context.Background(), req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, res.TxID, rec.TxID)
+	assert.True(t, TransferState(rec.Status).HasTx(), rec.Status)
+
+	history, _ := service.TransferHistory(context.Background(), req.ID)
+	var tookOver bool
+	for _, tr := range history {
+		tookOver = tookOver || (tr.From == string(StateBuilt) && tr.To == string(StateRequested))
+	}
+	assert.True(t, tookOver, "history %v", history)
+}
+
+func TestService_Transfer_FailureIsRecorded(t *testing.T) {
+	signer := &MockSigner{signFunc: func(ctx context.Context, req wallet.SignRequest) ([]byte, error) {
+		return nil, wallet.ErrSigningFailed
+	}}
+	service := newTestService(t, signer)
+	req := &TransferRequest{
+		ID:    "req-fail",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Value: "1",
+	}
+
+	_, err := service.Transfer(context.Background(), req)
+	assert.ErrorIs(t, err, wallet.ErrSigningFailed)
+
+	// A replay reports the failure instead of signing again
+	_, err = service.Transfer(context.Background(), req)
+	assert.ErrorIs(t, err, ErrTransferFailed)
+
+	history, err := service.TransferHistory(context.Background(), req.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 4) {
+		assert.Equal(t, string(StateBuilt), history[3].From)
+		assert.Equal(t, string(StateFailed), history[3].To)
+		assert.Contains(t, history[3].Reason, "signing failed")
+	}
+}
+
+func TestService_TransferHistory(t *testing.T) {
+	service := newTestService(t, &MockSigner{})
+	req := &TransferRequest{
+		ID:    "req-history",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Value: "1",
+	}
+	_, err := service.Transfer(context.Background(), req)
+	assert.NoError(t, err)
+
+	history, err := service.TransferHistory(context.Background(), req.ID)
+	assert.NoError(t, err)
+	var states []TransferState
+	for i, tr := range history {
+		states = append(states, TransferState(tr.To))
+		assert.False(t, tr.Timestamp.IsZero())
+		if i > 0 {
+			assert.Equal(t, history[i-1].To, tr.From)
+		}
+	}
+	// The monitor may already have seen the mempool
+	assert.Equal(t, []TransferState{StateRequested, StatePolicyChecked, StateBuilt, StateSigned, StateBroadcast}, states[:5])
 }
 
 func TestService_Transfer_Avalanche(t *testing.T) {
@@ -125,16 +396,603 @@ func TestService_Transfer_Avalanche(t *testing.T) {
 	req := &TransferRequest{
 		ID:    "avax-1",
 		Chain: "avalanche-fuji",
-		From:  "0x8E76C1897e55d208b2b5f45cDb43FD7d403a9a31",
+		From:  testEthFrom,
 		To:    "0x742d35Cc6634C0532925a3b844Bc9dbd8b5E8a18",
 		Value: "1",
 	}
 
 	res, err := service.Transfer(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, string(StateBroadcast), res.Status)
+
+	tx := new(types.Transaction)
+	require.NoError(t, tx.UnmarshalBinary(res.RawTx))
+	assert.Equal(t, big.NewInt(43113), tx.ChainId())
+	assert.Equal(t, "1000000000000000000", tx.Value().String())
+}
+
+func TestService_Transfer_ParsesValue(t *testing.T) {
+	service := newTestService(t, &MockSigner{})
+
+	res, err := service.Transfer(context.Background(), &TransferRequest{
+		ID:    "req-parse",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Asset: "ETH",
+		Value: "0.25",
+	})
+	assert.NoError(t, err)
+
+	tx := new(types.Transaction)
+	assert.NoError(t, tx.UnmarshalBinary(res.RawTx))
+	assert.Equal(t, "250000000000000000", tx.Value().String())
+}
+
+func TestService_Transfer_Token(t *testing.T) {
+	service := newTestService(t, &MockSigner{})
+
+	res, err := service.Transfer(context.Background(), &TransferRequest{
+		ID:    "req-usdc",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Asset: "USDC",
+		Value: "12.5",
+	})
+	assert.NoError(t, err)
+
+	tx := new(types.Transaction)
+	assert.NoError(t, tx.UnmarshalBinary(res.RawTx))
+	assert.Equal(t, tokens.USDC_Sepolia.Contract, *tx.To())
+	// transfer(address,uint256): selector + padded address + padded amount
+	amount := new(big.Int).SetBytes(tx.Data()[4+32:])
+	assert.Equal(t, int64(12_500_000), amount.Int64())
+}
+
+func TestService_Transfer_TokenInvalidRecipient(t *testing.T) {
+	signer := &MockSigner{signFunc: func(ctx context.Context, req wallet.SignRequest) ([]byte, error) {
+		t.Error("signed a token transfer to an invalid recipient")
+		return nil, wallet.ErrSigningFailed
+	}}
+	service := newTestService(t, signer)
+
+	for i, to := range []string{"", "0x123", "not-an-address"} {
+		_, err := service.Transfer(context.Background(), &TransferRequest{
+			ID:    fmt.Sprintf("req-usdc-bad-%d", i),
+			Chain: "ethereum-sepolia",
+			From:  testEthFrom,
+			To:    to,
+			Asset: "USDC",
+			Value: "12.5",
+		})
+		assert.ErrorIs(t, err, chain.ErrInvalidAddress, to)
+	}
+}
+
+func TestService_Transfer_SignsTxHash(t *testing.T) {
+	var payload []byte
+	signer := &MockSigner{
+		signFunc: func(ctx context.Context, req wallet.SignRequest) ([]byte, error) {
+			payload = req.Payload
+			return crypto.Sign(req.Payload, testKey)
+		},
+	}
+	service := newTestService(t, signer)
+
+	res, err := service.Transfer(context.Background(), &TransferRequest{
+		ID:    "req-sighash",
+		Chain: "ethereum-sepolia",
+		From:  testEthFrom,
+		To:    testEthTo,
+		Value: "1",
+	})
+	assert.NoError(t, err)
+
+	tx := new(types.Transaction)
+	assert.NoError(t, tx.UnmarshalBinary(res.RawTx))
+	chainID, err := chain.GetChainID(chain.EthereumSepolia)
+	require.NoError(t, err)
+	chainSigner := types.NewLondonSigner(chainID)
+
+	// The signer received the London sighash, and the TxID is the real transaction hash
+	assert.Equal(t, chainSigner.Hash(tx).Bytes(), payload)
+	assert.Equal(t, tx.Hash().Hex(), res.TxID)
+	sender, err := types.Sender(chainSigner, tx)
 	assert.NoError(t, err)
-	assert.Equal(t, "pending", res.Status)
+	assert.Equal(t, crypto.PubkeyToAddress(testKey.PublicKey), sender)
 }
 
+func TestService_Transfer_InvalidValue(t *testing.T) {
+	service := newTestService(t, &MockSigner{})
+
+	for _, value := range []string{"abc", "-1", "0", "1.0000001"} {
+		_, err := service.Transfer(context.Background(), &TransferRequest{
+			ID:    "req-bad-" + value,
+			Chain: "ethereum-sepolia",
+			From:  testEthFrom,
+			To:    testEthTo,
+			Asset: "USDC",
+			Value: value,
+		})
+		assert.Error(t, err, value)
+	}
+}
+
+func TestService_Transfer_Bitcoin(t *testing.T) {
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := wallet.NewSimulatedMPCSigner(seed)
+	utxoStore := store.NewInMemoryStore()
+	service := NewService(signer, utxoStore)
+
+	pubKey, err := signer.PublicKey(context.Background(), wallet.BitcoinTestnet)
+	assert.NoError(t, err)
+	from, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+	funding := chain.UTXO{TxID: testUTXOTxID, VOut: 0, Value: 2000000000}
+	assert.NoError(t, utxoStore.SaveUTXOs(context.Background(), from.EncodeAddress(), []chain.UTXO{funding}))
+
+	res, err := service.Transfer(context.Background(), &TransferRequest{
+		ID:    "btc-1",
+		Chain: "bitcoin-testnet",
+		From:  from.EncodeAddress(),
+		To:    "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm",
+		Value: "0.015",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	assert.NoError(t, msgTx.Deserialize(bytes.NewReader(res.RawTx)))
+	assert.Equal(t, msgTx.TxHash().String(), res.TxID)
+	assert.Equal(t, int64(1_500_000), msgTx.TxOut[0].Value)
+
+	// The witness must satisfy the spent P2WPKH script
+	pkScript, _ := txscript.PayToAddrScript(from)
+	fetcher := txscript.NewCannedPrevOutputFetcher(pkScript, 2000000000)
+	vm, err := txscript.NewEngine(pkScript, msgTx, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(msgTx, fetcher), 2000000000, fetcher)
+	assert.NoError(t, err)
+	assert.NoError(t, vm.Execute())
+
+	// The spent output is gone from the store
+	left, err := utxoStore.GetUTXOs(context.Background(), from.EncodeAddress())
+	assert.NoError(t, err)
+	assert.Empty(t, left)
+}
+
+func TestService_Transfer_Bitcoin_ConcurrentSpendsDisjointInputs(t *testing.T) {
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := wallet.NewSimulatedMPCSigner(seed)
+	utxoStore := store.NewInMemoryStore()
+	service := NewService(signer, utxoStore)
+
+	pubKey, _ := signer.PublicKey(context.Background(), wallet.BitcoinTestnet)
+	from, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+	const workers = 4
+	var utxos []chain.UTXO
+	for i := 0; i < workers; i++ {
+		utxos = append(utxos, chain.UTXO{TxID: testUTXOTxID, VOut: uint32(i), Value: 1_000_000})
+	}
+	assert.NoError(t, utxoStore.SaveUTXOs(context.Background(), from.EncodeAddress(), utxos))
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		spent   = map[wire.OutPoint]string{}
+		success int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := service.Transfer(context.Background(), &TransferRequest{
+				ID:    fmt.Sprintf("btc-concurrent-%d", i),
+				Chain: "bitcoin-testnet",
+				From:  from.EncodeAddress(),
+				To:    "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm",
+				Value: "0.005",
+			})
+			if err != nil {
+				return // lost every retry to other transfers
+			}
+			msgTx := wire.NewMsgTx(wire.TxVersion)
+			assert.NoError(t, msgTx.Deserialize(bytes.NewReader(res.RawTx)))
+
+			mu.Lock()
+			defer mu.Unlock()
+			success++
+			for _, in := range msgTx.TxIn {
+				if other, dup := spent[in.PreviousOutPoint]; dup {
+					t.Errorf("%v spent by %s and %s", in.PreviousOutPoint, other, res.TxID)
+				}
+				spent[in.PreviousOutPoint] = res.TxID
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.NotZero(t, success)
+}
+
+func TestService_Transfer_Bitcoin_ReleasesOnFailure(t *testing.T) {
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := &failingSigner{SimulatedMPCSigner: wallet.NewSimulatedMPCSigner(seed)}
+	utxoStore := store.NewInMemoryStore()
+	service := NewService(signer, utxoStore)
+
+	pubKey, _ := signer.PublicKey(context.Background(), wallet.BitcoinTestnet)
+	from, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+	assert.NoError(t, utxoStore.SaveUTXOs(context.Background(), from.EncodeAddress(), []chain.UTXO{
+		{TxID: testUTXOTxID, VOut: 0, Value: 2000000000},
+	}))
+
+	_, err := service.Transfer(context.Background(), &TransferRequest{
+		ID:    "btc-fail",
+		Chain: "bitcoin-testnet",
+		From:  from.EncodeAddress(),
+		To:    "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm",
+		Value: "0.015",
+	})
+	assert.Error(t, err)
+
+	spendable, err := utxoStore.GetSpendableUTXOs(context.Background(), from.EncodeAddress())
+	assert.NoError(t, err)
+	assert.Len(t, spendable, 1)
+}
+
+func TestService_Transfer_EthereumNode(t *testing.T) {
+	ctx := context.Background()
+	node := chain.NewFakeEVMNode(big.NewInt(11155111))
+	from := crypto.PubkeyToAddress(testKey.PublicKey).Hex()
+	node.SetBalance(from, big.NewInt(1e18))
+	transferStore := store.NewInMemoryStore()
+	service := NewService(&MockSigner{}, transferStore,
+		WithClient(chain.EthereumSepolia, node), WithPollInterval(10*time.Millisecond))
+
+	// A transaction sent from elsewhere moves the node's nonce ahead of ours
+	external := types.MustSignNewTx(testKey, types.LatestSignerForChainID(big.NewInt(11155111)), &types.DynamicFeeTx{
+		ChainID: big.NewInt(11155111), Nonce: 0, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1e10), Gas: 21000,
+	})
+	raw, _ := external.MarshalBinary()
+	_, err := node.Broadcast(ctx, raw)
+	require.NoError(t, err)
+
+	res, err := service.Transfer(ctx, &TransferRequest{
+		ID:    "eth-node-1",
+		Chain: "ethereum-sepolia",
+		From:  from,
+		To:    testEthTo,
+		Value: "0.5",
+	})
+	require.NoError(t, err)
+
+	tx := new(types.Transaction)
+	require.NoError(t, tx.UnmarshalBinary(res.RawTx))
+	assert.Equal(t, uint64(1), tx.Nonce())
+	// Fee cap is twice the node's base fee plus its tip
+	assert.Equal(t, big.NewInt(3_000_000_000), tx.GasFeeCap())
+	balance, _ := node.Balance(ctx, testEthTo)
+	assert.Equal(t, "500000000000000000", balance.String())
+
+	node.Mine(1)
+	waitForStatus(t, transferStore, "eth-node-1", StateConfirmed)
+}
+
+func TestService_Transfer_EthereumNodeRejects(t *testing.T) {
+	ctx := context.Background()
+	node := chain.NewFakeEVMNode(big.NewInt(11155111)) // sender has no balance
+	from := crypto.PubkeyToAddress(testKey.PublicKey).Hex()
+	service := NewService(&MockSigner{}, store.NewInMemoryStore(), WithClient(chain.EthereumSepolia, node))
+
+	_, err := service.Transfer(ctx, &TransferRequest{
+		ID:    "eth-node-rejected",
+		Chain: "ethereum-sepolia",
+		From:  from,
+		To:    testEthTo,
+		Value: "1",
+	})
+	assert.ErrorIs(t, err, chain.ErrTxRejected)
+
+	history, _ := service.TransferHistory(ctx, "eth-node-rejected")
+	assert.Equal(t, string(StateFailed), history[len(history)-1].To)
+	// The unused nonce is handed out again
+	assert.Equal(t, uint64(0), service.nonceManager.GetNext(nonceKey(chain.EthereumSepolia, from)))
+}
+
+func TestService_Transfer_BroadcastErrorRetries(t *testing.T) {
+	ctx := context.Background()
+	node := chain.NewFakeEVMNode(big.NewInt(11155111))
+	from := crypto.PubkeyToAddress(testKey.PublicKey).Hex()
+	node.SetBalance(from, big.NewInt(1e18))
+	client := &flakyEVMClient{EVMClient: node, flakySends: flakySends{failures: 1}}
+	transferStore := store.NewInMemoryStore()
+	service := NewService(&MockSigner{}, transferStore,
+		WithClient(chain.EthereumSepolia, client), WithPollInterval(10*time.Millisecond))
+	defer service.Close()
+
+	// The node timed out, so the transaction may be out: it stays signed
+	res, err := service.Transfer(ctx, &TransferRequest{
+		ID:    "eth-node-timeout",
+		Chain: "ethereum-sepolia",
+		From:  from,
+		To:    testEthTo,
+		Value: "0.5",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, string(StateSigned), res.Status)
+	// and keeps its nonce
+	assert.Equal(t, uint64(1), service.nonceManager.GetNext(nonceKey(chain.EthereumSepolia, from)))
+
+	// The monitor sends it again
+	waitForStatus(t, transferStore, "eth-node-timeout", StateInMempool)
+	node.Mine(1)
+	waitForStatus(t, transferStore, "eth-node-timeout", StateConfirmed)
+}
+
+func TestService_Transfer_Bitcoin_BroadcastErrorKeepsInputs(t *testing.T) {
+	ctx := context.Background()
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := wallet.NewSimulatedMPCSigner(seed)
+	pubKey, _ := signer.PublicKey(ctx, wallet.BitcoinTestnet)
+	from, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+
+	node := chain.NewFakeBitcoinNode(&chaincfg.TestNet3Params)
+	_, err := node.Fund(from.EncodeAddress(), 3_000_000)
+	require.NoError(t, err)
+	// Every send times out, so the transfer waits for the monitor
+	client := &flakyBitcoinClient{BitcoinClient: node, flakySends: flakySends{failures: -1}}
+	transferStore := store.NewInMemoryStore()
+	service := NewService(signer, transferStore, WithClient(chain.BitcoinTestnet, client))
+	service.utxoLease = time.Millisecond
+	defer service.Close()
+
+	res, err := service.Transfer(ctx, &TransferRequest{
+		ID:    "btc-node-timeout",
+		Chain: "bitcoin-testnet",
+		From:  from.EncodeAddress(),
+		To:    "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm",
+		Value: "0.02",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, string(StateSigned), res.Status)
+
+	// Its only input stays spent, past the reservation's lease, rather than
+	// going to another transfer while the monitor retries
+	time.Sleep(10 * time.Millisecond)
+	spendable, err := transferStore.GetSpendableUTXOs(ctx, from.EncodeAddress())
+	require.NoError(t, err)
+	assert.Empty(t, spendable)
+	_, err = service.Transfer(ctx, &TransferRequest{
+		ID:    "btc-node-conflict",
+		Chain: "bitcoin-testnet",
+		From:  from.EncodeAddress(),
+		To:    "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm",
+		Value: "0.02",
+	})
+	assert.ErrorIs(t, err, chain.ErrInsufficientFunds)
+}
+
+func TestService_Transfer_BitcoinNode(t *testing.T) {
+	ctx := context.Background()
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := wallet.NewSimulatedMPCSigner(seed)
+	pubKey, _ := signer.PublicKey(ctx, wallet.BitcoinTestnet)
+	from, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+
+	node := chain.NewFakeBitcoinNode(&chaincfg.TestNet3Params)
+	node.FeeRate = 5
+	funded := map[string]int64{}
+	for _, v := range []int64{1_000_000, 3_000_000} {
+		u, err := node.Fund(from.EncodeAddress(), v)
+		require.NoError(t, err)
+		funded[u.TxID] = u.Value
+	}
+
+	transferStore := store.NewInMemoryStore()
+	service := NewService(signer, transferStore,
+		WithClient(chain.BitcoinTestnet, node), WithPollInterval(10*time.Millisecond))
+
+	res, err := service.Transfer(ctx, &TransferRequest{
+		ID:    "btc-node-1",
+		Chain: "bitcoin-testnet",
+		From:  from.EncodeAddress(),
+		To:    "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm",
+		Value: "0.02",
+	})
+	require.NoError(t, err)
+
+	// The node accepted the transaction, which means every witness verified
+	status, err := node.TxStatus(ctx, res.TxID)
+	require.NoError(t, err)
+	assert.Zero(t, status.Confirmations)
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	require.NoError(t, msgTx.Deserialize(bytes.NewReader(res.RawTx)))
+	var out int64
+	for _, o := range msgTx.TxOut {
+		out += o.Value
+	}
+	// The fee follows the node's 5 sat/vB estimate rather than the 10 sat/vB default
+	var in int64
+	for _, txIn := range msgTx.TxIn {
+		in += funded[txIn.PreviousOutPoint.Hash.String()]
+	}
+	vsize := int64((msgTx.SerializeSizeStripped()*3 + msgTx.SerializeSize() + 3) / 4)
+	fee := in - out
+	assert.GreaterOrEqual(t, fee, 5*vsize)
+	assert.Less(t, fee, 10*vsize)
+
+	node.Mine(1)
+	waitForStatus(t, transferStore, "btc-node-1", StateConfirmed)
+}
+
+// waitForStatus polls the store until a transfer reaches want.
+func waitForStatus(t *testing.T, s store.Store, id string, want TransferState) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		res, err := s.GetTransferResult(context.Background(), id)
+		require.NoError(t, err)
+		if TransferState(res.Status) == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("transfer %s is %s, want %s", id, res.Status, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// failingSigner exposes a real public key but refuses to sign.
+type failingSigner struct {
+	*wallet.SimulatedMPCSigner
+}
+
+func (f *failingSigner) Sign(ctx context.Context, req wallet.SignRequest) ([]byte, error) {
+	return nil, errors.New("signer offline")
+}
+
+// flakySends fails sends without a rejection, the way a timeout does, the
+// first failures times (every time when negative).
+type flakySends struct {
+	mu       sync.Mutex
+	failures int
+}
+
+func (f *flakySends) send(ctx context.Context, rawTx []byte, next func(context.Context, []byte) (string, error)) (string, error) {
+	f.mu.Lock()
+	fail := f.failures != 0
+	if f.failures > 0 {
+		f.failures--
+	}
+	f.mu.Unlock()
+	if fail {
+		return "", context.DeadlineExceeded
+	}
+	return next(ctx, rawTx)
+}
+
+// flakyEVMClient is an EVM node whose sends fail first.
+type flakyEVMClient struct {
+	chain.EVMClient
+	flakySends
+}
+
+func (c *flakyEVMClient) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	return c.send(ctx, rawTx, c.EVMClient.Broadcast)
+}
+
+// flakyBitcoinClient is a Bitcoin node whose sends fail first.
+type flakyBitcoinClient struct {
+	chain.BitcoinClient
+	flakySends
+}
+
+func (c *flakyBitcoinClient) Broadcast(ctx context.Context, rawTx []byte) (string, error) {
+	return c.send(ctx, rawTx, c.BitcoinClient.Broadcast)
+}
+
+// testUTXOTxID is an arbitrary funding transaction for Bitcoin tests.
+const testUTXOTxID = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
 func newTestService(t *testing.T, signer wallet.Signer) *Service {
 	return NewService(signer, store.NewInMemoryStore())
 }
+
+func TestService_Transfer_SolanaNode(t *testing.T) {
+	ctx := context.Background()
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := wallet.NewSimulatedMPCSigner(seed)
+	from, err := wallet.DeriveSolanaAddress(seed)
+	require.NoError(t, err)
+
+	node := chain.NewFakeSolanaNode()
+	transferStore := store.NewInMemoryStore()
+	service := NewService(signer, transferStore,
+		WithClient(chain.SolanaDevnet, node), WithPollInterval(10*time.Millisecond))
+	defer service.Close()
+
+	res, err := service.Transfer(ctx, &TransferRequest{
+		ID:    "sol-node-1",
+		Chain: "solana-devnet",
+		From:  from,
+		To:    "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+		Value: "0.5",
+	})
+	require.NoError(t, err)
+
+	// One signature by the sender over a message anchored to the node's blockhash
+	require.Equal(t, byte(1), res.RawTx[0])
+	sig, message := res.RawTx[1:65], res.RawTx[65:]
+	sender, _ := chain.ParseSolanaPublicKey(from)
+	assert.Equal(t, sender[:], message[4:36], "fee payer is the first account")
+	assert.True(t, ed25519.Verify(sender[:], message, sig))
+	blockhash, _ := chain.ParseSolanaPublicKey(node.Blockhash)
+	assert.True(t, bytes.Contains(message, blockhash[:]))
+
+	node.Mine(1)
+	waitForStatus(t, transferStore, "sol-node-1", StateConfirmed)
+}
+
+func TestService_Transfer_SolanaToken(t *testing.T) {
+	ctx := context.Background()
+	seed := bip39.NewSeed("slab lonely fish push bomb festival open oval empower federal slot hotel", "")
+	signer := wallet.NewSimulatedMPCSigner(seed)
+	from, _ := wallet.DeriveSolanaAddress(seed)
+	to := "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+
+	node := chain.NewFakeSolanaNode()
+	service := NewService(signer, store.NewInMemoryStore(),
+		WithClient(chain.SolanaDevnet, node), WithPollInterval(10*time.Millisecond))
+	defer service.Close()
+
+	transfer := func(id string) []byte {
+		res, err := service.Transfer(ctx, &TransferRequest{ID: id, Chain: "solana-devnet", Asset: "USDC", From: from, To: to, Value: "1.5"})
+		require.NoError(t, err)
+		return res.RawTx[65:]
+	}
+
+	// The recipient has no USDC account yet, so the transfer creates it
+	message := transfer("spl-1")
+	assert.True(t, bytes.Contains(message, chain.SolanaAssociatedTokenProgramID[:]))
+	assert.True(t, bytes.Contains(message, []byte{12, 0x60, 0xe3, 0x16, 0, 0, 0, 0, 0, 6}), "TransferChecked 1.5 USDC")
+
+	recipient, _ := chain.ParseSolanaPublicKey(to)
+	account, _ := chain.SolanaAssociatedTokenAddress(recipient, chain.SolanaPublicKey(tokens.USDC_SolanaDevnet.Mint))
+	node.CreateAccount(account.String())
+	message = transfer("spl-2")
+	assert.False(t, bytes.Contains(message, chain.SolanaAssociatedTokenProgramID[:]))
+}
+
+func TestService_Transfer_RegisteredEVMChain(t *testing.T) {
+	const devnet = "test-evm-devnet"
+	require.NoError(t, networks.Register(networks.Network{
+		Name:           devnet,
+		ChainID:        1337,
+		FeeStrategy:    networks.FeeEIP1559,
+		GasPrice:       big.NewInt(1_000_000_000),
+		FinalityDepth:  3,
+		NativeCurrency: networks.Currency{Name: "Dev Ether", Symbol: "DEV", Decimals: 18},
+	}))
+	service := newTestService(t, &MockSigner{})
+	ctx := context.Background()
+
+	send := func(id string, chainName chains.Chain) *types.Transaction {
+		res, err := service.Transfer(ctx, &TransferRequest{ID: id, Chain: chainName, From: testEthFrom, To: testEthTo, Value: "0.5"})
+		require.NoError(t, err)
+		tx := new(types.Transaction)
+		require.NoError(t, tx.UnmarshalBinary(res.RawTx))
+		return tx
+	}
+
+	// The same address keeps a separate nonce sequence per chain
+	assert.Equal(t, uint64(0), send("sep-1", "ethereum-sepolia").Nonce())
+	assert.Equal(t, uint64(1), send("sep-2", "ethereum-sepolia").Nonce())
+	tx := send("dev-1", devnet)
+	assert.Equal(t, uint64(0), tx.Nonce())
+	assert.Equal(t, big.NewInt(1337), tx.ChainId())
+	assert.Equal(t, "500000000000000000", tx.Value().String())
+
+	assert.Equal(t, uint64(3), service.finalityDepth(devnet))
+	assert.Equal(t, uint64(1), service.finalityDepth(chain.AvalancheFuji))
+}
@@ -0,0 +1,65 @@
+// state.go
+package custody
+
+import "errors"
+
+// TransferState is a step in a transfer's lifecycle. It is persisted as
+// store.TransferResult.Status.
+type TransferState string
+
+const (
+	StateRequested        TransferState = "requested"
+	StatePolicyChecked    TransferState = "policy_checked"
+	StateAwaitingApproval TransferState = "awaiting_approval"
+	StateBuilt            TransferState = "built"
+	StateSigned           TransferState = "signed"
+	StateBroadcast        TransferState = "broadcast"
+	StateInMempool        TransferState = "in_mempool"
+	StateConfirmed        TransferState = "confirmed"
+	StateFinalized        TransferState = "finalized"
+	StateFailed           TransferState = "failed"
+	StateReplaced         TransferState = "replaced"
+	StateCancelled        TransferState = "cancelled"
+)
+
+// ErrInvalidTransition is returned when a transfer is moved to a state its
+// current state does not lead to.
+var ErrInvalidTransition = errors.New("invalid transfer state transition")
+
+// transitions lists the states reachable from each state. Terminal states have none.
+var transitions = map[TransferState][]TransferState{
+	StateRequested:        {StatePolicyChecked, StateFailed, StateCancelled},
+	StatePolicyChecked:    {StateAwaitingApproval, StateBuilt, StateFailed, StateCancelled},
+	StateAwaitingApproval: {StateBuilt, StateFailed, StateCancelled},
+	StateBuilt:            {StateSigned, StateFailed, StateCancelled},
+	StateSigned:           {StateBroadcast, StateFailed, StateCancelled},
+	StateBroadcast:        {StateInMempool, StateConfirmed, StateFailed, StateReplaced},
+	StateInMempool:        {StateConfirmed, StateFailed, StateReplaced},
+	// A reorg can drop a confirmed transaction back into the mempool, or
+	// confirm a conflicting one instead
+	StateConfirmed: {StateFinalized, StateInMempool, StateFailed},
+}
+
+// CanTransitionTo reports whether a transfer in s may move to next.
+func (s TransferState) CanTransitionTo(next TransferState) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether no further transitions are possible from s.
+func (s TransferState) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// HasTx reports whether a transfer in s already has a signed transaction.
+func (s TransferState) HasTx() bool {
+	switch s {
+	case StateSigned, StateBroadcast, StateInMempool, StateConfirmed, StateFinalized, StateReplaced:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+// state_test.go
+package custody
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferState_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to TransferState
+		want     bool
+	}{
+		{StateRequested, StatePolicyChecked, true},
+		{StatePolicyChecked, StateAwaitingApproval, true},
+		{StateAwaitingApproval, StateBuilt, true},
+		{StateBuilt, StateSigned, true},
+		{StateSigned, StateBroadcast, true},
+		{StateBroadcast, StateInMempool, true},
+		{StateInMempool, StateReplaced, true},
+		{StateConfirmed, StateFinalized, true},
+		{StateConfirmed, StateInMempool, true}, // reorg
+		{StateConfirmed, StateFailed, true},    // conflicting tx confirmed
+		{StateBuilt, StateFailed, true},
+
+		{StateRequested, StateSigned, false}, // skips building
+		{StateBroadcast, StateCancelled, false},
+		{StateFinalized, StateConfirmed, false},
+		{StateFailed, StateRequested, false},
+		{StateCancelled, StateBuilt, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.from.CanTransitionTo(tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
+
+func TestTransferState_IsTerminal(t *testing.T) {
+	for _, s := range []TransferState{StateFinalized, StateFailed, StateReplaced, StateCancelled} {
+		assert.True(t, s.IsTerminal(), s)
+	}
+	for _, s := range []TransferState{StateRequested, StateBuilt, StateBroadcast, StateConfirmed} {
+		assert.False(t, s.IsTerminal(), s)
+	}
+}
@@ -1,37 +1,342 @@
 // utxo_selector.go
 package custody
 
-import "andi-custodian/internal/chain"
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
 
-// UTXOSelector selects UTXOs to fund a transaction.
+	"andi-custodian/internal/chain"
+)
+
+// UTXOSelector selects UTXOs to fund a transaction at a given fee rate.
+// Implementations are passed to the builder through chain.BuildOptions.Selector.
 type UTXOSelector interface {
-	Select(utxos []chain.UTXO, target int64) ([]chain.UTXO, int64, error)
+	Select(utxos []chain.UTXO, params chain.SelectionParams) (*chain.Selection, error)
 }
 
+// ErrNoSolution is returned when a selector finds no input set it is willing to use,
+// even though the wallet may hold enough funds for another algorithm.
+var ErrNoSolution = errors.New("coin selection: no solution")
+
 // GreedySelector implements a simple largest-first selection.
-type GreedySelector struct{}
-
-func (gs *GreedySelector) Select(utxos []chain.UTXO, target int64) ([]chain.UTXO, int64, error) {
-	// Sort descending by value
-	for i := 0; i < len(utxos); i++ {
-		for j := i + 1; j < len(utxos); j++ {
-			if utxos[i].Value < utxos[j].Value {
-				utxos[i], utxos[j] = utxos[j], utxos[i]
+type GreedySelector = chain.LargestFirstSelector
+
+// bnbMaxTries bounds the Branch-and-Bound search, as in Bitcoin Core.
+const bnbMaxTries = 100_000
+
+// knapsackIterations is the number of stochastic passes of the knapsack solver.
+const knapsackIterations = 1000
+
+// Waste scores a selection the way Bitcoin Core does: the cost of spending the inputs
+// now rather than at the long-term fee rate, plus either the cost of creating and later
+// spending change, or the excess given up to the fee when there is no change.
+// Lower is better.
+func Waste(sel *chain.Selection, p chain.SelectionParams) int64 {
+	n := int64(len(sel.Inputs))
+	waste := n * p.InputVSize * (p.FeeRate - p.LongTerm())
+	if sel.Change > 0 {
+		return waste + p.CostOfChange()
+	}
+	feeNoChange := (p.BaseVSize + n*p.InputVSize) * p.FeeRate
+	return waste + sel.Fee - feeNoChange
+}
+
+// WasteSelector runs several selection algorithms and keeps the solution with the
+// lowest waste, mirroring Bitcoin Core's SelectCoins.
+type WasteSelector struct {
+	Candidates []UTXOSelector
+}
+
+// NewWasteSelector returns a selector trying Branch-and-Bound, knapsack,
+// single-random-draw and largest-first.
+func NewWasteSelector() *WasteSelector {
+	return &WasteSelector{
+		Candidates: []UTXOSelector{
+			&BranchAndBoundSelector{},
+			&KnapsackSelector{},
+			&SingleRandomDrawSelector{},
+			&GreedySelector{},
+		},
+	}
+}
+
+func (ws *WasteSelector) Select(utxos []chain.UTXO, p chain.SelectionParams) (*chain.Selection, error) {
+	var (
+		best      *chain.Selection
+		bestWaste int64 = math.MaxInt64
+		lastErr   error = chain.ErrInsufficientFunds
+	)
+	for _, candidate := range ws.Candidates {
+		sel, err := candidate.Select(utxos, p)
+		if err != nil {
+			if !errors.Is(err, ErrNoSolution) {
+				lastErr = err
 			}
+			continue
+		}
+		if w := Waste(sel, p); w < bestWaste {
+			best, bestWaste = sel, w
+		}
+	}
+	if best == nil {
+		return nil, lastErr
+	}
+	return best, nil
+}
+
+// BranchAndBoundSelector searches for an input set whose effective value lands between
+// the target and the target plus the cost of change, so no change output is needed.
+// It returns ErrNoSolution when no changeless match exists.
+type BranchAndBoundSelector struct{}
+
+func (bb *BranchAndBoundSelector) Select(utxos []chain.UTXO, p chain.SelectionParams) (*chain.Selection, error) {
+	if p.SubtractFee {
+		return nil, ErrNoSolution // the amount is flexible, so there is no exact target
+	}
+
+	pool := positiveEffective(utxos, p)
+	sort.SliceStable(pool, func(i, j int) bool { return pool[i].effective > pool[j].effective })
+
+	target := p.Target + p.BaseVSize*p.FeeRate
+	upper := target + p.CostOfChange()
+	inputWaste := p.InputVSize * (p.FeeRate - p.LongTerm())
+
+	var remaining int64
+	for _, c := range pool {
+		remaining += c.effective
+	}
+	if remaining < target {
+		return nil, chain.ErrInsufficientFunds
+	}
+
+	var (
+		current   []chain.UTXO
+		best      []chain.UTXO
+		bestWaste int64 = math.MaxInt64
+		tries     int
+	)
+	var search func(i int, value, waste, remaining int64)
+	search = func(i int, value, waste, remaining int64) {
+		tries++
+		if tries > bnbMaxTries || value > upper || value+remaining < target {
+			return
+		}
+		// With fees above the long-term rate every extra input only adds waste
+		if inputWaste > 0 && waste > bestWaste {
+			return
+		}
+		if value >= target {
+			if w := waste + value - target; w <= bestWaste {
+				best = append(best[:0], current...)
+				bestWaste = w
+			}
+			return
+		}
+		if i == len(pool) {
+			return
+		}
+		c := pool[i]
+		current = append(current, c.utxo)
+		search(i+1, value+c.effective, waste+inputWaste, remaining-c.effective)
+		current = current[:len(current)-1]
+
+		// Omitting a UTXO equal to one just omitted explores the same sums again
+		next := i + 1
+		for next < len(pool) && pool[next].effective == c.effective {
+			remaining -= pool[next].effective
+			next++
+		}
+		search(next, value, waste, remaining-c.effective)
+	}
+	search(0, 0, 0, remaining)
+
+	if best == nil {
+		return nil, ErrNoSolution
+	}
+	changeless := p
+	changeless.NoChange = true
+	return chain.NewSelection(best, changeless)
+}
+
+// SingleRandomDrawSelector adds UTXOs in random order until the target, the fee and a
+// change output are covered. It spreads spends across the wallet and avoids always
+// consuming the largest outputs.
+type SingleRandomDrawSelector struct {
+	Rand *rand.Rand // nil uses the global source
+}
+
+func (sr *SingleRandomDrawSelector) Select(utxos []chain.UTXO, p chain.SelectionParams) (*chain.Selection, error) {
+	pool := positiveEffective(utxos, p)
+	shuffle(sr.Rand, len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+
+	target := effectiveTarget(p) + p.ChangeVSize*p.FeeRate + chain.DustThreshold
+	var (
+		selected []chain.UTXO
+		value    int64
+	)
+	for _, c := range pool {
+		selected = append(selected, c.utxo)
+		value += c.effective
+		if value >= target {
+			return chain.NewSelection(selected, p)
 		}
 	}
+	// Not enough for change: fall back to a changeless spend of everything drawn
+	if value >= effectiveTarget(p) {
+		return chain.NewSelection(selected, p)
+	}
+	return nil, chain.ErrInsufficientFunds
+}
+
+// KnapsackSelector approximates the subset whose effective value is closest to (but not
+// below) the target, using Bitcoin Core's stochastic approximation. A single UTXO that
+// exactly matches, or the smallest single UTXO larger than the target, is preferred
+// when it does at least as well.
+type KnapsackSelector struct {
+	Rand *rand.Rand // nil uses the global source
+}
+
+func (ks *KnapsackSelector) Select(utxos []chain.UTXO, p chain.SelectionParams) (*chain.Selection, error) {
+	target := effectiveTarget(p)
 
+	var (
+		smaller      []candidate
+		smallerTotal int64
+		lowestLarger *candidate
+	)
+	for _, c := range positiveEffective(utxos, p) {
+		c := c
+		switch {
+		case c.effective == target:
+			return chain.NewSelection([]chain.UTXO{c.utxo}, p)
+		case c.effective < target:
+			smaller = append(smaller, c)
+			smallerTotal += c.effective
+		case lowestLarger == nil || c.effective < lowestLarger.effective:
+			lowestLarger = &c
+		}
+	}
+
+	if smallerTotal == target {
+		return chain.NewSelection(utxosOf(smaller), p)
+	}
+	if smallerTotal < target {
+		if lowestLarger == nil {
+			return nil, chain.ErrInsufficientFunds
+		}
+		return chain.NewSelection([]chain.UTXO{lowestLarger.utxo}, p)
+	}
+
+	sort.SliceStable(smaller, func(i, j int) bool { return smaller[i].effective > smaller[j].effective })
+	included, bestTotal := ks.approximateBestSubset(smaller, smallerTotal, target)
+
+	if lowestLarger != nil && (bestTotal != target && lowestLarger.effective <= bestTotal) {
+		return chain.NewSelection([]chain.UTXO{lowestLarger.utxo}, p)
+	}
 	var selected []chain.UTXO
-	var total int64
+	for i, in := range included {
+		if in {
+			selected = append(selected, smaller[i].utxo)
+		}
+	}
+	return chain.NewSelection(selected, p)
+}
+
+// approximateBestSubset is Bitcoin Core's ApproximateBestSubset: repeated randomized
+// two-pass inclusion, keeping the smallest total that reaches the target.
+func (ks *KnapsackSelector) approximateBestSubset(pool []candidate, total, target int64) ([]bool, int64) {
+	best := make([]bool, len(pool))
+	for i := range best {
+		best[i] = true
+	}
+	bestTotal := total
+
+	included := make([]bool, len(pool))
+	for rep := 0; rep < knapsackIterations && bestTotal != target; rep++ {
+		for i := range included {
+			included[i] = false
+		}
+		var sum int64
+		reached := false
+		for pass := 0; pass < 2 && !reached; pass++ {
+			for i, c := range pool {
+				// First pass: random inclusion. Second pass: include what is left.
+				take := false
+				if pass == 0 {
+					take = intn(ks.Rand, 2) == 1
+				} else {
+					take = !included[i]
+				}
+				if !take {
+					continue
+				}
+				sum += c.effective
+				included[i] = true
+				if sum >= target {
+					reached = true
+					if sum < bestTotal {
+						bestTotal = sum
+						copy(best, included)
+					}
+					sum -= c.effective
+					included[i] = false
+				}
+			}
+		}
+	}
+	return best, bestTotal
+}
+
+// candidate is a UTXO with its effective value: what it contributes after paying for
+// its own input. When the fee is subtracted from the amount, the full value counts.
+type candidate struct {
+	utxo      chain.UTXO
+	effective int64
+}
+
+func positiveEffective(utxos []chain.UTXO, p chain.SelectionParams) []candidate {
+	pool := make([]candidate, 0, len(utxos))
 	for _, u := range utxos {
-		if total >= target {
-			break
+		eff := u.Value
+		if !p.SubtractFee {
+			eff -= p.InputFee()
 		}
-		selected = append(selected, u)
-		total += u.Value
+		if eff > 0 {
+			pool = append(pool, candidate{utxo: u, effective: eff})
+		}
+	}
+	return pool
+}
+
+// effectiveTarget is the effective value the inputs must sum to.
+func effectiveTarget(p chain.SelectionParams) int64 {
+	if p.SubtractFee {
+		return p.Target
+	}
+	return p.Target + p.BaseVSize*p.FeeRate
+}
+
+func utxosOf(cs []candidate) []chain.UTXO {
+	out := make([]chain.UTXO, len(cs))
+	for i, c := range cs {
+		out[i] = c.utxo
 	}
-	if total < target {
-		return nil, 0, chain.ErrInsufficientFunds
+	return out
+}
+
+func shuffle(r *rand.Rand, n int, swap func(i, j int)) {
+	if r != nil {
+		r.Shuffle(n, swap)
+		return
+	}
+	rand.Shuffle(n, swap)
+}
+
+func intn(r *rand.Rand, n int) int {
+	if r != nil {
+		return r.Intn(n)
 	}
-	return selected, total - target, nil // change = total - target
+	return rand.Intn(n)
 }
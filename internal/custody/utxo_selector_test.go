@@ -2,11 +2,20 @@
 package custody
 
 import (
+	"math/rand"
 	"testing"
 
 	"andi-custodian/internal/chain"
 )
 
+// testParams describes a P2WPKH spend at 10 sat/vbyte.
+var testParams = chain.SelectionParams{
+	FeeRate:     10,
+	BaseVSize:   42,
+	InputVSize:  68,
+	ChangeVSize: 31,
+}
+
 func TestGreedySelector_Select_Success(t *testing.T) {
 	selector := &GreedySelector{}
 	utxos := []chain.UTXO{
@@ -14,18 +23,44 @@ func TestGreedySelector_Select_Success(t *testing.T) {
 		{Value: 500_000_000}, // 5 BTC
 		{Value: 100_000_000}, // 1 BTC
 	}
-	target := int64(1_050_000_000) // 10.5 BTC
+	params := testParams
+	params.Target = 1_050_000_000 // 10.5 BTC
 
-	selected, change, err := selector.Select(utxos, target)
+	sel, err := selector.Select(utxos, params)
 	if err != nil {
 		t.Fatalf("Select failed: %v", err)
 	}
 
-	if len(selected) != 2 {
-		t.Errorf("Expected 2 UTXOs, got %d", len(selected))
+	if len(sel.Inputs) != 2 {
+		t.Errorf("Expected 2 UTXOs, got %d", len(sel.Inputs))
+	}
+	wantFee := int64(42+2*68+31) * 10
+	if sel.Fee != wantFee {
+		t.Errorf("Expected fee=%d, got %d", wantFee, sel.Fee)
 	}
-	if change != 50_000_000 {
-		t.Errorf("Expected change=50_000_000, got %d", change)
+	if sel.Change != 50_000_000-wantFee {
+		t.Errorf("Expected change=%d, got %d", 50_000_000-wantFee, sel.Change)
+	}
+}
+
+func TestGreedySelector_Select_FeeNeedsExtraInput(t *testing.T) {
+	selector := &GreedySelector{}
+	utxos := []chain.UTXO{
+		{Value: 100_000},
+		{Value: 50_000},
+	}
+	params := testParams
+	params.Target = 100_000 // the largest UTXO alone cannot also pay the fee
+
+	sel, err := selector.Select(utxos, params)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(sel.Inputs) != 2 {
+		t.Errorf("Expected 2 UTXOs, got %d", len(sel.Inputs))
+	}
+	if sel.Total() != sel.Amount+sel.Fee+sel.Change {
+		t.Error("Inputs must balance outputs plus fee")
 	}
 }
 
@@ -34,8 +69,155 @@ func TestGreedySelector_Select_InsufficientFunds(t *testing.T) {
 	utxos := []chain.UTXO{
 		{Value: 100_000_000},
 	}
-	_, _, err := selector.Select(utxos, 200_000_000)
+	params := testParams
+	params.Target = 200_000_000
+	_, err := selector.Select(utxos, params)
 	if err != chain.ErrInsufficientFunds {
 		t.Errorf("Expected ErrInsufficientFunds, got %v", err)
 	}
 }
+
+func TestBranchAndBoundSelector_ExactMatch(t *testing.T) {
+	params := testParams
+	inputFee := params.InputVSize * params.FeeRate
+	baseFee := params.BaseVSize * params.FeeRate
+	params.Target = 300_000
+
+	// 100k + 200k effective values hit the target exactly once fees are included
+	utxos := []chain.UTXO{
+		{TxID: "a", Value: 500_000},
+		{TxID: "b", Value: 100_000 + inputFee + baseFee},
+		{TxID: "c", Value: 200_000 + inputFee},
+		{TxID: "d", Value: 50_000},
+	}
+	sel, err := (&BranchAndBoundSelector{}).Select(utxos, params)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if sel.Change != 0 {
+		t.Errorf("BnB should be changeless, got change %d", sel.Change)
+	}
+	if len(sel.Inputs) != 2 {
+		t.Errorf("Expected 2 inputs, got %d", len(sel.Inputs))
+	}
+	if sel.Total() != sel.Amount+sel.Fee {
+		t.Error("Inputs must balance outputs plus fee")
+	}
+	if w := Waste(sel, params); w != 0 {
+		t.Errorf("Exact match at the long-term fee rate should have zero waste, got %d", w)
+	}
+}
+
+func TestBranchAndBoundSelector_NoSolution(t *testing.T) {
+	params := testParams
+	params.Target = 300_000
+	utxos := []chain.UTXO{{Value: 1_000_000}, {Value: 2_000_000}}
+
+	_, err := (&BranchAndBoundSelector{}).Select(utxos, params)
+	if err != ErrNoSolution {
+		t.Errorf("Expected ErrNoSolution, got %v", err)
+	}
+}
+
+func TestKnapsackSelector_ClosestSubset(t *testing.T) {
+	params := testParams
+	params.FeeRate = 0
+	params.Target = 70_000
+	utxos := []chain.UTXO{
+		{TxID: "a", Value: 40_000},
+		{TxID: "b", Value: 30_000},
+		{TxID: "c", Value: 20_000},
+		{TxID: "d", Value: 1_000_000},
+	}
+	ks := &KnapsackSelector{Rand: rand.New(rand.NewSource(1))}
+	sel, err := ks.Select(utxos, params)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if sel.Total() != 70_000 {
+		t.Errorf("Expected exact 70k subset, got %d", sel.Total())
+	}
+}
+
+func TestKnapsackSelector_LowestLarger(t *testing.T) {
+	params := testParams
+	params.FeeRate = 0
+	params.Target = 100_000
+	utxos := []chain.UTXO{
+		{Value: 10_000},
+		{Value: 20_000},
+		{Value: 150_000},
+		{Value: 900_000},
+	}
+	sel, err := (&KnapsackSelector{Rand: rand.New(rand.NewSource(1))}).Select(utxos, params)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(sel.Inputs) != 1 || sel.Inputs[0].Value != 150_000 {
+		t.Errorf("Expected the smallest UTXO above the target, got %+v", sel.Inputs)
+	}
+}
+
+func TestSingleRandomDrawSelector_Select(t *testing.T) {
+	params := testParams
+	params.Target = 100_000
+	utxos := []chain.UTXO{
+		{Value: 80_000}, {Value: 70_000}, {Value: 60_000}, {Value: 50_000},
+	}
+	sr := &SingleRandomDrawSelector{Rand: rand.New(rand.NewSource(7))}
+	sel, err := sr.Select(utxos, params)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if sel.Change <= 0 {
+		t.Errorf("SRD should leave change, got %d", sel.Change)
+	}
+	if sel.Total() != sel.Amount+sel.Fee+sel.Change {
+		t.Error("Inputs must balance outputs plus fee")
+	}
+
+	params.Target = 1_000_000
+	if _, err := sr.Select(utxos, params); err != chain.ErrInsufficientFunds {
+		t.Errorf("Expected ErrInsufficientFunds, got %v", err)
+	}
+}
+
+func TestWasteSelector_PrefersChangeless(t *testing.T) {
+	params := testParams
+	inputFee := params.InputVSize * params.FeeRate
+	baseFee := params.BaseVSize * params.FeeRate
+	params.Target = 250_000
+
+	exact := chain.UTXO{TxID: "exact", Value: 250_000 + inputFee + baseFee}
+	utxos := []chain.UTXO{
+		{TxID: "big", Value: 5_000_000},
+		exact,
+		{TxID: "small", Value: 120_000},
+	}
+	sel, err := NewWasteSelector().Select(utxos, params)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(sel.Inputs) != 1 || sel.Inputs[0].TxID != "exact" || sel.Change != 0 {
+		t.Errorf("Expected the changeless exact match, got %+v change=%d", sel.Inputs, sel.Change)
+	}
+}
+
+func TestWaste(t *testing.T) {
+	params := testParams
+	params.FeeRate = 20
+	params.LongTermFeeRate = 10
+	params.Target = 100_000
+
+	withChange, _ := chain.NewSelection([]chain.UTXO{{Value: 200_000}}, params)
+	want := params.InputVSize*(20-10) + params.CostOfChange()
+	if w := Waste(withChange, params); w != want {
+		t.Errorf("Waste with change = %d, want %d", w, want)
+	}
+
+	// Spending two inputs at a high fee rate is more wasteful than one
+	twoInputs, _ := chain.NewSelection([]chain.UTXO{{Value: 100_000}, {Value: 100_000}}, params)
+	if diff := Waste(twoInputs, params) - Waste(withChange, params); diff != params.InputVSize*(20-10) {
+		t.Errorf("Extra input should add %d waste, added %d", params.InputVSize*10, diff)
+	}
+}
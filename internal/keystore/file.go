@@ -0,0 +1,255 @@
+// keystore/file.go
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Version is the keystore format this package writes.
+const Version = 1
+
+// CipherXChaCha20Poly1305 is the AEAD seeds are sealed with.
+const CipherXChaCha20Poly1305 = "xchacha20-poly1305"
+
+// Key derivation functions.
+const (
+	KDFScrypt   = "scrypt"
+	KDFArgon2id = "argon2id"
+)
+
+var (
+	// ErrWrongPassword is returned when a keystore does not open with a password.
+	ErrWrongPassword = errors.New("wrong keystore password")
+	// ErrUnsupported is returned for keystore versions, ciphers and KDFs this package cannot read.
+	ErrUnsupported = errors.New("unsupported keystore")
+	// ErrCorrupt is returned for keystores whose contents do not match their header.
+	ErrCorrupt = errors.New("corrupt keystore")
+)
+
+// saltSize is the length of generated KDF salts.
+const saltSize = 32
+
+// KDFParams selects the password hash and its cost. Fields that do not apply
+// to the KDF are zero.
+type KDFParams struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+	// Argon2id
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"` // KiB
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+// Scrypt returns scrypt parameters of N=2^18, r=8, p=1: 256 MiB and about a
+// second per unlock, affordable for a key unlocked at startup.
+func Scrypt() KDFParams {
+	return KDFParams{Name: KDFScrypt, N: 1 << 18, R: 8, P: 1}
+}
+
+// Argon2id returns the second recommended option of RFC 9106: 3 passes over
+// 64 MiB.
+func Argon2id() KDFParams {
+	return KDFParams{Name: KDFArgon2id, Time: 3, Memory: 64 * 1024, Threads: 4}
+}
+
+// validate bounds the cost parameters, so a tampered file cannot make
+// unlocking exhaust memory, and rejects weak ones.
+func (p KDFParams) validate() error {
+	if len(p.Salt) < 16 {
+		return fmt.Errorf("%w: salt of %d bytes", ErrCorrupt, len(p.Salt))
+	}
+	switch p.Name {
+	case KDFScrypt:
+		if p.N < 1<<14 || p.N > 1<<22 || p.N&(p.N-1) != 0 || p.R < 1 || p.R > 32 || p.P < 1 || p.P > 16 {
+			return fmt.Errorf("%w: scrypt N=%d r=%d p=%d", ErrUnsupported, p.N, p.R, p.P)
+		}
+	case KDFArgon2id:
+		if p.Time < 1 || p.Time > 64 || p.Memory < 8*1024 || p.Memory > 4*1024*1024 || p.Threads < 1 {
+			return fmt.Errorf("%w: argon2id t=%d m=%d p=%d", ErrUnsupported, p.Time, p.Memory, p.Threads)
+		}
+	default:
+		return fmt.Errorf("%w: kdf %q", ErrUnsupported, p.Name)
+	}
+	return nil
+}
+
+// key stretches password into a cipher key.
+func (p KDFParams) key(password []byte) ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	if p.Name == KDFScrypt {
+		return scrypt.Key(password, p.Salt, p.N, p.R, p.P, chacha20poly1305.KeySize)
+	}
+	return argon2.IDKey(password, p.Salt, p.Time, p.Memory, p.Threads, chacha20poly1305.KeySize), nil
+}
+
+// File is an encrypted seed as stored on disk. Everything but the ciphertext
+// is authenticated as associated data, so editing the header, e.g. to lower
+// the KDF cost or swap the fingerprint, makes the file fail to open.
+type File struct {
+	Version int `json:"version"`
+	// Fingerprint is the BIP-32 master key fingerprint, which identifies the
+	// wallet without unlocking it.
+	Fingerprint string    `json:"fingerprint"`
+	KDF         KDFParams `json:"kdf"`
+	Cipher      string    `json:"cipher"`
+	Nonce       []byte    `json:"nonce"`
+	Ciphertext  []byte    `json:"ciphertext"`
+}
+
+// header returns the associated data the ciphertext is bound to.
+func (f *File) header() []byte {
+	h, _ := json.Marshal(struct {
+		Version     int
+		Fingerprint string
+		KDF         KDFParams
+		Cipher      string
+	}{f.Version, f.Fingerprint, f.KDF, f.Cipher})
+	return h
+}
+
+// Encrypt seals a BIP-39 seed under password. A nil salt in kdf is filled
+// with random bytes.
+func Encrypt(seed, password []byte, kdf KDFParams) (*File, error) {
+	fingerprint, err := Fingerprint(seed)
+	if err != nil {
+		return nil, err
+	}
+	if kdf.Salt == nil {
+		kdf.Salt = make([]byte, saltSize)
+		if _, err := rand.Read(kdf.Salt); err != nil {
+			return nil, err
+		}
+	}
+	key, err := kdf.key(password)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		Version:     Version,
+		Fingerprint: fingerprint,
+		KDF:         kdf,
+		Cipher:      CipherXChaCha20Poly1305,
+		Nonce:       make([]byte, aead.NonceSize()),
+	}
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return nil, err
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, seed, f.header())
+	return f, nil
+}
+
+// Decrypt opens the file with password and returns the seed. The caller owns
+// the result and should clear it when done.
+func (f *File) Decrypt(password []byte) ([]byte, error) {
+	if f.Version != Version {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupported, f.Version)
+	}
+	if f.Cipher != CipherXChaCha20Poly1305 {
+		return nil, fmt.Errorf("%w: cipher %q", ErrUnsupported, f.Cipher)
+	}
+	key, err := f.KDF.key(password)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: nonce of %d bytes", ErrCorrupt, len(f.Nonce))
+	}
+	seed, err := aead.Open(nil, f.Nonce, f.Ciphertext, f.header())
+	if err != nil {
+		// A wrong password and a tampered file are indistinguishable here
+		return nil, ErrWrongPassword
+	}
+	if fingerprint, err := Fingerprint(seed); err != nil || fingerprint != f.Fingerprint {
+		clear(seed)
+		return nil, fmt.Errorf("%w: seed does not match fingerprint %s", ErrCorrupt, f.Fingerprint)
+	}
+	return seed, nil
+}
+
+// Fingerprint returns the BIP-32 fingerprint of a seed's master key: the first
+// four bytes of HASH160 of its public key, as wallets show it.
+func Fingerprint(seed []byte) (string, error) {
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return "", fmt.Errorf("create master key: %w", err)
+	}
+	defer master.Zero()
+	pub, err := master.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(btcutil.Hash160(pub.SerializeCompressed())[:4]), nil
+}
+
+// Load reads a keystore file.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+	return &f, nil
+}
+
+// Save writes a keystore file readable by its owner only. The file is
+// replaced atomically, so a crash never leaves a truncated keystore.
+func Save(path string, f *File) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".keystore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
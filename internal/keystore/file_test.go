@@ -0,0 +1,99 @@
+// keystore/file_test.go
+package keystore
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Cheapest parameters validate accepts, to keep the tests fast.
+var (
+	testScrypt   = KDFParams{Name: KDFScrypt, N: 1 << 14, R: 8, P: 1}
+	testArgon2id = KDFParams{Name: KDFArgon2id, Time: 1, Memory: 8 * 1024, Threads: 1}
+)
+
+func testSeed() []byte {
+	return bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+}
+
+func TestEncrypt_RoundTrip(t *testing.T) {
+	for _, kdf := range []KDFParams{testScrypt, testArgon2id} {
+		t.Run(kdf.Name, func(t *testing.T) {
+			f, err := Encrypt(testSeed(), []byte("hunter2"), kdf)
+			require.NoError(t, err)
+			assert.Equal(t, Version, f.Version)
+			assert.Equal(t, "73c5da0a", f.Fingerprint) // as shown by BIP-32 wallets
+			assert.Len(t, f.KDF.Salt, saltSize)
+			assert.NotContains(t, hex.EncodeToString(f.Ciphertext), hex.EncodeToString(testSeed()[:8]))
+
+			seed, err := f.Decrypt([]byte("hunter2"))
+			require.NoError(t, err)
+			assert.Equal(t, testSeed(), seed)
+
+			_, err = f.Decrypt([]byte("hunter3"))
+			assert.ErrorIs(t, err, ErrWrongPassword)
+		})
+	}
+
+	// Fresh salt and nonce every time
+	a, _ := Encrypt(testSeed(), []byte("hunter2"), testScrypt)
+	b, _ := Encrypt(testSeed(), []byte("hunter2"), testScrypt)
+	assert.NotEqual(t, a.KDF.Salt, b.KDF.Salt)
+	assert.NotEqual(t, a.Ciphertext, b.Ciphertext)
+}
+
+func TestDecrypt_TamperedHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(f *File)
+		want   error
+	}{
+		{"fingerprint", func(f *File) { f.Fingerprint = "00000000" }, ErrWrongPassword},
+		{"kdf cost", func(f *File) { f.KDF.N = 1 << 15 }, ErrWrongPassword},
+		{"ciphertext", func(f *File) { f.Ciphertext[0] ^= 1 }, ErrWrongPassword},
+		{"version", func(f *File) { f.Version = 2 }, ErrUnsupported},
+		{"cipher", func(f *File) { f.Cipher = "aes-256-gcm" }, ErrUnsupported},
+		{"kdf name", func(f *File) { f.KDF.Name = "pbkdf2" }, ErrUnsupported},
+		{"weak kdf", func(f *File) { f.KDF.N = 2 }, ErrUnsupported},
+		{"huge kdf", func(f *File) { f.KDF.N = 1 << 30 }, ErrUnsupported},
+		{"short salt", func(f *File) { f.KDF.Salt = f.KDF.Salt[:4] }, ErrCorrupt},
+		{"nonce", func(f *File) { f.Nonce = f.Nonce[:12] }, ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Encrypt(testSeed(), []byte("hunter2"), testScrypt)
+			require.NoError(t, err)
+			tt.tamper(f)
+			_, err = f.Decrypt([]byte("hunter2"))
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	f, err := Encrypt(testSeed(), []byte("hunter2"), testArgon2id)
+	require.NoError(t, err)
+	require.NoError(t, Save(path, f))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, f, loaded)
+	seed, err := loaded.Decrypt([]byte("hunter2"))
+	require.NoError(t, err)
+	assert.Equal(t, testSeed(), seed)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = Load(path)
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
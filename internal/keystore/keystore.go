@@ -0,0 +1,127 @@
+// Package keystore keeps a wallet seed encrypted at rest and decrypted in
+// memory only while unlocked.
+package keystore
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLocked is returned when using the seed of a locked keystore.
+var ErrLocked = errors.New("keystore is locked")
+
+// Keystore holds an encrypted seed and, while unlocked, its plaintext. Locking
+// zeroes the plaintext; an unlock with an auto-lock timeout locks again once
+// the seed has not been used for that long.
+type Keystore struct {
+	file *File
+
+	mu       sync.RWMutex
+	seed     []byte // nil while locked
+	autoLock time.Duration
+	lastUsed time.Time
+	timer    *time.Timer
+	epoch    uint64 // bumped on every unlock and lock to retire stale timers
+
+	usedMu sync.Mutex // guards lastUsed under a read lock
+}
+
+// New returns a locked keystore for an encrypted file.
+func New(f *File) *Keystore {
+	return &Keystore{file: f}
+}
+
+// Open loads a keystore file, locked.
+func Open(path string) (*Keystore, error) {
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(f), nil
+}
+
+// Fingerprint identifies the wallet without unlocking it.
+func (k *Keystore) Fingerprint() string {
+	return k.file.Fingerprint
+}
+
+// Unlock decrypts the seed. With a positive autoLock the keystore locks itself
+// after the seed has gone unused for that long; zero keeps it unlocked until
+// Lock. The caller should clear password afterwards.
+func (k *Keystore) Unlock(password []byte, autoLock time.Duration) error {
+	seed, err := k.file.Decrypt(password)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.lockLocked()
+	k.seed = seed
+	k.autoLock = autoLock
+	k.lastUsed = time.Now()
+	if autoLock > 0 {
+		epoch := k.epoch
+		k.timer = time.AfterFunc(autoLock, func() { k.expire(epoch) })
+	}
+	return nil
+}
+
+// Lock zeroes the seed. Calls to WithSeed in progress finish first.
+func (k *Keystore) Lock() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.lockLocked()
+}
+
+// lockLocked zeroes the seed and stops the auto-lock timer. k.mu must be held.
+func (k *Keystore) lockLocked() {
+	clear(k.seed)
+	k.seed = nil
+	if k.timer != nil {
+		k.timer.Stop()
+		k.timer = nil
+	}
+	k.epoch++
+}
+
+// expire locks the keystore if it has been idle for the auto-lock timeout and
+// otherwise checks again when it would be.
+func (k *Keystore) expire(epoch uint64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if epoch != k.epoch {
+		return // locked or unlocked again since this timer was set
+	}
+	k.usedMu.Lock()
+	idle := time.Since(k.lastUsed)
+	k.usedMu.Unlock()
+	if idle < k.autoLock {
+		k.timer = time.AfterFunc(k.autoLock-idle, func() { k.expire(epoch) })
+		return
+	}
+	k.lockLocked()
+}
+
+// Unlocked reports whether the seed is in memory.
+func (k *Keystore) Unlocked() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.seed != nil
+}
+
+// WithSeed lends the seed to fn and counts as use for the auto-lock. The
+// keystore cannot lock while fn runs; fn must not keep the slice or anything
+// sharing its memory after it returns.
+func (k *Keystore) WithSeed(fn func(seed []byte) error) error {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.seed == nil {
+		return ErrLocked
+	}
+	k.usedMu.Lock()
+	k.lastUsed = time.Now()
+	k.usedMu.Unlock()
+	return fn(k.seed)
+}
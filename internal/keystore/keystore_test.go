@@ -0,0 +1,98 @@
+// keystore/keystore_test.go
+package keystore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"andi-custodian/internal/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeystore(t *testing.T) *Keystore {
+	f, err := Encrypt(testSeed(), []byte("hunter2"), testScrypt)
+	require.NoError(t, err)
+	return New(f)
+}
+
+func TestKeystore_UnlockLock(t *testing.T) {
+	ks := newTestKeystore(t)
+	assert.Equal(t, "73c5da0a", ks.Fingerprint())
+	assert.False(t, ks.Unlocked())
+	assert.ErrorIs(t, ks.WithSeed(func([]byte) error { return nil }), ErrLocked)
+
+	assert.ErrorIs(t, ks.Unlock([]byte("wrong"), 0), ErrWrongPassword)
+	assert.False(t, ks.Unlocked())
+
+	require.NoError(t, ks.Unlock([]byte("hunter2"), 0))
+	var lent []byte
+	require.NoError(t, ks.WithSeed(func(seed []byte) error {
+		assert.Equal(t, testSeed(), seed)
+		lent = seed // only to check the zeroing below
+		return nil
+	}))
+
+	ks.Lock()
+	assert.False(t, ks.Unlocked())
+	assert.Equal(t, make([]byte, len(lent)), lent, "lock must zero the seed")
+	assert.ErrorIs(t, ks.WithSeed(func([]byte) error { return nil }), ErrLocked)
+}
+
+func TestKeystore_AutoLock(t *testing.T) {
+	ks := newTestKeystore(t)
+	require.NoError(t, ks.Unlock([]byte("hunter2"), 100*time.Millisecond))
+
+	// Use keeps it unlocked past the timeout
+	for i := 0; i < 10; i++ {
+		require.NoError(t, ks.WithSeed(func([]byte) error { return nil }))
+		time.Sleep(20 * time.Millisecond)
+	}
+	assert.True(t, ks.Unlocked())
+
+	// Idleness locks it
+	assert.Eventually(t, func() bool { return !ks.Unlocked() }, time.Second, 10*time.Millisecond)
+
+	// A timer from an earlier unlock does not lock a later one
+	require.NoError(t, ks.Unlock([]byte("hunter2"), 50*time.Millisecond))
+	require.NoError(t, ks.Unlock([]byte("hunter2"), 0))
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, ks.Unlocked())
+}
+
+func TestKeystore_Signer(t *testing.T) {
+	ks := newTestKeystore(t)
+	signer := wallet.NewSimulatedMPCSignerFrom(ks)
+	req := wallet.SignRequest{Chain: wallet.EthereumSepolia, Payload: make([]byte, 32)}
+
+	_, err := signer.Sign(context.Background(), req)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, ks.Unlock([]byte("hunter2"), 0))
+	sig, err := signer.Sign(context.Background(), req)
+	require.NoError(t, err)
+	// The key is the wallet's, so the signature recovers its address
+	w, err := wallet.NewWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	require.NoError(t, err)
+	derived, err := w.DeriveAddress(wallet.EthereumSepolia)
+	require.NoError(t, err)
+	assert.True(t, (&wallet.Verifier{}).VerifyEthereum(req.Payload, sig, derived.Address))
+
+	ks.Lock()
+	_, err = signer.Sign(context.Background(), req)
+	assert.ErrorIs(t, err, ErrLocked)
+}
+
+func TestNewWatchOnlyWalletFrom_Keystore(t *testing.T) {
+	ks := newTestKeystore(t)
+	require.NoError(t, ks.Unlock([]byte("hunter2"), 0))
+	watch, err := wallet.NewWatchOnlyWalletFrom(ks, 0, wallet.BitcoinMainnet)
+	require.NoError(t, err)
+	ks.Lock()
+
+	// Deposit addresses keep coming after the keystore locks
+	derived, err := watch.Derive(wallet.BitcoinMainnet, 0, wallet.ChangeExternal, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", derived.Address)
+}
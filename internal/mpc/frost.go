@@ -0,0 +1,395 @@
+// mpc/frost.go
+package mpc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"slices"
+
+	"filippo.io/edwards25519"
+)
+
+// frostContext is the RFC 9591 context string of FROST(Ed25519, SHA-512).
+// Following it makes signature shares interoperable with other FROST
+// implementations, and the aggregate an ordinary Ed25519 signature.
+const frostContext = "FROST-ED25519-SHA512-v1"
+
+// frostKey is a party's FROST key share. Like the ECDSA share, it never
+// leaves the party.
+type frostKey struct {
+	share     *edwards25519.Scalar
+	publicKey *edwards25519.Point // group key
+}
+
+// frostDKGCommit is a party's broadcast in the first FROST DKG round:
+// Feldman commitments to its polynomial and a Schnorr proof that it knows
+// the constant term, which stops a party from cancelling the others' keys.
+type frostDKGCommit struct {
+	commitments []*edwards25519.Point
+	proofR      *edwards25519.Point
+	proofZ      *edwards25519.Scalar
+}
+
+// frostDKGResult is what a party reports after FROST DKG: the sum of every
+// party's commitments. The first is the group key; evaluated at a party's
+// identifier they give its verification share.
+type frostDKGResult struct {
+	commitments []*edwards25519.Point
+}
+
+// frostDKG runs Pedersen distributed key generation over edwards25519, as in
+// the FROST paper: each party shares a random secret with a proof of
+// knowledge, and each party's key share is the sum of the shares it received.
+func frostDKG(ctx context.Context, p *party, session uint64) (any, error) {
+	coeffs := make([]*edwards25519.Scalar, p.threshold)
+	commitments := make([]*edwards25519.Point, p.threshold)
+	for i := range coeffs {
+		c, err := randomEdScalar()
+		if err != nil {
+			return nil, err
+		}
+		coeffs[i] = c
+		commitments[i] = new(edwards25519.Point).ScalarBaseMult(c)
+	}
+	defer zeroEdScalars(coeffs...)
+
+	k, err := randomEdScalar()
+	if err != nil {
+		return nil, err
+	}
+	defer zeroEdScalars(k)
+	proofR := new(edwards25519.Point).ScalarBaseMult(k)
+	challenge := frostProofChallenge(p.id, commitments[0], proofR)
+	commit := &frostDKGCommit{
+		commitments: commitments,
+		proofR:      proofR,
+		proofZ:      edwards25519.NewScalar().MultiplyAdd(coeffs[0], challenge, k),
+	}
+
+	all := make([]int, p.parties)
+	for i := range all {
+		all[i] = i + 1
+	}
+	if err := p.sendTo(ctx, session, roundFROSTCommit, all, func(int) any { return commit }); err != nil {
+		return nil, err
+	}
+	if err := p.sendTo(ctx, session, roundFROSTShare, all, func(to int) any { return evalPolynomial(coeffs, frostID(to)) }); err != nil {
+		return nil, err
+	}
+
+	id := frostID(p.id)
+	share := evalPolynomial(coeffs, id)
+	combined := make([]*edwards25519.Point, p.threshold)
+	for i, c := range commitments {
+		combined[i] = new(edwards25519.Point).Set(c)
+	}
+	for _, from := range all {
+		if from == p.id {
+			continue
+		}
+		msg, err := p.mail.recv(ctx, session, roundFROSTCommit, from)
+		if err != nil {
+			return nil, err
+		}
+		theirs := msg.payload.(*frostDKGCommit)
+		if len(theirs.commitments) != p.threshold || !theirs.verifyProof(from) {
+			return nil, fmt.Errorf("party %d sent a malformed commitment", from)
+		}
+		msg, err = p.mail.recv(ctx, session, roundFROSTShare, from)
+		if err != nil {
+			return nil, err
+		}
+		mine := msg.payload.(*edwards25519.Scalar)
+		if new(edwards25519.Point).ScalarBaseMult(mine).Equal(evalCommitments(theirs.commitments, id)) != 1 {
+			return nil, fmt.Errorf("party %d sent a share that does not match its commitments", from)
+		}
+		share.Add(share, mine)
+		for i, c := range theirs.commitments {
+			combined[i].Add(combined[i], c)
+		}
+	}
+
+	if new(edwards25519.Point).ScalarBaseMult(share).Equal(evalCommitments(combined, id)) != 1 {
+		zeroEdScalars(share)
+		return nil, errors.New("key share does not match the group commitments")
+	}
+	if combined[0].Equal(edwards25519.NewIdentityPoint()) == 1 {
+		zeroEdScalars(share)
+		return nil, errors.New("group key is the identity")
+	}
+	p.frost = &frostKey{share: share, publicKey: combined[0]}
+	return &frostDKGResult{commitments: combined}, nil
+}
+
+// verifyProof checks the proof of knowledge of the secret behind the first
+// commitment: z·B = R + c·C₀.
+func (c *frostDKGCommit) verifyProof(from int) bool {
+	for _, p := range append([]*edwards25519.Point{c.proofR}, c.commitments...) {
+		if p == nil {
+			return false
+		}
+	}
+	challenge := frostProofChallenge(from, c.commitments[0], c.proofR)
+	negC := edwards25519.NewScalar().Negate(challenge)
+	R := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(negC, c.commitments[0], c.proofZ)
+	return R.Equal(c.proofR) == 1
+}
+
+func frostProofChallenge(id int, secret, R *edwards25519.Point) *edwards25519.Scalar {
+	return frostHash("dkg", frostID(id).Bytes(), secret.Bytes(), R.Bytes())
+}
+
+// frostCommitment is a party's commitment to its hiding and binding nonces.
+type frostCommitment struct {
+	hiding  *edwards25519.Point // D_i = d_i·B
+	binding *edwards25519.Point // E_i = e_i·B
+}
+
+// frostShare is a party's signature share together with the nonce
+// commitment it was made with.
+type frostShare struct {
+	commitment *frostCommitment
+	z          *edwards25519.Scalar
+}
+
+// signFROST returns the protocol that produces party p's FROST signature
+// share of msg together with the other parties in signers.
+//
+// In the first round each signer commits to a fresh pair of nonces; in the
+// second it binds them to the message and the whole commitment list and
+// answers z_i = d_i + e_i·ρ_i + λ_i·s_i·c. Nonces are used for one session
+// only and erased when it ends, whether or not it succeeds.
+func signFROST(signers []int, msg []byte) func(context.Context, *party, uint64) (any, error) {
+	return func(ctx context.Context, p *party, session uint64) (any, error) {
+		if p.frost == nil {
+			return nil, errors.New("no FROST key share; run DKG first")
+		}
+		hiding, err := frostNonce(p.frost.share)
+		if err != nil {
+			return nil, err
+		}
+		defer zeroEdScalars(hiding)
+		binding, err := frostNonce(p.frost.share)
+		if err != nil {
+			return nil, err
+		}
+		defer zeroEdScalars(binding)
+
+		// Round 1: commit to the nonces
+		mine := &frostCommitment{
+			hiding:  new(edwards25519.Point).ScalarBaseMult(hiding),
+			binding: new(edwards25519.Point).ScalarBaseMult(binding),
+		}
+		if err := p.sendTo(ctx, session, roundFROSTNonce, signers, func(int) any { return mine }); err != nil {
+			return nil, err
+		}
+		commitments := map[int]*frostCommitment{p.id: mine}
+		for _, from := range signers {
+			if from == p.id {
+				continue
+			}
+			msg, err := p.mail.recv(ctx, session, roundFROSTNonce, from)
+			if err != nil {
+				return nil, err
+			}
+			theirs, _ := msg.payload.(*frostCommitment)
+			if !theirs.valid() {
+				return nil, fmt.Errorf("party %d sent an invalid nonce commitment", from)
+			}
+			commitments[from] = theirs
+		}
+
+		// Round 2: the signature share
+		R, rho := groupCommitment(p.frost.publicKey, msg, commitments)
+		c := frostChallenge(R, p.frost.publicKey, msg)
+		weighted := edwards25519.NewScalar().Multiply(frostLagrange(p.id, signers), p.frost.share)
+		defer zeroEdScalars(weighted)
+		z := edwards25519.NewScalar().MultiplyAdd(binding, rho[p.id], hiding)
+		z.MultiplyAdd(weighted, c, z)
+		return &frostShare{commitment: mine, z: z}, nil
+	}
+}
+
+// valid reports whether both nonce commitments are points of the prime-order
+// subgroup other than the identity.
+func (c *frostCommitment) valid() bool {
+	if c == nil || c.hiding == nil || c.binding == nil {
+		return false
+	}
+	identity := edwards25519.NewIdentityPoint()
+	for _, p := range []*edwards25519.Point{c.hiding, c.binding} {
+		if p.Equal(identity) == 1 || new(edwards25519.Point).MultByCofactor(p).Equal(identity) == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// frostAggregate checks every party's signature share against its
+// verification share and sums them into an Ed25519 signature R || z. An
+// invalid share is reported with the party that sent it.
+func frostAggregate(groupCommitments []*edwards25519.Point, msg []byte, shares map[int]*frostShare) ([]byte, error) {
+	publicKey := groupCommitments[0]
+	signers := make([]int, 0, len(shares))
+	commitments := make(map[int]*frostCommitment, len(shares))
+	for id, share := range shares {
+		if !share.commitment.valid() {
+			return nil, fmt.Errorf("party %d sent an invalid nonce commitment", id)
+		}
+		signers = append(signers, id)
+		commitments[id] = share.commitment
+	}
+	R, rho := groupCommitment(publicKey, msg, commitments)
+	c := frostChallenge(R, publicKey, msg)
+
+	z := edwards25519.NewScalar()
+	for _, id := range signers {
+		share := shares[id]
+		// z_i·B = D_i + ρ_i·E_i + c·λ_i·Y_i
+		verifying := evalCommitments(groupCommitments, frostID(id))
+		cl := edwards25519.NewScalar().Multiply(c, frostLagrange(id, signers))
+		want := new(edwards25519.Point).ScalarMult(rho[id], share.commitment.binding)
+		want.Add(want, share.commitment.hiding)
+		want.Add(want, new(edwards25519.Point).ScalarMult(cl, verifying))
+		if new(edwards25519.Point).ScalarBaseMult(share.z).Equal(want) != 1 {
+			return nil, fmt.Errorf("party %d sent an invalid signature share", id)
+		}
+		z.Add(z, share.z)
+	}
+
+	sig := append(R.Bytes(), z.Bytes()...)
+	if !ed25519.Verify(publicKey.Bytes(), msg, sig) {
+		return nil, errors.New("signature does not verify")
+	}
+	return sig, nil
+}
+
+// groupCommitment computes each signer's binding factor ρ_i and the group
+// commitment R = Σ D_i + ρ_i·E_i, the signature's nonce point.
+func groupCommitment(publicKey *edwards25519.Point, msg []byte, commitments map[int]*frostCommitment) (*edwards25519.Point, map[int]*edwards25519.Scalar) {
+	ids := make([]int, 0, len(commitments))
+	for id := range commitments {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var encoded []byte
+	for _, id := range ids {
+		encoded = append(encoded, frostID(id).Bytes()...)
+		encoded = append(encoded, commitments[id].hiding.Bytes()...)
+		encoded = append(encoded, commitments[id].binding.Bytes()...)
+	}
+	prefix := publicKey.Bytes()
+	prefix = append(prefix, frostDigest("msg", msg)...)
+	prefix = append(prefix, frostDigest("com", encoded)...)
+
+	R := edwards25519.NewIdentityPoint()
+	rho := make(map[int]*edwards25519.Scalar, len(ids))
+	for _, id := range ids {
+		rho[id] = frostHash("rho", prefix, frostID(id).Bytes())
+		R.Add(R, new(edwards25519.Point).ScalarMult(rho[id], commitments[id].binding))
+		R.Add(R, commitments[id].hiding)
+	}
+	return R, rho
+}
+
+// frostChallenge is Ed25519's challenge SHA-512(R || A || M), so the
+// aggregate verifies as a plain Ed25519 signature.
+func frostChallenge(R, publicKey *edwards25519.Point, msg []byte) *edwards25519.Scalar {
+	h := sha512.New()
+	h.Write(R.Bytes())
+	h.Write(publicKey.Bytes())
+	h.Write(msg)
+	c, _ := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	return c
+}
+
+// frostNonce hedges fresh randomness with the key share, so a weak random
+// source alone does not repeat nonces.
+func frostNonce(secret *edwards25519.Scalar) (*edwards25519.Scalar, error) {
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+	defer clear(random[:])
+	return frostHash("nonce", random[:], secret.Bytes()), nil
+}
+
+// frostDigest is SHA-512 under the FROST context string and a tag.
+func frostDigest(tag string, parts ...[]byte) []byte {
+	h := sha512.New()
+	h.Write([]byte(frostContext))
+	h.Write([]byte(tag))
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// frostHash is frostDigest reduced to a scalar.
+func frostHash(tag string, parts ...[]byte) *edwards25519.Scalar {
+	s, _ := edwards25519.NewScalar().SetUniformBytes(frostDigest(tag, parts...))
+	return s
+}
+
+// frostID returns a party's identifier as a scalar.
+func frostID(id int) *edwards25519.Scalar {
+	var b [32]byte
+	b[0] = byte(id)
+	s, _ := edwards25519.NewScalar().SetCanonicalBytes(b[:])
+	return s
+}
+
+// frostLagrange returns the Lagrange coefficient of id at zero over signers.
+func frostLagrange(id int, signers []int) *edwards25519.Scalar {
+	num, den := frostID(1), frostID(1)
+	xi := frostID(id)
+	for _, j := range signers {
+		if j == id {
+			continue
+		}
+		xj := frostID(j)
+		num.Multiply(num, xj)
+		den.Multiply(den, edwards25519.NewScalar().Subtract(xj, xi))
+	}
+	return num.Multiply(num, den.Invert(den))
+}
+
+// evalPolynomial returns Σ coeffs[i]·xⁱ.
+func evalPolynomial(coeffs []*edwards25519.Scalar, x *edwards25519.Scalar) *edwards25519.Scalar {
+	y := edwards25519.NewScalar()
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y.MultiplyAdd(y, x, coeffs[i])
+	}
+	return y
+}
+
+// evalCommitments returns Σ commitments[i]·xⁱ, the public image of
+// evalPolynomial.
+func evalCommitments(commitments []*edwards25519.Point, x *edwards25519.Scalar) *edwards25519.Point {
+	y := edwards25519.NewIdentityPoint()
+	for i := len(commitments) - 1; i >= 0; i-- {
+		y.ScalarMult(x, y)
+		y.Add(y, commitments[i])
+	}
+	return y
+}
+
+func randomEdScalar() (*edwards25519.Scalar, error) {
+	var b [64]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	defer clear(b[:])
+	return edwards25519.NewScalar().SetUniformBytes(b[:])
+}
+
+func zeroEdScalars(scalars ...*edwards25519.Scalar) {
+	for _, s := range scalars {
+		s.Set(edwards25519.NewScalar())
+	}
+}
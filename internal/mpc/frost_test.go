@@ -0,0 +1,96 @@
+// mpc/frost_test.go
+package mpc
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"filippo.io/edwards25519"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dealFROST splits a random key with a dealer, standing in for DKG.
+func dealFROST(t *testing.T, threshold, total int) (map[int]*frostKey, []*edwards25519.Point) {
+	t.Helper()
+	coeffs := make([]*edwards25519.Scalar, threshold)
+	commitments := make([]*edwards25519.Point, threshold)
+	for i := range coeffs {
+		c, err := randomEdScalar()
+		require.NoError(t, err)
+		coeffs[i] = c
+		commitments[i] = new(edwards25519.Point).ScalarBaseMult(c)
+	}
+	keys := make(map[int]*frostKey, total)
+	for id := 1; id <= total; id++ {
+		keys[id] = &frostKey{share: evalPolynomial(coeffs, frostID(id)), publicKey: commitments[0]}
+	}
+	return keys, commitments
+}
+
+// frostRound runs both signing rounds for signers without the network.
+func frostRound(t *testing.T, keys map[int]*frostKey, signers []int, msg []byte) map[int]*frostShare {
+	t.Helper()
+	nonces := make(map[int][2]*edwards25519.Scalar)
+	commitments := make(map[int]*frostCommitment)
+	for _, id := range signers {
+		d, err := frostNonce(keys[id].share)
+		require.NoError(t, err)
+		e, err := frostNonce(keys[id].share)
+		require.NoError(t, err)
+		nonces[id] = [2]*edwards25519.Scalar{d, e}
+		commitments[id] = &frostCommitment{
+			hiding:  new(edwards25519.Point).ScalarBaseMult(d),
+			binding: new(edwards25519.Point).ScalarBaseMult(e),
+		}
+	}
+	shares := make(map[int]*frostShare)
+	for _, id := range signers {
+		R, rho := groupCommitment(keys[id].publicKey, msg, commitments)
+		c := frostChallenge(R, keys[id].publicKey, msg)
+		z := edwards25519.NewScalar().MultiplyAdd(nonces[id][1], rho[id], nonces[id][0])
+		weighted := edwards25519.NewScalar().Multiply(frostLagrange(id, signers), keys[id].share)
+		z.MultiplyAdd(weighted, c, z)
+		shares[id] = &frostShare{commitment: commitments[id], z: z}
+	}
+	return shares
+}
+
+func TestFROST_Aggregate(t *testing.T) {
+	keys, commitments := dealFROST(t, 3, 5)
+	msg := []byte("transfer 1 SOL")
+
+	for _, signers := range [][]int{{1, 2, 3}, {5, 2, 4}, {1, 3, 5}} {
+		sig, err := frostAggregate(commitments, msg, frostRound(t, keys, signers, msg))
+		require.NoError(t, err, "signers %v", signers)
+		assert.True(t, ed25519.Verify(commitments[0].Bytes(), msg, sig), "signers %v", signers)
+	}
+}
+
+func TestFROST_Aggregate_NamesCheater(t *testing.T) {
+	keys, commitments := dealFROST(t, 2, 3)
+	msg := []byte("transfer 1 SOL")
+	shares := frostRound(t, keys, []int{1, 3}, msg)
+
+	shares[3].z.Add(shares[3].z, frostID(1))
+	_, err := frostAggregate(commitments, msg, shares)
+	assert.ErrorContains(t, err, "party 3 sent an invalid signature share")
+
+	shares = frostRound(t, keys, []int{1, 3}, msg)
+	shares[1].commitment.binding = edwards25519.NewIdentityPoint()
+	_, err = frostAggregate(commitments, msg, shares)
+	assert.ErrorContains(t, err, "party 1 sent an invalid nonce commitment")
+}
+
+func TestFROST_Lagrange(t *testing.T) {
+	keys, commitments := dealFROST(t, 3, 4)
+	signers := []int{4, 1, 2}
+	secret := edwards25519.NewScalar()
+	for _, id := range signers {
+		secret.MultiplyAdd(frostLagrange(id, signers), keys[id].share, secret)
+	}
+	assert.Equal(t, 1, new(edwards25519.Point).ScalarBaseMult(secret).Equal(commitments[0]))
+	for id, key := range keys {
+		assert.Equal(t, 1, new(edwards25519.Point).ScalarBaseMult(key.share).Equal(evalCommitments(commitments, frostID(id))), "party %d", id)
+	}
+}
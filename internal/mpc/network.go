@@ -0,0 +1,102 @@
+// mpc/network.go
+package mpc
+
+import (
+	"context"
+	"fmt"
+)
+
+// coordinatorID addresses the coordinator; parties are numbered from 1.
+const coordinatorID = 0
+
+// Protocol rounds. Each message belongs to one round of one session.
+const (
+	roundDKGCommit   = iota + 1 // broadcast: Feldman commitments and Paillier key
+	roundDKGShare               // peer to peer: the recipient's share of the sender's secret
+	roundSignCipher             // broadcast: Paillier encryption of k_i
+	roundSignMtA                // peer to peer: MtA responses for k_j·γ_i and k_j·w_i
+	roundSignDelta              // broadcast: δ_i and Γ_i
+	roundFROSTCommit            // broadcast: FROST DKG commitments and proof of knowledge
+	roundFROSTShare             // peer to peer: the recipient's FROST DKG share
+	roundFROSTNonce             // broadcast: FROST nonce commitments
+	roundResult                 // to the coordinator: the party's outcome
+)
+
+// message is one protocol message. Payloads are read by the recipient only.
+type message struct {
+	session uint64
+	round   int
+	from    int
+	to      int
+	payload any
+}
+
+// network delivers messages between the coordinator and the parties. Each
+// participant owns one inbox; sends block only while an inbox is full.
+type network struct {
+	inboxes map[int]chan message
+}
+
+func newNetwork(parties int) *network {
+	n := &network{inboxes: make(map[int]chan message, parties+1)}
+	for id := coordinatorID; id <= parties; id++ {
+		// Room for every message of a session, so no sender waits on a slow peer
+		n.inboxes[id] = make(chan message, 8*(parties+1))
+	}
+	return n
+}
+
+// send delivers msg to its recipient unless ctx ends first.
+func (n *network) send(ctx context.Context, msg message) error {
+	inbox, ok := n.inboxes[msg.to]
+	if !ok {
+		return fmt.Errorf("no participant %d", msg.to)
+	}
+	select {
+	case inbox <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// mailbox receives one participant's messages, holding back those that
+// arrive before the round waiting for them.
+type mailbox struct {
+	inbox   <-chan message
+	pending []message
+}
+
+// recv returns the message of a session and round from a sender. Messages of
+// earlier sessions are dropped; those of later rounds are kept for later.
+func (m *mailbox) recv(ctx context.Context, session uint64, round, from int) (message, error) {
+	kept := m.pending[:0]
+	var found *message
+	for _, msg := range m.pending {
+		switch {
+		case msg.session < session:
+		case found == nil && msg.session == session && msg.round == round && msg.from == from:
+			found = &msg
+		default:
+			kept = append(kept, msg)
+		}
+	}
+	m.pending = kept
+	if found != nil {
+		return *found, nil
+	}
+
+	for {
+		select {
+		case msg := <-m.inbox:
+			if msg.session == session && msg.round == round && msg.from == from {
+				return msg, nil
+			}
+			if msg.session >= session {
+				m.pending = append(m.pending, msg)
+			}
+		case <-ctx.Done():
+			return message{}, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// mpc/paillier.go
+package mpc
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var one = big.NewInt(1)
+
+// paillierPublicKey encrypts under the additively homomorphic Paillier scheme
+// with generator N+1.
+type paillierPublicKey struct {
+	N  *big.Int
+	N2 *big.Int // N²
+}
+
+// paillierKey is a Paillier key pair. It never leaves the party that made it.
+type paillierKey struct {
+	paillierPublicKey
+	lambda *big.Int // φ(N)
+	mu     *big.Int // φ(N)⁻¹ mod N
+}
+
+// generatePaillierKey returns a key with a modulus of bits bits.
+func generatePaillierKey(bits int) (*paillierKey, error) {
+	for {
+		p, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := rand.Prime(rand.Reader, bits-bits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+		n := new(big.Int).Mul(p, q)
+		lambda := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		// Equal-length primes make gcd(N, φ(N)) = 1, so μ exists
+		mu := new(big.Int).ModInverse(lambda, n)
+		if mu == nil {
+			continue
+		}
+		return &paillierKey{
+			paillierPublicKey: paillierPublicKey{N: n, N2: new(big.Int).Mul(n, n)},
+			lambda:            lambda,
+			mu:                mu,
+		}, nil
+	}
+}
+
+// encrypt returns (1 + m·N)·r^N mod N² for a random unit r.
+func (pk *paillierPublicKey) encrypt(m *big.Int) (*big.Int, error) {
+	if m.Sign() < 0 || m.Cmp(pk.N) >= 0 {
+		return nil, errors.New("paillier plaintext out of range")
+	}
+	var r *big.Int
+	for {
+		var err error
+		if r, err = rand.Int(rand.Reader, pk.N); err != nil {
+			return nil, err
+		}
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, pk.N).Cmp(one) == 0 {
+			break
+		}
+	}
+	c := new(big.Int).Mul(m, pk.N)
+	c.Add(c, one)
+	c.Mul(c, new(big.Int).Exp(r, pk.N, pk.N2))
+	return c.Mod(c, pk.N2), nil
+}
+
+// add returns a ciphertext of the sum of the plaintexts of c1 and c2.
+func (pk *paillierPublicKey) add(c1, c2 *big.Int) *big.Int {
+	c := new(big.Int).Mul(c1, c2)
+	return c.Mod(c, pk.N2)
+}
+
+// mul returns a ciphertext of k times the plaintext of c.
+func (pk *paillierPublicKey) mul(c, k *big.Int) *big.Int {
+	return new(big.Int).Exp(c, k, pk.N2)
+}
+
+// validCiphertext reports whether c is a unit modulo N², as every honest
+// ciphertext is.
+func (pk *paillierPublicKey) validCiphertext(c *big.Int) bool {
+	return c != nil && c.Sign() > 0 && c.Cmp(pk.N2) < 0 && new(big.Int).GCD(nil, nil, c, pk.N2).Cmp(one) == 0
+}
+
+// decrypt returns L(c^λ mod N²)·μ mod N, where L(u) = (u-1)/N.
+func (sk *paillierKey) decrypt(c *big.Int) (*big.Int, error) {
+	if !sk.validCiphertext(c) {
+		return nil, fmt.Errorf("invalid paillier ciphertext")
+	}
+	u := new(big.Int).Exp(c, sk.lambda, sk.N2)
+	u.Sub(u, one)
+	u.Div(u, sk.N)
+	u.Mul(u, sk.mu)
+	return u.Mod(u, sk.N), nil
+}
@@ -0,0 +1,35 @@
+// mpc/paillier_test.go
+package mpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaillier_Homomorphism(t *testing.T) {
+	sk, err := generatePaillierKey(512)
+	require.NoError(t, err)
+	require.Equal(t, 512, sk.N.BitLen())
+
+	a, b, k := big.NewInt(1234567), big.NewInt(7654321), big.NewInt(99)
+	ca, err := sk.encrypt(a)
+	require.NoError(t, err)
+	cb, err := sk.encrypt(b)
+	require.NoError(t, err)
+
+	got, err := sk.decrypt(sk.add(ca, cb))
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int).Add(a, b), got)
+
+	got, err = sk.decrypt(sk.mul(ca, k))
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int).Mul(a, k), got)
+
+	_, err = sk.encrypt(sk.N)
+	assert.Error(t, err)
+	_, err = sk.decrypt(sk.N2)
+	assert.Error(t, err)
+}
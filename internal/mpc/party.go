@@ -0,0 +1,378 @@
+// mpc/party.go
+package mpc
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"andi-custodian/internal/shamir"
+)
+
+// curveOrder is q, the order of secp256k1.
+var curveOrder = btcec.S256().N
+
+// mtaMask bounds the MtA masks: q⁵ hides a product below q² and still leaves
+// the masked sum far below any Paillier modulus this package accepts.
+var mtaMask = new(big.Int).Exp(curveOrder, big.NewInt(5), nil)
+
+// command starts a protocol session on a party.
+type command struct {
+	ctx     context.Context
+	session uint64
+	run     func(ctx context.Context, p *party, session uint64) (any, error)
+}
+
+// party is one signer. Its key shares and Paillier secret key live only in
+// the goroutine running run; everything else reaches it as messages.
+type party struct {
+	id           int
+	parties      int
+	threshold    int
+	paillierBits int
+	net          *network
+	mail         mailbox
+	commands     chan command
+
+	// Set by DKG
+	share    btcec.ModNScalar
+	paillier *paillierKey
+	peers    map[int]*paillierPublicKey
+
+	// Set by FROST DKG
+	frost *frostKey
+}
+
+// run serves commands until the channel closes, reporting each outcome to
+// the coordinator.
+func (p *party) run() {
+	for cmd := range p.commands {
+		payload, err := cmd.run(cmd.ctx, p, cmd.session)
+		if err != nil {
+			payload = fmt.Errorf("party %d: %w", p.id, err)
+		}
+		_ = p.net.send(cmd.ctx, message{session: cmd.session, round: roundResult, from: p.id, to: coordinatorID, payload: payload})
+	}
+}
+
+// sendTo sends a payload to each of the given parties but p.
+func (p *party) sendTo(ctx context.Context, session uint64, round int, ids []int, payload func(to int) any) error {
+	for _, to := range ids {
+		if to == p.id {
+			continue
+		}
+		if err := p.net.send(ctx, message{session: session, round: round, from: p.id, to: to, payload: payload(to)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dkgCommit is a party's broadcast in the first DKG round.
+type dkgCommit struct {
+	commitments shamir.Commitments
+	paillier    *paillierPublicKey
+}
+
+// dkgResult is what a party reports after DKG: public values only.
+type dkgResult struct {
+	publicKey   *btcec.PublicKey
+	commitments shamir.Commitments // sum of every party's commitments
+}
+
+// dkg runs Feldman-verified distributed key generation: every party shares a
+// random secret, and each party's key share is the sum of the shares it
+// received. The group key is the sum of the secrets, which no one learns.
+func dkg(ctx context.Context, p *party, session uint64) (any, error) {
+	paillier, err := generatePaillierKey(p.paillierBits)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := shamir.RandomScalar()
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Zero()
+	shares, commitments, err := shamir.Split(secret, p.threshold, p.parties, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]int, p.parties)
+	for i := range all {
+		all[i] = i + 1
+	}
+	commit := &dkgCommit{commitments: commitments, paillier: &paillier.paillierPublicKey}
+	if err := p.sendTo(ctx, session, roundDKGCommit, all, func(int) any { return commit }); err != nil {
+		return nil, err
+	}
+	if err := p.sendTo(ctx, session, roundDKGShare, all, func(to int) any { return shares[to-1] }); err != nil {
+		return nil, err
+	}
+
+	share := shares[p.id-1].Value
+	combined := commitments
+	peers := make(map[int]*paillierPublicKey, p.parties-1)
+	for _, from := range all {
+		if from == p.id {
+			continue
+		}
+		msg, err := p.mail.recv(ctx, session, roundDKGCommit, from)
+		if err != nil {
+			return nil, err
+		}
+		theirs := msg.payload.(*dkgCommit)
+		if len(theirs.commitments) != p.threshold || theirs.paillier.N.BitLen() < p.paillierBits {
+			return nil, fmt.Errorf("party %d sent a malformed commitment", from)
+		}
+		msg, err = p.mail.recv(ctx, session, roundDKGShare, from)
+		if err != nil {
+			return nil, err
+		}
+		mine := msg.payload.(shamir.Share)
+		if mine.Index != uint8(p.id) || !theirs.commitments.Verify(mine) {
+			return nil, fmt.Errorf("%w: party %d sent a share that does not match its commitments", shamir.ErrInvalidShare, from)
+		}
+		share.Add(&mine.Value)
+		combined = addCommitments(combined, theirs.commitments)
+		peers[from] = theirs.paillier
+	}
+
+	// The summed commitments must open to the summed share
+	if !combined.Verify(shamir.Share{Index: uint8(p.id), Value: share}) {
+		return nil, errors.New("key share does not match the group commitments")
+	}
+	p.share = share
+	p.paillier = paillier
+	p.peers = peers
+	return &dkgResult{publicKey: combined[0], commitments: combined}, nil
+}
+
+// addCommitments adds two sets of commitments pointwise, committing to the
+// sum of the polynomials.
+func addCommitments(a, b shamir.Commitments) shamir.Commitments {
+	sum := make(shamir.Commitments, len(a))
+	for i := range a {
+		var pa, pb, ps btcec.JacobianPoint
+		a[i].AsJacobian(&pa)
+		b[i].AsJacobian(&pb)
+		btcec.AddNonConst(&pa, &pb, &ps)
+		sum[i] = toPublicKey(&ps)
+	}
+	return sum
+}
+
+// signMtA carries party i's MtA responses to party j's encrypted k_j: one
+// ciphertext of k_j·γ_i + β'γ and one of k_j·w_i + β'w.
+type signMtA struct {
+	gamma *big.Int
+	w     *big.Int
+}
+
+// signDelta is a party's broadcast in the third signing round.
+type signDelta struct {
+	delta btcec.ModNScalar // share of k·γ
+	gamma *btcec.PublicKey // Γ_i = γ_i·G
+}
+
+// signResult is a party's share of the signature.
+type signResult struct {
+	r *btcec.PublicKey // R = k⁻¹·G
+	s btcec.ModNScalar // share of s = k(m + r·x)
+}
+
+// sign returns the protocol that produces party p's share of a signature on
+// hash together with the other parties in signers.
+//
+// With k = Σk_i and γ = Σγ_i, the parties compute additive shares of k·γ and
+// k·x through pairwise multiplicative-to-additive (MtA) conversions over
+// Paillier, open δ = k·γ and Γ = γ·G to get R = δ⁻¹·Γ = k⁻¹·G, and each
+// contribute s_i = m·k_i + r·σ_i with Σσ_i = k·x. The range proofs GG18
+// attaches to each MtA message are omitted, so the protocol is secure against
+// honest-but-curious parties only.
+func sign(signers []int, hash []byte) func(context.Context, *party, uint64) (any, error) {
+	return func(ctx context.Context, p *party, session uint64) (any, error) {
+		if p.paillier == nil {
+			return nil, errors.New("no key share; run DKG first")
+		}
+		indexes := make([]uint8, len(signers))
+		for i, id := range signers {
+			indexes[i] = uint8(id)
+		}
+		// w_i = λ_i·x_i turns the threshold shares into additive shares of x
+		w := shamir.Lagrange(uint8(p.id), indexes)
+		w.Mul(&p.share)
+		defer w.Zero()
+
+		k, err := shamir.RandomScalar()
+		if err != nil {
+			return nil, err
+		}
+		defer k.Zero()
+		gamma, err := shamir.RandomScalar()
+		if err != nil {
+			return nil, err
+		}
+		defer gamma.Zero()
+
+		// Round 1: Enc_i(k_i) to every other signer
+		kCipher, err := p.paillier.encrypt(scalarToInt(k))
+		if err != nil {
+			return nil, err
+		}
+		if err := p.sendTo(ctx, session, roundSignCipher, signers, func(int) any { return kCipher }); err != nil {
+			return nil, err
+		}
+
+		// Round 2: answer each Enc_j(k_j) with masked encryptions of k_j·γ_i
+		// and k_j·w_i, keeping the negated masks as our halves
+		var delta, sigma btcec.ModNScalar
+		delta.Mul2(k, gamma)
+		sigma.Mul2(k, w)
+		gammaInt, wInt := scalarToInt(gamma), scalarToInt(w)
+		responses := make(map[int]*signMtA, len(signers)-1)
+		for _, from := range signers {
+			if from == p.id {
+				continue
+			}
+			msg, err := p.mail.recv(ctx, session, roundSignCipher, from)
+			if err != nil {
+				return nil, err
+			}
+			theirs := p.peers[from]
+			c, _ := msg.payload.(*big.Int)
+			if !theirs.validCiphertext(c) {
+				return nil, fmt.Errorf("party %d sent an invalid ciphertext", from)
+			}
+			gammaResp, betaGamma, err := mtaRespond(theirs, c, gammaInt)
+			if err != nil {
+				return nil, err
+			}
+			wResp, betaW, err := mtaRespond(theirs, c, wInt)
+			if err != nil {
+				return nil, err
+			}
+			delta.Add(betaGamma)
+			sigma.Add(betaW)
+			responses[from] = &signMtA{gamma: gammaResp, w: wResp}
+		}
+		if err := p.sendTo(ctx, session, roundSignMtA, signers, func(to int) any { return responses[to] }); err != nil {
+			return nil, err
+		}
+
+		// Round 3: decrypt the answers to our k_i into our halves
+		for _, from := range signers {
+			if from == p.id {
+				continue
+			}
+			msg, err := p.mail.recv(ctx, session, roundSignMtA, from)
+			if err != nil {
+				return nil, err
+			}
+			resp := msg.payload.(*signMtA)
+			alpha, err := p.paillier.decrypt(resp.gamma)
+			if err != nil {
+				return nil, fmt.Errorf("party %d: %w", from, err)
+			}
+			mu, err := p.paillier.decrypt(resp.w)
+			if err != nil {
+				return nil, fmt.Errorf("party %d: %w", from, err)
+			}
+			delta.Add(intToScalar(alpha))
+			sigma.Add(intToScalar(mu))
+		}
+		defer sigma.Zero()
+
+		var bigGamma btcec.JacobianPoint
+		btcec.ScalarBaseMultNonConst(gamma, &bigGamma)
+		mine := &signDelta{delta: delta, gamma: toPublicKey(&bigGamma)}
+		if err := p.sendTo(ctx, session, roundSignDelta, signers, func(int) any { return mine }); err != nil {
+			return nil, err
+		}
+
+		// Round 4: δ = k·γ and Γ = γ·G give R = δ⁻¹·Γ = k⁻¹·G
+		totalDelta := mine.delta
+		mine.gamma.AsJacobian(&bigGamma)
+		for _, from := range signers {
+			if from == p.id {
+				continue
+			}
+			msg, err := p.mail.recv(ctx, session, roundSignDelta, from)
+			if err != nil {
+				return nil, err
+			}
+			theirs := msg.payload.(*signDelta)
+			totalDelta.Add(&theirs.delta)
+			var g, sum btcec.JacobianPoint
+			theirs.gamma.AsJacobian(&g)
+			btcec.AddNonConst(&bigGamma, &g, &sum)
+			bigGamma = sum
+		}
+		if totalDelta.IsZero() {
+			return nil, errors.New("δ is zero")
+		}
+		var bigR btcec.JacobianPoint
+		btcec.ScalarMultNonConst(totalDelta.InverseNonConst(), &bigGamma, &bigR)
+		R := toPublicKey(&bigR)
+		r := xScalar(R)
+
+		// s_i = m·k_i + r·σ_i
+		var m, s btcec.ModNScalar
+		m.SetByteSlice(hash)
+		s.Mul2(&m, k).Add(new(btcec.ModNScalar).Mul2(&r, &sigma))
+		return &signResult{r: R, s: s}, nil
+	}
+}
+
+// mtaRespond is the responder's side of MtA for a ciphertext c of a: it
+// returns Enc(a·b + β') and its own additive share -β' of a·b.
+func mtaRespond(pk *paillierPublicKey, c, b *big.Int) (*big.Int, *btcec.ModNScalar, error) {
+	betaPrime, err := rand.Int(rand.Reader, mtaMask)
+	if err != nil {
+		return nil, nil, err
+	}
+	masked, err := pk.encrypt(betaPrime)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp := pk.add(pk.mul(c, b), masked)
+	beta := intToScalar(betaPrime)
+	return resp, beta.Negate(), nil
+}
+
+// scalarToInt converts a scalar to a big integer in [0, q).
+func scalarToInt(s *btcec.ModNScalar) *big.Int {
+	b := s.Bytes()
+	defer clear(b[:])
+	return new(big.Int).SetBytes(b[:])
+}
+
+// intToScalar reduces a non-negative integer modulo q.
+func intToScalar(i *big.Int) *btcec.ModNScalar {
+	var b [32]byte
+	new(big.Int).Mod(i, curveOrder).FillBytes(b[:])
+	var s btcec.ModNScalar
+	s.SetBytes(&b)
+	clear(b[:])
+	return &s
+}
+
+// xScalar returns a point's x coordinate reduced modulo q, ECDSA's r.
+func xScalar(p *btcec.PublicKey) btcec.ModNScalar {
+	x := p.X().Bytes()
+	var b [32]byte
+	copy(b[32-len(x):], x)
+	var r btcec.ModNScalar
+	r.SetBytes(&b)
+	return r
+}
+
+// toPublicKey converts a point to affine coordinates.
+func toPublicKey(p *btcec.JacobianPoint) *btcec.PublicKey {
+	p.ToAffine()
+	return btcec.NewPublicKey(&p.X, &p.Y)
+}
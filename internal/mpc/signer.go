@@ -0,0 +1,314 @@
+// Package mpc simulates a threshold custody signer in process. Parties run as
+// goroutines that exchange protocol messages over channels; each holds one
+// share of keys created by distributed key generation, and any threshold of
+// them sign together without a key ever being assembled: with threshold
+// ECDSA for secp256k1 chains and FROST for Ed25519 (Solana).
+package mpc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sync"
+
+	"filippo.io/edwards25519"
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+
+	"andi-custodian/internal/shamir"
+	"andi-custodian/internal/wallet"
+	"andi-custodian/pkg/chains"
+)
+
+// minPaillierBits keeps MtA masks, up to q⁵, from wrapping the Paillier modulus.
+const minPaillierBits = 1536
+
+// ErrClosed is returned when using a signer after Close.
+var ErrClosed = errors.New("threshold signer is closed")
+
+// Option configures a ThresholdSigner.
+type Option func(*ThresholdSigner)
+
+// WithPaillierBits sets the Paillier modulus size of every party. The default
+// is 2048; smaller moduli speed up key generation in tests.
+func WithPaillierBits(bits int) Option {
+	return func(s *ThresholdSigner) {
+		s.paillierBits = bits
+	}
+}
+
+// ThresholdSigner coordinates t-of-n threshold signing across parties. The
+// coordinator only routes sessions and assembles signature shares; it holds
+// public values and no secret.
+type ThresholdSigner struct {
+	policy       wallet.ThresholdPolicy
+	paillierBits int
+	net          *network
+	mail         mailbox
+	parties      []*party
+
+	mu        sync.Mutex // one session at a time
+	session   uint64
+	publicKey *btcec.PublicKey
+	// FROST group commitments: the Ed25519 group key, then the coefficients
+	// that give each party's verification share
+	frostCommitments []*edwards25519.Point
+	closed           bool
+}
+
+// NewThresholdSigner starts policy.Total parties and runs distributed key
+// generation among them, once for the secp256k1 key and once for the Ed25519
+// key. Signing needs policy.Threshold of them.
+func NewThresholdSigner(ctx context.Context, policy wallet.ThresholdPolicy, opts ...Option) (*ThresholdSigner, error) {
+	if policy.Threshold < 1 || policy.Threshold > policy.Total || policy.Total > shamir.MaxShares {
+		return nil, errors.New("invalid threshold policy")
+	}
+	s := &ThresholdSigner{policy: policy, paillierBits: 2048}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.paillierBits < minPaillierBits {
+		return nil, fmt.Errorf("paillier modulus must be at least %d bits", minPaillierBits)
+	}
+
+	s.net = newNetwork(policy.Total)
+	s.mail = mailbox{inbox: s.net.inboxes[coordinatorID]}
+	for id := 1; id <= policy.Total; id++ {
+		p := &party{
+			id:           id,
+			parties:      policy.Total,
+			threshold:    policy.Threshold,
+			paillierBits: s.paillierBits,
+			net:          s.net,
+			mail:         mailbox{inbox: s.net.inboxes[id]},
+			commands:     make(chan command, 1),
+		}
+		s.parties = append(s.parties, p)
+		go p.run()
+	}
+
+	results, err := s.runSession(ctx, s.allParties(), dkg)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("distributed key generation failed: %w", err)
+	}
+	for id, res := range results {
+		pk := res.(*dkgResult).publicKey
+		if s.publicKey == nil {
+			s.publicKey = pk
+		} else if !s.publicKey.IsEqual(pk) {
+			s.Close()
+			return nil, fmt.Errorf("distributed key generation failed: party %d derived another group key", id)
+		}
+	}
+
+	results, err = s.runSession(ctx, s.allParties(), frostDKG)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("FROST key generation failed: %w", err)
+	}
+	for id, res := range results {
+		commitments := res.(*frostDKGResult).commitments
+		if s.frostCommitments == nil {
+			s.frostCommitments = commitments
+			continue
+		}
+		for i, c := range commitments {
+			if c.Equal(s.frostCommitments[i]) != 1 {
+				s.Close()
+				return nil, fmt.Errorf("FROST key generation failed: party %d derived other group commitments", id)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Close stops the parties. Their key shares are lost with them.
+func (s *ThresholdSigner) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for _, p := range s.parties {
+		close(p.commands)
+	}
+}
+
+func (s *ThresholdSigner) allParties() []int {
+	ids := make([]int, s.policy.Total)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	return ids
+}
+
+// runSession starts a protocol on the given parties and collects each one's
+// result. A failure or ctx ending aborts the whole session.
+func (s *ThresholdSigner) runSession(ctx context.Context, ids []int, run func(context.Context, *party, uint64) (any, error)) (map[int]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	s.session++
+	session := s.session
+	// Cancelling on return releases parties still waiting on a failed peer
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, id := range ids {
+		select {
+		case s.parties[id-1].commands <- command{ctx: ctx, session: session, run: run}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	results := make(map[int]any, len(ids))
+	for _, id := range ids {
+		msg, err := s.mail.recv(ctx, session, roundResult, id)
+		if err != nil {
+			return nil, err
+		}
+		if err, ok := msg.payload.(error); ok {
+			return nil, err
+		}
+		results[id] = msg.payload
+	}
+	return results, nil
+}
+
+// Sign signs with the first Threshold parties. It implements wallet.Signer:
+// [R || S || V] signatures on EVM chains, DER on Bitcoin, and Ed25519
+// signatures of the raw message on Solana. The group keys have no derivation
+// paths, so requests must not carry one.
+func (s *ThresholdSigner) Sign(ctx context.Context, req wallet.SignRequest) ([]byte, error) {
+	return s.SignWith(ctx, req, s.allParties()[:s.policy.Threshold])
+}
+
+// SignWith signs with the given parties, e.g. to leave an unavailable one out.
+// Exactly Threshold distinct parties must take part.
+func (s *ThresholdSigner) SignWith(ctx context.Context, req wallet.SignRequest, signers []int) ([]byte, error) {
+	if err := checkRequest(req.Chain, req.Path); err != nil {
+		return nil, err
+	}
+	if len(signers) != s.policy.Threshold {
+		return nil, fmt.Errorf("need %d signers, got %d", s.policy.Threshold, len(signers))
+	}
+	seen := make(map[int]bool, len(signers))
+	for _, id := range signers {
+		if id < 1 || id > s.policy.Total || seen[id] {
+			return nil, fmt.Errorf("invalid signer set %v", signers)
+		}
+		seen[id] = true
+	}
+	if req.Chain.Family() == chains.FamilySolana {
+		return s.signEd25519(ctx, req.Payload, signers)
+	}
+	if len(req.Payload) != 32 {
+		return nil, fmt.Errorf("payload must be a 32-byte hash, got %d bytes", len(req.Payload))
+	}
+
+	results, err := s.runSession(ctx, signers, sign(signers, req.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", wallet.ErrSigningFailed, err)
+	}
+
+	// Every party must agree on R; s is the sum of the shares
+	var R *btcec.PublicKey
+	var sum btcec.ModNScalar
+	for _, id := range signers {
+		res := results[id].(*signResult)
+		if R == nil {
+			R = res.r
+		} else if !R.IsEqual(res.r) {
+			return nil, fmt.Errorf("%w: parties disagree on R", wallet.ErrSigningFailed)
+		}
+		sum.Add(&res.s)
+	}
+	return s.finish(req, R, &sum)
+}
+
+// finish builds and verifies the signature (r, s) with nonce point R.
+func (s *ThresholdSigner) finish(req wallet.SignRequest, R *btcec.PublicKey, sum *btcec.ModNScalar) ([]byte, error) {
+	r := xScalar(R)
+	if r.IsZero() || sum.IsZero() {
+		return nil, fmt.Errorf("%w: degenerate signature", wallet.ErrSigningFailed)
+	}
+	// The recovery id is R's y parity, plus 2 when R.x overflowed the order
+	recID := byte(0)
+	if R.Y().Bit(0) == 1 {
+		recID = 1
+	}
+	if R.X().Cmp(curveOrder) >= 0 {
+		recID |= 2
+	}
+	// Low-S (BIP-146, EIP-2); negating s mirrors R
+	if sum.IsOverHalfOrder() {
+		sum.Negate()
+		recID ^= 1
+	}
+
+	sig := btcecdsa.NewSignature(&r, sum)
+	if !sig.Verify(req.Payload, s.publicKey) {
+		return nil, fmt.Errorf("%w: signature does not verify", wallet.ErrSigningFailed)
+	}
+	if req.Chain.Family() == chains.FamilyUTXO {
+		return sig.Serialize(), nil
+	}
+	out := make([]byte, 65)
+	rb, sb := r.Bytes(), sum.Bytes()
+	copy(out[:32], rb[:])
+	copy(out[32:64], sb[:])
+	out[64] = recID
+	return out, nil
+}
+
+// signEd25519 runs FROST among signers and aggregates their shares.
+func (s *ThresholdSigner) signEd25519(ctx context.Context, msg []byte, signers []int) ([]byte, error) {
+	results, err := s.runSession(ctx, signers, signFROST(signers, msg))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", wallet.ErrSigningFailed, err)
+	}
+	shares := make(map[int]*frostShare, len(results))
+	for id, res := range results {
+		shares[id] = res.(*frostShare)
+	}
+	sig, err := frostAggregate(s.frostCommitments, msg, shares)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", wallet.ErrSigningFailed, err)
+	}
+	return sig, nil
+}
+
+// PublicKey returns the group key: compressed SEC1 for secp256k1 chains, raw
+// 32 bytes for Solana.
+func (s *ThresholdSigner) PublicKey(ctx context.Context, chain wallet.Chain) ([]byte, error) {
+	return s.PublicKeyAt(ctx, chain, nil)
+}
+
+// PublicKeyAt returns the group key; path must be nil.
+func (s *ThresholdSigner) PublicKeyAt(ctx context.Context, chain wallet.Chain, path wallet.DerivationPath) ([]byte, error) {
+	if err := checkRequest(chain, path); err != nil {
+		return nil, err
+	}
+	if chain.Family() == chains.FamilySolana {
+		return ed25519.PublicKey(s.frostCommitments[0].Bytes()), nil
+	}
+	return s.publicKey.SerializeCompressed(), nil
+}
+
+// checkRequest accepts supported chains without a derivation path.
+func checkRequest(chain wallet.Chain, path wallet.DerivationPath) error {
+	switch chain.Family() {
+	case chains.FamilyEVM, chains.FamilyUTXO, chains.FamilySolana:
+	default:
+		return fmt.Errorf("threshold signer does not sign for %s", chain)
+	}
+	if path != nil {
+		return fmt.Errorf("%w: the threshold key has no derivation path %s", wallet.ErrInvalidPath, path)
+	}
+	return nil
+}
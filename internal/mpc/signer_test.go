@@ -0,0 +1,122 @@
+// mpc/signer_test.go
+package mpc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"andi-custodian/internal/wallet"
+)
+
+// newTestSigner runs DKG with the smallest Paillier keys allowed, to keep tests fast.
+func newTestSigner(t *testing.T) *ThresholdSigner {
+	t.Helper()
+	s, err := NewThresholdSigner(context.Background(), wallet.ThresholdPolicy{Threshold: 2, Total: 3}, WithPaillierBits(minPaillierBits))
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestThresholdSigner(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSigner(t)
+	var _ wallet.Signer = s
+
+	pubBytes, err := s.PublicKey(ctx, wallet.EthereumSepolia)
+	require.NoError(t, err)
+	pub, err := btcec.ParsePubKey(pubBytes)
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(*pub.ToECDSA()).Hex()
+	verifier := &wallet.Verifier{}
+
+	for _, signers := range [][]int{{1, 2}, {1, 3}, {3, 2}} {
+		hash := sha256.Sum256([]byte{byte(signers[0]), byte(signers[1])})
+
+		sig, err := s.SignWith(ctx, wallet.SignRequest{Chain: wallet.EthereumSepolia, Payload: hash[:]}, signers)
+		require.NoError(t, err, "signers %v", signers)
+		assert.True(t, verifier.VerifyEthereum(hash[:], sig, addr), "signers %v", signers)
+
+		der, err := s.SignWith(ctx, wallet.SignRequest{Chain: wallet.BitcoinTestnet, Payload: hash[:]}, signers)
+		require.NoError(t, err, "signers %v", signers)
+		assert.True(t, verifier.VerifyBitcoin(hash[:], der, pub), "signers %v", signers)
+	}
+
+	hash := sha256.Sum256([]byte("default signers"))
+	sig, err := s.Sign(ctx, wallet.SignRequest{Chain: wallet.EthereumSepolia, Payload: hash[:]})
+	require.NoError(t, err)
+	assert.True(t, verifier.VerifyEthereum(hash[:], sig, addr))
+}
+
+func TestThresholdSigner_Solana(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSigner(t)
+
+	pub, err := s.PublicKey(ctx, wallet.SolanaDevnet)
+	require.NoError(t, err)
+	require.Len(t, pub, ed25519.PublicKeySize)
+
+	// Solana signs the serialized message itself, of any length
+	msg := []byte("solana transaction message, not a hash")
+	for _, signers := range [][]int{{1, 2}, {1, 3}, {3, 2}} {
+		sig, err := s.SignWith(ctx, wallet.SignRequest{Chain: wallet.SolanaDevnet, Payload: msg}, signers)
+		require.NoError(t, err, "signers %v", signers)
+		assert.True(t, ed25519.Verify(pub, msg, sig), "signers %v", signers)
+	}
+
+	// Fresh nonces every session: the same message never reuses R
+	first, err := s.Sign(ctx, wallet.SignRequest{Chain: wallet.SolanaDevnet, Payload: msg})
+	require.NoError(t, err)
+	second, err := s.Sign(ctx, wallet.SignRequest{Chain: wallet.SolanaDevnet, Payload: msg})
+	require.NoError(t, err)
+	assert.NotEqual(t, first[:32], second[:32])
+}
+
+func TestThresholdSigner_Rejects(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSigner(t)
+	hash := sha256.Sum256([]byte("rejected"))
+	req := wallet.SignRequest{Chain: wallet.EthereumSepolia, Payload: hash[:]}
+
+	for _, signers := range [][]int{{1}, {1, 1}, {1, 4}, {0, 2}, {1, 2, 3}} {
+		_, err := s.SignWith(ctx, req, signers)
+		assert.Error(t, err, "signers %v", signers)
+	}
+
+	withPath := req
+	withPath.Path = wallet.DerivationPath{44 + wallet.Hardened, 60 + wallet.Hardened, wallet.Hardened, 0, 0}
+	_, err := s.Sign(ctx, withPath)
+	assert.True(t, errors.Is(err, wallet.ErrInvalidPath), "got %v", err)
+
+	_, err = s.Sign(ctx, wallet.SignRequest{Chain: wallet.Chain("dogecoin-mainnet"), Payload: hash[:]})
+	assert.Error(t, err)
+	_, err = s.Sign(ctx, wallet.SignRequest{Chain: wallet.EthereumSepolia, Payload: hash[:16]})
+	assert.Error(t, err)
+
+	// A cancelled session leaves the parties ready for the next one
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = s.Sign(cancelled, req)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.Sign(ctx, req)
+	assert.NoError(t, err)
+
+	s.Close()
+	_, err = s.Sign(ctx, req)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestNewThresholdSigner_Options(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewThresholdSigner(ctx, wallet.ThresholdPolicy{Threshold: 2, Total: 3}, WithPaillierBits(1024))
+	assert.Error(t, err)
+	_, err = NewThresholdSigner(ctx, wallet.ThresholdPolicy{Threshold: 4, Total: 3})
+	assert.Error(t, err)
+}
@@ -0,0 +1,207 @@
+// Package shamir implements k-of-n Shamir secret sharing of secp256k1
+// scalars with Feldman commitments, so every share can be checked against
+// public values before it is trusted.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// MaxShares is the most shares one split can have; indexes are 1..255.
+const MaxShares = 255
+
+var (
+	// ErrInvalidShare is returned for shares that do not match the commitments.
+	ErrInvalidShare = errors.New("invalid share")
+	// ErrTooFewShares is returned when combining fewer shares than the threshold.
+	ErrTooFewShares = errors.New("not enough shares")
+)
+
+// Share is the sharing polynomial evaluated at Index.
+type Share struct {
+	Index uint8
+	Value btcec.ModNScalar
+}
+
+// Commitments are Feldman commitments to the sharing polynomial: entry j is
+// a_j·G for coefficient a_j, so entry 0 is the public key of the secret. There
+// is one entry per degree of freedom, which makes the threshold len(c).
+type Commitments []*btcec.PublicKey
+
+// Split shares secret so that any threshold of the total shares recover it
+// and fewer reveal nothing about it. Randomness is read from rnd, or from
+// crypto/rand if rnd is nil.
+func Split(secret *btcec.ModNScalar, threshold, total int, rnd io.Reader) ([]Share, Commitments, error) {
+	if threshold < 1 || threshold > total || total > MaxShares {
+		return nil, nil, fmt.Errorf("invalid %d-of-%d threshold", threshold, total)
+	}
+	if secret.IsZero() {
+		return nil, nil, errors.New("cannot share a zero secret")
+	}
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+
+	// f(x) = secret + a_1·x + ... + a_{t-1}·x^{t-1}
+	coeffs := make([]btcec.ModNScalar, threshold)
+	defer func() {
+		for i := range coeffs {
+			coeffs[i].Zero()
+		}
+	}()
+	coeffs[0].Set(secret)
+	for i := 1; i < threshold; i++ {
+		if err := randomScalar(rnd, &coeffs[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	commitments := make(Commitments, threshold)
+	for i := range coeffs {
+		var p btcec.JacobianPoint
+		btcec.ScalarBaseMultNonConst(&coeffs[i], &p)
+		commitments[i] = toPublicKey(&p)
+	}
+	shares := make([]Share, total)
+	for i := range shares {
+		shares[i].Index = uint8(i + 1)
+		evaluate(coeffs, shares[i].Index, &shares[i].Value)
+	}
+	return shares, commitments, nil
+}
+
+// Verify reports whether share lies on the committed polynomial:
+// Value·G = Σ C_j·Index^j.
+func (c Commitments) Verify(share Share) bool {
+	if share.Index == 0 || len(c) == 0 {
+		return false
+	}
+	var want, got btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&share.Value, &want)
+
+	// Horner's rule over the points: ((C_{t-1}·x + C_{t-2})·x + ...)·x + C_0
+	var x btcec.ModNScalar
+	x.SetInt(uint32(share.Index))
+	for j := len(c) - 1; j >= 0; j-- {
+		var cj, scaled btcec.JacobianPoint
+		c[j].AsJacobian(&cj)
+		if j < len(c)-1 {
+			btcec.ScalarMultNonConst(&x, &got, &scaled)
+		}
+		btcec.AddNonConst(&scaled, &cj, &got)
+	}
+	want.ToAffine()
+	got.ToAffine()
+	return want.X.Equals(&got.X) && want.Y.Equals(&got.Y)
+}
+
+// Equal reports whether two sets of commitments are to the same polynomial.
+func (c Commitments) Equal(other Commitments) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	for i := range c {
+		if !c[i].IsEqual(other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Combine verifies shares against the commitments and interpolates the secret
+// from the first threshold of them. Duplicate indexes are rejected.
+func Combine(shares []Share, c Commitments) (*btcec.ModNScalar, error) {
+	threshold := len(c)
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("%w: have %d, need %d", ErrTooFewShares, len(shares), threshold)
+	}
+	seen := make(map[uint8]bool, len(shares))
+	for _, s := range shares {
+		if seen[s.Index] {
+			return nil, fmt.Errorf("%w: index %d appears twice", ErrInvalidShare, s.Index)
+		}
+		seen[s.Index] = true
+		if !c.Verify(s) {
+			return nil, fmt.Errorf("%w: share %d does not match the commitments", ErrInvalidShare, s.Index)
+		}
+	}
+
+	used := shares[:threshold]
+	indexes := make([]uint8, threshold)
+	for i, s := range used {
+		indexes[i] = s.Index
+	}
+	var secret btcec.ModNScalar
+	for _, s := range used {
+		lambda := Lagrange(s.Index, indexes)
+		lambda.Mul(&s.Value)
+		secret.Add(lambda)
+	}
+	return &secret, nil
+}
+
+// Lagrange returns the coefficient of share index in the interpolation of
+// f(0) from the shares at indexes: Π x_j / (x_j - x_i) over j ≠ i.
+func Lagrange(index uint8, indexes []uint8) *btcec.ModNScalar {
+	var num, den btcec.ModNScalar
+	num.SetInt(1)
+	den.SetInt(1)
+	var xi btcec.ModNScalar
+	xi.SetInt(uint32(index))
+	for _, j := range indexes {
+		if j == index {
+			continue
+		}
+		var xj, diff btcec.ModNScalar
+		xj.SetInt(uint32(j))
+		num.Mul(&xj)
+		diff.NegateVal(&xi).Add(&xj)
+		den.Mul(&diff)
+	}
+	return num.Mul(den.InverseNonConst())
+}
+
+// evaluate sets out to f(x) by Horner's rule.
+func evaluate(coeffs []btcec.ModNScalar, x uint8, out *btcec.ModNScalar) {
+	var xs btcec.ModNScalar
+	xs.SetInt(uint32(x))
+	out.Zero()
+	for j := len(coeffs) - 1; j >= 0; j-- {
+		out.Mul(&xs).Add(&coeffs[j])
+	}
+}
+
+// randomScalar sets s to a uniformly random non-zero scalar.
+func randomScalar(rnd io.Reader, s *btcec.ModNScalar) error {
+	var b [32]byte
+	defer clear(b[:])
+	for {
+		if _, err := io.ReadFull(rnd, b[:]); err != nil {
+			return err
+		}
+		// Rejecting values ≥ n keeps the distribution uniform
+		if overflow := s.SetBytes(&b); overflow == 0 && !s.IsZero() {
+			return nil
+		}
+	}
+}
+
+// RandomScalar returns a uniformly random non-zero scalar from crypto/rand.
+func RandomScalar() (*btcec.ModNScalar, error) {
+	var s btcec.ModNScalar
+	if err := randomScalar(rand.Reader, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// toPublicKey converts a point to affine coordinates.
+func toPublicKey(p *btcec.JacobianPoint) *btcec.PublicKey {
+	p.ToAffine()
+	return btcec.NewPublicKey(&p.X, &p.Y)
+}
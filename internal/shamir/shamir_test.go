@@ -0,0 +1,105 @@
+// shamir/shamir_test.go
+package shamir
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret, err := RandomScalar()
+	require.NoError(t, err)
+	shares, commitments, err := Split(secret, 3, 5, nil)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+	require.Len(t, commitments, 3)
+
+	// The constant commitment is the secret's public key
+	assert.True(t, commitments[0].IsEqual(btcec.PrivKeyFromScalar(secret).PubKey()))
+	for _, s := range shares {
+		assert.True(t, commitments.Verify(s), "share %d", s.Index)
+	}
+
+	// Every 3-subset recovers the secret
+	for a := 0; a < 5; a++ {
+		for b := a + 1; b < 5; b++ {
+			for c := b + 1; c < 5; c++ {
+				got, err := Combine([]Share{shares[c], shares[a], shares[b]}, commitments)
+				require.NoError(t, err)
+				assert.True(t, got.Equals(secret), "shares %d,%d,%d", a+1, b+1, c+1)
+			}
+		}
+	}
+	// Extra shares are verified and do no harm
+	got, err := Combine(shares, commitments)
+	require.NoError(t, err)
+	assert.True(t, got.Equals(secret))
+}
+
+func TestCombine_TooFew(t *testing.T) {
+	secret, _ := RandomScalar()
+	shares, commitments, err := Split(secret, 3, 5, nil)
+	require.NoError(t, err)
+	_, err = Combine(shares[:2], commitments)
+	assert.ErrorIs(t, err, ErrTooFewShares)
+}
+
+func TestCombine_Tampered(t *testing.T) {
+	secret, _ := RandomScalar()
+	shares, commitments, err := Split(secret, 2, 3, nil)
+	require.NoError(t, err)
+
+	tampered := shares[1]
+	var one btcec.ModNScalar
+	one.SetInt(1)
+	tampered.Value.Add(&one)
+	assert.False(t, commitments.Verify(tampered))
+	_, err = Combine([]Share{shares[0], tampered}, commitments)
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	// A valid value under another index
+	moved := shares[1]
+	moved.Index = 3
+	assert.False(t, commitments.Verify(moved))
+
+	_, err = Combine([]Share{shares[0], shares[0]}, commitments)
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	// Shares of another split do not verify
+	other, _, err := Split(secret, 2, 3, nil)
+	require.NoError(t, err)
+	assert.False(t, commitments.Verify(other[0]))
+}
+
+func TestSplit_Invalid(t *testing.T) {
+	secret, _ := RandomScalar()
+	for _, tt := range [][2]int{{0, 3}, {4, 3}, {2, 256}} {
+		_, _, err := Split(secret, tt[0], tt[1], nil)
+		assert.Error(t, err, "%d-of-%d", tt[0], tt[1])
+	}
+	_, _, err := Split(new(btcec.ModNScalar), 2, 3, nil)
+	assert.Error(t, err)
+
+	// 1-of-n hands every holder the secret
+	shares, commitments, err := Split(secret, 1, 2, nil)
+	require.NoError(t, err)
+	assert.True(t, shares[1].Value.Equals(secret))
+	got, err := Combine(shares[1:], commitments)
+	require.NoError(t, err)
+	assert.True(t, got.Equals(secret))
+}
+
+func TestLagrange(t *testing.T) {
+	// Interpolating f(x) = 7 + 3x at x = 1, 2 gives f(0)
+	var f1, f2, want btcec.ModNScalar
+	f1.SetInt(10)
+	f2.SetInt(13)
+	want.SetInt(7)
+	l1 := Lagrange(1, []uint8{1, 2})
+	l2 := Lagrange(2, []uint8{1, 2})
+	got := l1.Mul(&f1).Add(l2.Mul(&f2))
+	assert.True(t, got.Equals(&want))
+}
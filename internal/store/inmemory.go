@@ -3,21 +3,58 @@ package store
 import (
 	"andi-custodian/internal/chain"
 	"context"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
+var _ Store = (*InMemoryStore)(nil)
+
 type InMemoryStore struct {
-	mu        sync.RWMutex
-	transfers map[string]*TransferResult
-	nonces    map[string]uint64
-	utxos     map[string][]chain.UTXO
+	mu           sync.RWMutex
+	transfers    map[string]*TransferResult
+	transitions  map[string][]Transition
+	nonces       map[string]uint64
+	utxos        map[string][]chain.UTXO
+	reservations map[chain.OutPoint]reservation
+	spent        map[chain.OutPoint]string // committed outputs, by reservation
+	nextIndex    map[accountKey]uint32
+	deposits     map[depositKey]*DepositAddress
+	byCustomer   map[string][]depositKey // in issue order
+	now          func() time.Time
+}
+
+// accountKey identifies an HD account on a chain.
+type accountKey struct {
+	chain   string
+	account uint32
+}
+
+// depositKey identifies an issued address.
+type depositKey struct {
+	chain   string
+	address string
+}
+
+// reservation is a lease on one output.
+type reservation struct {
+	id      string
+	expires time.Time
 }
 
 func NewInMemoryStore() *InMemoryStore {
 	return &InMemoryStore{
-		transfers: make(map[string]*TransferResult),
-		nonces:    make(map[string]uint64),
-		utxos:     make(map[string][]chain.UTXO),
+		transfers:    make(map[string]*TransferResult),
+		transitions:  make(map[string][]Transition),
+		nonces:       make(map[string]uint64),
+		utxos:        make(map[string][]chain.UTXO),
+		reservations: make(map[chain.OutPoint]reservation),
+		spent:        make(map[chain.OutPoint]string),
+		nextIndex:    make(map[accountKey]uint32),
+		deposits:     make(map[depositKey]*DepositAddress),
+		byCustomer:   make(map[string][]depositKey),
+		now:          time.Now,
 	}
 }
 
@@ -25,7 +62,8 @@ func (s *InMemoryStore) GetTransferResult(ctx context.Context, id string) (*Tran
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if res, ok := s.transfers[id]; ok {
-		return res, nil
+		cp := *res
+		return &cp, nil
 	}
 	return nil, nil // or return a sentinel error
 }
@@ -33,10 +71,84 @@ func (s *InMemoryStore) GetTransferResult(ctx context.Context, id string) (*Tran
 func (s *InMemoryStore) SaveTransferResult(ctx context.Context, id string, result *TransferResult) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.transfers[id] = result
+	cp := *result
+	s.transfers[id] = &cp
+	return nil
+}
+
+func (s *InMemoryStore) ClaimTransfer(ctx context.Context, id string, claim *TransferResult) (*TransferResult, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if res, ok := s.transfers[id]; ok {
+		cp := *res
+		return &cp, false, nil
+	}
+	cp := *claim
+	s.transfers[id] = &cp
+	s.transitions[id] = []Transition{{To: claim.Status, Timestamp: claim.Timestamp}}
+	return claim, true, nil
+}
+
+func (s *InMemoryStore) TakeOverTransfer(ctx context.Context, id string, stale, claim *TransferResult) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, ok := s.transfers[id]
+	if !ok {
+		return false, fmt.Errorf("%s: %w", id, ErrTransferNotFound)
+	}
+	if res.Status != stale.Status || !res.ClaimedUntil.Equal(stale.ClaimedUntil) {
+		return false, nil
+	}
+	cp := *claim
+	s.transfers[id] = &cp
+	s.transitions[id] = append(s.transitions[id], Transition{
+		From: stale.Status, To: claim.Status, Reason: takeOverReason, Timestamp: claim.Timestamp,
+	})
+	return true, nil
+}
+
+func (s *InMemoryStore) RecordTransition(ctx context.Context, id string, tr Transition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, ok := s.transfers[id]
+	if !ok {
+		return fmt.Errorf("%s: %w", id, ErrTransferNotFound)
+	}
+	if res.Status != tr.From {
+		return fmt.Errorf("%s is %s, not %s: %w", id, res.Status, tr.From, ErrStaleTransition)
+	}
+	cp := *res
+	cp.Status = tr.To
+	s.transfers[id] = &cp
+	s.transitions[id] = append(s.transitions[id], tr)
 	return nil
 }
 
+func (s *InMemoryStore) GetTransitions(ctx context.Context, id string) ([]Transition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history := make([]Transition, len(s.transitions[id]))
+	copy(history, s.transitions[id])
+	return history, nil
+}
+
+func (s *InMemoryStore) ListTransfers(ctx context.Context, statuses ...string) ([]*TransferResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []*TransferResult
+	for _, res := range s.transfers {
+		for _, status := range statuses {
+			if res.Status == status {
+				cp := *res
+				result = append(result, &cp)
+				break
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+	return result, nil
+}
+
 func (s *InMemoryStore) GetNonce(ctx context.Context, address string) (uint64, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -53,21 +165,155 @@ func (s *InMemoryStore) SetNonce(ctx context.Context, address string, nonce uint
 func (s *InMemoryStore) GetUTXOs(ctx context.Context, address string) ([]chain.UTXO, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	utxos, ok := s.utxos[address]
-	if !ok {
-		return nil, nil
-	}
 	// Return a copy to prevent mutation
-	result := make([]chain.UTXO, len(utxos))
-	copy(result, utxos)
+	var result []chain.UTXO
+	for _, u := range s.utxos[address] {
+		if _, spent := s.spent[u.OutPoint()]; !spent {
+			result = append(result, u)
+		}
+	}
 	return result, nil
 }
 
 func (s *InMemoryStore) SaveUTXOs(ctx context.Context, address string, utxos []chain.UTXO) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	// A committed output stays spent while the node still lists it, i.e. until
+	// the spending transaction confirms, and is forgotten once it does not
+	keep := make(map[chain.OutPoint]bool, len(utxos))
+	for _, u := range utxos {
+		keep[u.OutPoint()] = true
+	}
+	for _, u := range s.utxos[address] {
+		if !keep[u.OutPoint()] {
+			delete(s.spent, u.OutPoint())
+		}
+	}
 	// Store a copy
 	s.utxos[address] = make([]chain.UTXO, len(utxos))
 	copy(s.utxos[address], utxos)
 	return nil
 }
+
+func (s *InMemoryStore) GetSpendableUTXOs(ctx context.Context, address string) ([]chain.UTXO, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.now()
+	var result []chain.UTXO
+	for _, u := range s.utxos[address] {
+		if _, spent := s.spent[u.OutPoint()]; spent {
+			continue
+		}
+		if r, ok := s.reservations[u.OutPoint()]; ok && now.Before(r.expires) {
+			continue
+		}
+		result = append(result, u)
+	}
+	return result, nil
+}
+
+func (s *InMemoryStore) ReserveUTXOs(ctx context.Context, reservationID string, outpoints []chain.OutPoint, lease time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+
+	// Check everything first so a conflict reserves nothing
+	for _, op := range outpoints {
+		if !s.hasUTXO(op) {
+			return fmt.Errorf("%s: %w", op, ErrUTXONotFound)
+		}
+		if r, ok := s.reservations[op]; ok && r.id != reservationID && now.Before(r.expires) {
+			return fmt.Errorf("%s: %w", op, ErrUTXOReserved)
+		}
+	}
+	for _, op := range outpoints {
+		s.reservations[op] = reservation{id: reservationID, expires: now.Add(lease)}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) ReleaseUTXOs(ctx context.Context, reservationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for op, r := range s.reservations {
+		if r.id == reservationID {
+			delete(s.reservations, op)
+		}
+	}
+	for op, id := range s.spent {
+		if id == reservationID {
+			delete(s.spent, op)
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) CommitUTXOs(ctx context.Context, reservationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for op, r := range s.reservations {
+		if r.id == reservationID {
+			s.spent[op] = reservationID
+			delete(s.reservations, op)
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) NextAddressIndex(ctx context.Context, chain string, account uint32) (uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := accountKey{chain, account}
+	idx := s.nextIndex[key]
+	s.nextIndex[key] = idx + 1
+	return idx, nil
+}
+
+func (s *InMemoryStore) SaveDepositAddress(ctx context.Context, addr *DepositAddress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := depositKey{addr.Chain, addr.Address}
+	if _, ok := s.deposits[key]; ok {
+		return fmt.Errorf("%s on %s: %w", addr.Address, addr.Chain, ErrAddressExists)
+	}
+	cp := *addr
+	s.deposits[key] = &cp
+	s.byCustomer[addr.CustomerID] = append(s.byCustomer[addr.CustomerID], key)
+	return nil
+}
+
+func (s *InMemoryStore) GetDepositAddress(ctx context.Context, chain, address string) (*DepositAddress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if addr, ok := s.deposits[depositKey{chain, address}]; ok {
+		cp := *addr
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (s *InMemoryStore) ListDepositAddresses(ctx context.Context, customerID string) ([]*DepositAddress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []*DepositAddress
+	for _, key := range s.byCustomer[customerID] {
+		cp := *s.deposits[key]
+		result = append(result, &cp)
+	}
+	return result, nil
+}
+
+// hasUTXO reports whether any address holds the output unspent. Callers hold s.mu.
+func (s *InMemoryStore) hasUTXO(op chain.OutPoint) bool {
+	if _, spent := s.spent[op]; spent {
+		return false
+	}
+	for _, utxos := range s.utxos {
+		for _, u := range utxos {
+			if u.OutPoint() == op {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -101,3 +101,323 @@ func TestInMemoryStore_Concurrent(t *testing.T) {
 	_, err := store.GetNonce(ctx, addr)
 	assert.NoError(t, err)
 }
+
+func TestInMemoryStore_ReserveUTXOs(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	addr := "tb1q..."
+	utxos := []chain.UTXO{
+		{TxID: "tx1", VOut: 0, Value: 1000000},
+		{TxID: "tx2", VOut: 1, Value: 2000000},
+	}
+	assert.NoError(t, store.SaveUTXOs(ctx, addr, utxos))
+
+	// Reserve the first output
+	err := store.ReserveUTXOs(ctx, "r1", []chain.OutPoint{utxos[0].OutPoint()}, time.Minute)
+	assert.NoError(t, err)
+
+	spendable, err := store.GetSpendableUTXOs(ctx, addr)
+	assert.NoError(t, err)
+	assert.Equal(t, []chain.UTXO{utxos[1]}, spendable)
+
+	// A conflicting reservation takes nothing, not even the free output
+	err = store.ReserveUTXOs(ctx, "r2", []chain.OutPoint{utxos[1].OutPoint(), utxos[0].OutPoint()}, time.Minute)
+	assert.ErrorIs(t, err, ErrUTXOReserved)
+	spendable, _ = store.GetSpendableUTXOs(ctx, addr)
+	assert.Len(t, spendable, 1)
+
+	// Unknown outputs cannot be reserved
+	err = store.ReserveUTXOs(ctx, "r2", []chain.OutPoint{{TxID: "nope", VOut: 0}}, time.Minute)
+	assert.ErrorIs(t, err, ErrUTXONotFound)
+
+	// Release makes it spendable again
+	assert.NoError(t, store.ReleaseUTXOs(ctx, "r1"))
+	spendable, _ = store.GetSpendableUTXOs(ctx, addr)
+	assert.Len(t, spendable, 2)
+}
+
+func TestInMemoryStore_ReserveUTXOs_LeaseExpires(t *testing.T) {
+	store := NewInMemoryStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	addr := "tb1q..."
+	utxo := chain.UTXO{TxID: "tx1", VOut: 0, Value: 1000000}
+	assert.NoError(t, store.SaveUTXOs(ctx, addr, []chain.UTXO{utxo}))
+
+	assert.NoError(t, store.ReserveUTXOs(ctx, "r1", []chain.OutPoint{utxo.OutPoint()}, time.Minute))
+	assert.ErrorIs(t, store.ReserveUTXOs(ctx, "r2", []chain.OutPoint{utxo.OutPoint()}, time.Minute), ErrUTXOReserved)
+
+	// Once the lease runs out another reservation may take the output
+	now = now.Add(time.Minute)
+	spendable, _ := store.GetSpendableUTXOs(ctx, addr)
+	assert.Len(t, spendable, 1)
+	assert.NoError(t, store.ReserveUTXOs(ctx, "r2", []chain.OutPoint{utxo.OutPoint()}, time.Minute))
+}
+
+func TestInMemoryStore_CommitUTXOs(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	addr := "tb1q..."
+	utxos := []chain.UTXO{
+		{TxID: "tx1", VOut: 0, Value: 1000000},
+		{TxID: "tx2", VOut: 1, Value: 2000000},
+	}
+	assert.NoError(t, store.SaveUTXOs(ctx, addr, utxos))
+	assert.NoError(t, store.ReserveUTXOs(ctx, "r1", []chain.OutPoint{utxos[1].OutPoint()}, time.Minute))
+
+	assert.NoError(t, store.CommitUTXOs(ctx, "r1"))
+
+	remaining, err := store.GetUTXOs(ctx, addr)
+	assert.NoError(t, err)
+	assert.Equal(t, []chain.UTXO{utxos[0]}, remaining)
+}
+
+func TestInMemoryStore_CommitUTXOs_Resync(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	addr := "tb1q..."
+	utxos := []chain.UTXO{
+		{TxID: "tx1", VOut: 0, Value: 1000000, PkScript: []byte{0x00, 0x14}},
+		{TxID: "tx2", VOut: 1, Value: 2000000, PkScript: []byte{0x00, 0x14}},
+	}
+	assert.NoError(t, store.SaveUTXOs(ctx, addr, utxos))
+	assert.NoError(t, store.ReserveUTXOs(ctx, "r1", []chain.OutPoint{utxos[1].OutPoint()}, time.Minute))
+	assert.NoError(t, store.CommitUTXOs(ctx, "r1"))
+
+	// The node lists the output until the spending transaction confirms
+	assert.NoError(t, store.SaveUTXOs(ctx, addr, utxos))
+	spendable, err := store.GetSpendableUTXOs(ctx, addr)
+	assert.NoError(t, err)
+	assert.Equal(t, []chain.UTXO{utxos[0]}, spendable)
+	assert.ErrorIs(t, store.ReserveUTXOs(ctx, "r2", []chain.OutPoint{utxos[1].OutPoint()}, time.Minute), ErrUTXONotFound)
+
+	// A rejected transaction gives its inputs back
+	assert.NoError(t, store.ReleaseUTXOs(ctx, "r1"))
+	spendable, _ = store.GetSpendableUTXOs(ctx, addr)
+	assert.Equal(t, utxos, spendable)
+
+	// Once confirmed, the node stops listing it and the mark goes with it
+	assert.NoError(t, store.ReserveUTXOs(ctx, "r3", []chain.OutPoint{utxos[1].OutPoint()}, time.Minute))
+	assert.NoError(t, store.CommitUTXOs(ctx, "r3"))
+	assert.NoError(t, store.SaveUTXOs(ctx, addr, utxos[:1]))
+	assert.Empty(t, store.spent)
+	remaining, _ := store.GetUTXOs(ctx, addr)
+	assert.Equal(t, utxos[:1], remaining)
+}
+
+func TestInMemoryStore_ReserveUTXOs_Concurrent(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	utxo := chain.UTXO{TxID: "tx1", VOut: 0, Value: 1000000}
+	assert.NoError(t, store.SaveUTXOs(ctx, "tb1q...", []chain.UTXO{utxo}))
+
+	const workers = 10
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		won int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := string(rune('a' + i))
+			if store.ReserveUTXOs(ctx, id, []chain.OutPoint{utxo.OutPoint()}, time.Minute) == nil {
+				mu.Lock()
+				won++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, won)
+}
+
+func TestInMemoryStore_ClaimTransfer(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	id := "claim-1"
+
+	first := &TransferResult{Status: "processing", Fingerprint: "a"}
+	got, claimed, err := store.ClaimTransfer(ctx, id, first)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, first, got)
+
+	// A second claim returns the first one untouched
+	got, claimed, err = store.ClaimTransfer(ctx, id, &TransferResult{Status: "processing", Fingerprint: "b"})
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, "a", got.Fingerprint)
+}
+
+func TestInMemoryStore_TakeOverTransfer(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	id := "takeover-1"
+	start := time.Now()
+
+	stale := &TransferResult{ID: id, Status: "requested", Timestamp: start, ClaimedUntil: start.Add(time.Minute)}
+	_, _, err := store.ClaimTransfer(ctx, id, stale)
+	assert.NoError(t, err)
+	assert.NoError(t, store.RecordTransition(ctx, id, Transition{From: "requested", To: "built"}))
+
+	// The claimer moved on since it was read
+	claim := &TransferResult{ID: id, Status: "requested", Timestamp: start.Add(2 * time.Minute), ClaimedUntil: start.Add(3 * time.Minute)}
+	took, err := store.TakeOverTransfer(ctx, id, stale, claim)
+	assert.NoError(t, err)
+	assert.False(t, took)
+
+	current, _ := store.GetTransferResult(ctx, id)
+	took, err = store.TakeOverTransfer(ctx, id, current, claim)
+	assert.NoError(t, err)
+	assert.True(t, took)
+	// and only one caller takes it over
+	took, err = store.TakeOverTransfer(ctx, id, current, claim)
+	assert.NoError(t, err)
+	assert.False(t, took)
+
+	got, _ := store.GetTransferResult(ctx, id)
+	assert.Equal(t, claim.ClaimedUntil, got.ClaimedUntil)
+	history, _ := store.GetTransitions(ctx, id)
+	assert.Equal(t, Transition{From: "built", To: "requested", Reason: takeOverReason, Timestamp: claim.Timestamp}, history[len(history)-1])
+
+	_, err = store.TakeOverTransfer(ctx, "missing", current, claim)
+	assert.ErrorIs(t, err, ErrTransferNotFound)
+}
+
+func TestInMemoryStore_RecordTransition(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	id := "transition-1"
+	start := time.Now()
+
+	_, _, err := store.ClaimTransfer(ctx, id, &TransferResult{Status: "requested", Timestamp: start})
+	assert.NoError(t, err)
+
+	err = store.RecordTransition(ctx, id, Transition{From: "requested", To: "built", Reason: "ok", Timestamp: start})
+	assert.NoError(t, err)
+
+	// The status moved on, so a transition from the old status is stale
+	err = store.RecordTransition(ctx, id, Transition{From: "requested", To: "failed", Timestamp: start})
+	assert.ErrorIs(t, err, ErrStaleTransition)
+
+	err = store.RecordTransition(ctx, "unknown", Transition{From: "requested", To: "built"})
+	assert.ErrorIs(t, err, ErrTransferNotFound)
+
+	res, _ := store.GetTransferResult(ctx, id)
+	assert.Equal(t, "built", res.Status)
+
+	history, err := store.GetTransitions(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, []Transition{
+		{To: "requested", Timestamp: start},
+		{From: "requested", To: "built", Reason: "ok", Timestamp: start},
+	}, history)
+}
+
+func TestInMemoryStore_ClaimTransfer_Concurrent(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	const workers = 10
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		won int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, claimed, err := store.ClaimTransfer(ctx, "race", &TransferResult{Status: "processing"})
+			assert.NoError(t, err)
+			if claimed {
+				mu.Lock()
+				won++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, won)
+}
+
+func TestInMemoryStore_ListTransfers(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	start := time.Now()
+
+	for i, status := range []string{"broadcast", "finalized", "confirmed", "failed"} {
+		id := status + "-1"
+		_, _, err := store.ClaimTransfer(ctx, id, &TransferResult{ID: id, Status: status, Timestamp: start.Add(time.Duration(i) * time.Second)})
+		assert.NoError(t, err)
+	}
+
+	pending, err := store.ListTransfers(ctx, "broadcast", "confirmed")
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 2) {
+		assert.Equal(t, "broadcast-1", pending[0].ID)
+		assert.Equal(t, "confirmed-1", pending[1].ID)
+	}
+
+	none, err := store.ListTransfers(ctx, "replaced")
+	assert.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestInMemoryStore_NextAddressIndex_Concurrent(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	const workers = 20
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = make(map[uint32]bool)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			idx, err := store.NextAddressIndex(ctx, "bitcoin-testnet", 0)
+			assert.NoError(t, err)
+			mu.Lock()
+			seen[idx] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, workers)
+	assert.True(t, seen[0] && seen[workers-1])
+
+	// Accounts and chains count separately
+	idx, _ := store.NextAddressIndex(ctx, "bitcoin-testnet", 1)
+	assert.Equal(t, uint32(0), idx)
+	idx, _ = store.NextAddressIndex(ctx, "ethereum-sepolia", 0)
+	assert.Equal(t, uint32(0), idx)
+}
+
+func TestInMemoryStore_DepositAddresses(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+
+	first := &DepositAddress{Chain: "bitcoin-testnet", Address: "tb1q-a", CustomerID: "alice", Path: "m/84'/1'/0'/0/0", CreatedAt: time.Now()}
+	second := &DepositAddress{Chain: "ethereum-sepolia", Address: "0xA", CustomerID: "alice", Path: "m/44'/60'/0'/0/0", CreatedAt: time.Now()}
+	assert.NoError(t, store.SaveDepositAddress(ctx, first))
+	assert.NoError(t, store.SaveDepositAddress(ctx, second))
+	assert.NoError(t, store.SaveDepositAddress(ctx, &DepositAddress{Chain: "bitcoin-testnet", Address: "tb1q-b", CustomerID: "bob"}))
+	assert.ErrorIs(t, store.SaveDepositAddress(ctx, &DepositAddress{Chain: "bitcoin-testnet", Address: "tb1q-a", CustomerID: "bob"}), ErrAddressExists)
+
+	got, err := store.GetDepositAddress(ctx, "bitcoin-testnet", "tb1q-a")
+	assert.NoError(t, err)
+	assert.Equal(t, first, got)
+	got, err = store.GetDepositAddress(ctx, "ethereum-sepolia", "tb1q-a")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	alice, err := store.ListDepositAddresses(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []*DepositAddress{first, second}, alice)
+}
@@ -6,11 +6,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"andi-custodian/internal/chain"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+var _ Store = (*PostgresStore)(nil)
+
 // PostgresStore implements Store using PostgreSQL.
 type PostgresStore struct {
 	db *sql.DB
@@ -77,6 +80,178 @@ func (p *PostgresStore) SaveTransferResult(ctx context.Context, id string, resul
 	return err
 }
 
+func (p *PostgresStore) ClaimTransfer(ctx context.Context, id string, claim *TransferResult) (*TransferResult, bool, error) {
+	data, err := json.Marshal(claim)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal transfer result: %w", err)
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// The primary key makes the insert the arbiter between racing replicas
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO transfers (id, data, created_at) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING",
+		id, data, claim.Timestamp)
+	if err != nil {
+		return nil, false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+	if n == 1 {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO transfer_transitions (transfer_id, from_state, to_state, created_at) VALUES ($1, '', $2, $3)",
+			id, claim.Status, claim.Timestamp); err != nil {
+			return nil, false, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, false, err
+		}
+		return claim, true, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	existing, err := p.GetTransferResult(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		return nil, false, fmt.Errorf("transfer %s vanished while claiming", id)
+	}
+	return existing, false, nil
+}
+
+func (p *PostgresStore) TakeOverTransfer(ctx context.Context, id string, stale, claim *TransferResult) (bool, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// The row lock serializes callers taking over the same claim
+	var data []byte
+	err = tx.QueryRowContext(ctx, "SELECT data FROM transfers WHERE id = $1 FOR UPDATE", id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("%s: %w", id, ErrTransferNotFound)
+	}
+	if err != nil {
+		return false, err
+	}
+	var current TransferResult
+	if err := json.Unmarshal(data, &current); err != nil {
+		return false, fmt.Errorf("failed to unmarshal transfer result: %w", err)
+	}
+	if current.Status != stale.Status || !current.ClaimedUntil.Equal(stale.ClaimedUntil) {
+		return false, nil
+	}
+
+	if data, err = json.Marshal(claim); err != nil {
+		return false, fmt.Errorf("failed to marshal transfer result: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE transfers SET data = $2 WHERE id = $1", id, data); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO transfer_transitions (transfer_id, from_state, to_state, reason, created_at) VALUES ($1, $2, $3, $4, $5)",
+		id, stale.Status, claim.Status, takeOverReason, claim.Timestamp); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (p *PostgresStore) RecordTransition(ctx context.Context, id string, tr Transition) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var data []byte
+	err = tx.QueryRowContext(ctx, "SELECT data FROM transfers WHERE id = $1 FOR UPDATE", id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%s: %w", id, ErrTransferNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	var result TransferResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to unmarshal transfer result: %w", err)
+	}
+	if result.Status != tr.From {
+		return fmt.Errorf("%s is %s, not %s: %w", id, result.Status, tr.From, ErrStaleTransition)
+	}
+
+	result.Status = tr.To
+	if data, err = json.Marshal(&result); err != nil {
+		return fmt.Errorf("failed to marshal transfer result: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE transfers SET data = $2 WHERE id = $1", id, data); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO transfer_transitions (transfer_id, from_state, to_state, reason, created_at) VALUES ($1, $2, $3, $4, $5)",
+		id, tr.From, tr.To, tr.Reason, tr.Timestamp); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *PostgresStore) GetTransitions(ctx context.Context, id string) ([]Transition, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT from_state, to_state, reason, created_at FROM transfer_transitions WHERE transfer_id = $1 ORDER BY seq",
+		id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []Transition
+	for rows.Next() {
+		var tr Transition
+		if err := rows.Scan(&tr.From, &tr.To, &tr.Reason, &tr.Timestamp); err != nil {
+			return nil, err
+		}
+		history = append(history, tr)
+	}
+	return history, rows.Err()
+}
+
+func (p *PostgresStore) ListTransfers(ctx context.Context, statuses ...string) ([]*TransferResult, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT data, created_at FROM transfers WHERE data->>'status' = ANY($1) ORDER BY created_at",
+		pq.Array(statuses))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*TransferResult
+	for rows.Next() {
+		var data []byte
+		var timestamp sql.NullTime
+		if err := rows.Scan(&data, &timestamp); err != nil {
+			return nil, err
+		}
+		var res TransferResult
+		if err := json.Unmarshal(data, &res); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal transfer result: %w", err)
+		}
+		if timestamp.Valid {
+			res.Timestamp = timestamp.Time
+		}
+		result = append(result, &res)
+	}
+	return result, rows.Err()
+}
+
 // Nonce methods
 
 func (p *PostgresStore) GetNonce(ctx context.Context, address string) (uint64, error) {
@@ -104,7 +279,7 @@ func (p *PostgresStore) SetNonce(ctx context.Context, address string, nonce uint
 
 func (p *PostgresStore) GetUTXOs(ctx context.Context, address string) ([]chain.UTXO, error) {
 	rows, err := p.db.QueryContext(ctx,
-		"SELECT tx_id, vout, value FROM utxos WHERE address = $1 ORDER BY value DESC",
+		"SELECT tx_id, vout, value, pk_script FROM utxos WHERE address = $1 AND spent_by IS NULL ORDER BY value DESC",
 		address)
 	if err != nil {
 		return nil, err
@@ -114,7 +289,7 @@ func (p *PostgresStore) GetUTXOs(ctx context.Context, address string) ([]chain.U
 	var utxos []chain.UTXO
 	for rows.Next() {
 		var u chain.UTXO
-		err := rows.Scan(&u.TxID, &u.VOut, &u.Value)
+		err := rows.Scan(&u.TxID, &u.VOut, &u.Value, &u.PkScript)
 		if err != nil {
 			return nil, err
 		}
@@ -131,16 +306,44 @@ func (p *PostgresStore) SaveUTXOs(ctx context.Context, address string, utxos []c
 	}
 	defer tx.Rollback()
 
-	// Clear existing UTXOs for address
-	if _, err := tx.ExecContext(ctx, "DELETE FROM utxos WHERE address = $1", address); err != nil {
+	// Clear existing UTXOs for address that are not in the new set. A spent
+	// output the set still lists keeps its row, and with it the mark, until
+	// the spending transaction confirms
+	keep := make(map[chain.OutPoint]bool, len(utxos))
+	for _, u := range utxos {
+		keep[u.OutPoint()] = true
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT tx_id, vout FROM utxos WHERE address = $1 FOR UPDATE", address)
+	if err != nil {
+		return err
+	}
+	var stale []chain.OutPoint
+	for rows.Next() {
+		var op chain.OutPoint
+		if err := rows.Scan(&op.TxID, &op.VOut); err != nil {
+			rows.Close()
+			return err
+		}
+		if !keep[op] {
+			stale = append(stale, op)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
 		return err
 	}
+	for _, op := range stale {
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM utxos WHERE tx_id = $1 AND vout = $2", op.TxID, op.VOut); err != nil {
+			return err
+		}
+	}
 
-	// Insert new UTXOs
+	// Upsert new UTXOs, keeping any reservation or spent mark already on them
 	for _, u := range utxos {
 		if _, err := tx.ExecContext(ctx,
-			"INSERT INTO utxos (address, tx_id, vout, value) VALUES ($1, $2, $3, $4)",
-			address, u.TxID, u.VOut, u.Value); err != nil {
+			"INSERT INTO utxos (address, tx_id, vout, value, pk_script) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (address, tx_id, vout) DO UPDATE SET value = $4, pk_script = $5",
+			address, u.TxID, u.VOut, u.Value, u.PkScript); err != nil {
 			return err
 		}
 	}
@@ -148,6 +351,143 @@ func (p *PostgresStore) SaveUTXOs(ctx context.Context, address string, utxos []c
 	return tx.Commit()
 }
 
+func (p *PostgresStore) GetSpendableUTXOs(ctx context.Context, address string) ([]chain.UTXO, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT tx_id, vout, value, pk_script FROM utxos WHERE address = $1 AND spent_by IS NULL AND (reserved_by IS NULL OR reserved_until <= NOW()) ORDER BY value DESC",
+		address)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var utxos []chain.UTXO
+	for rows.Next() {
+		var u chain.UTXO
+		if err := rows.Scan(&u.TxID, &u.VOut, &u.Value, &u.PkScript); err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, u)
+	}
+	return utxos, rows.Err()
+}
+
+func (p *PostgresStore) ReserveUTXOs(ctx context.Context, reservationID string, outpoints []chain.OutPoint, lease time.Duration) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock each row so concurrent reservations serialize on the same outputs
+	for _, op := range outpoints {
+		var holder sql.NullString
+		var live bool
+		err := tx.QueryRowContext(ctx,
+			"SELECT reserved_by, COALESCE(reserved_until > NOW(), FALSE) FROM utxos WHERE tx_id = $1 AND vout = $2 AND spent_by IS NULL FOR UPDATE",
+			op.TxID, op.VOut).Scan(&holder, &live)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%s: %w", op, ErrUTXONotFound)
+		}
+		if err != nil {
+			return err
+		}
+		if holder.Valid && holder.String != reservationID && live {
+			return fmt.Errorf("%s: %w", op, ErrUTXOReserved)
+		}
+	}
+
+	for _, op := range outpoints {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE utxos SET reserved_by = $1, reserved_until = NOW() + $2 * INTERVAL '1 millisecond' WHERE tx_id = $3 AND vout = $4",
+			reservationID, lease.Milliseconds(), op.TxID, op.VOut); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *PostgresStore) ReleaseUTXOs(ctx context.Context, reservationID string) error {
+	_, err := p.db.ExecContext(ctx,
+		"UPDATE utxos SET reserved_by = NULL, reserved_until = NULL, spent_by = NULL WHERE reserved_by = $1 OR spent_by = $1",
+		reservationID)
+	return err
+}
+
+func (p *PostgresStore) CommitUTXOs(ctx context.Context, reservationID string) error {
+	_, err := p.db.ExecContext(ctx,
+		"UPDATE utxos SET spent_by = reserved_by, reserved_by = NULL, reserved_until = NULL WHERE reserved_by = $1",
+		reservationID)
+	return err
+}
+
+// Deposit address methods
+
+func (p *PostgresStore) NextAddressIndex(ctx context.Context, chain string, account uint32) (uint32, error) {
+	// The upsert takes a row lock, so racing callers each get their own index
+	var idx uint32
+	err := p.db.QueryRowContext(ctx,
+		`INSERT INTO address_indexes (chain, account, next_index) VALUES ($1, $2, 1)
+		 ON CONFLICT (chain, account) DO UPDATE SET next_index = address_indexes.next_index + 1
+		 RETURNING next_index - 1`,
+		chain, int64(account)).Scan(&idx)
+	return idx, err
+}
+
+func (p *PostgresStore) SaveDepositAddress(ctx context.Context, addr *DepositAddress) error {
+	res, err := p.db.ExecContext(ctx,
+		`INSERT INTO deposit_addresses (chain, address, customer_id, path, account, idx, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (chain, address) DO NOTHING`,
+		addr.Chain, addr.Address, addr.CustomerID, addr.Path, int64(addr.Account), int64(addr.Index), addr.CreatedAt)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s on %s: %w", addr.Address, addr.Chain, ErrAddressExists)
+	}
+	return nil
+}
+
+func (p *PostgresStore) GetDepositAddress(ctx context.Context, chain, address string) (*DepositAddress, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT chain, address, customer_id, path, account, idx, created_at FROM deposit_addresses WHERE chain = $1 AND address = $2",
+		chain, address)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := scanDepositAddresses(rows)
+	if err != nil || len(addrs) == 0 {
+		return nil, err
+	}
+	return addrs[0], nil
+}
+
+func (p *PostgresStore) ListDepositAddresses(ctx context.Context, customerID string) ([]*DepositAddress, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT chain, address, customer_id, path, account, idx, created_at FROM deposit_addresses WHERE customer_id = $1 ORDER BY seq",
+		customerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanDepositAddresses(rows)
+}
+
+func scanDepositAddresses(rows *sql.Rows) ([]*DepositAddress, error) {
+	defer rows.Close()
+	var result []*DepositAddress
+	for rows.Next() {
+		var addr DepositAddress
+		if err := rows.Scan(&addr.Chain, &addr.Address, &addr.CustomerID, &addr.Path, &addr.Account, &addr.Index, &addr.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, &addr)
+	}
+	return result, rows.Err()
+}
+
 // Schema
 const schema = `
 CREATE TABLE IF NOT EXISTS transfers (
@@ -156,6 +496,15 @@ CREATE TABLE IF NOT EXISTS transfers (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+CREATE TABLE IF NOT EXISTS transfer_transitions (
+    seq BIGSERIAL PRIMARY KEY,
+    transfer_id TEXT NOT NULL REFERENCES transfers(id),
+    from_state TEXT NOT NULL,
+    to_state TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
 CREATE TABLE IF NOT EXISTS nonces (
     address TEXT PRIMARY KEY,
     nonce BIGINT NOT NULL CHECK (nonce >= 0)
@@ -169,8 +518,40 @@ CREATE TABLE IF NOT EXISTS utxos (
     PRIMARY KEY (address, tx_id, vout)
 );
 
+-- UTXO reservations: a live lease hides the output from coin selection
+ALTER TABLE utxos ADD COLUMN IF NOT EXISTS reserved_by TEXT;
+ALTER TABLE utxos ADD COLUMN IF NOT EXISTS reserved_until TIMESTAMP WITH TIME ZONE;
+-- Outputs a broadcast transaction spends, kept until it confirms so a node
+-- listing that still shows them cannot make them spendable again
+ALTER TABLE utxos ADD COLUMN IF NOT EXISTS spent_by TEXT;
+ALTER TABLE utxos ADD COLUMN IF NOT EXISTS pk_script BYTEA;
+
+-- HD deposit addresses: the next unused index per account, and what was issued to whom
+CREATE TABLE IF NOT EXISTS address_indexes (
+    chain TEXT NOT NULL,
+    account BIGINT NOT NULL,
+    next_index BIGINT NOT NULL,
+    PRIMARY KEY (chain, account)
+);
+
+CREATE TABLE IF NOT EXISTS deposit_addresses (
+    seq BIGSERIAL,
+    chain TEXT NOT NULL,
+    address TEXT NOT NULL,
+    customer_id TEXT NOT NULL,
+    path TEXT NOT NULL,
+    account BIGINT NOT NULL,
+    idx BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chain, address)
+);
+
 -- Optional: indexes for performance
 CREATE INDEX IF NOT EXISTS idx_transfers_id ON transfers(id);
+CREATE INDEX IF NOT EXISTS idx_transfers_status ON transfers((data->>'status'));
+CREATE INDEX IF NOT EXISTS idx_transfer_transitions_transfer_id ON transfer_transitions(transfer_id);
 CREATE INDEX IF NOT EXISTS idx_nonces_address ON nonces(address);
 CREATE INDEX IF NOT EXISTS idx_utxos_address ON utxos(address);
+CREATE INDEX IF NOT EXISTS idx_utxos_reserved_by ON utxos(reserved_by);
+CREATE INDEX IF NOT EXISTS idx_deposit_addresses_customer ON deposit_addresses(customer_id, seq);
 `
@@ -5,6 +5,9 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
+
+	"andi-custodian/internal/chain"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,3 +36,82 @@ func TestPostgresStore_Exists(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(42), nonce)
 }
+
+func TestPostgresStore_ReserveUTXOs(t *testing.T) {
+	if os.Getenv("TEST_POSTGRES") == "" {
+		t.Skip("Skipping PostgreSQL tests (set TEST_POSTGRES=1 to enable)")
+	}
+
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		connStr = "user=postgres password=postgres dbname=andi_custodian sslmode=disable"
+	}
+
+	store, err := NewPostgresStore(connStr)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	addr := "tb1q-reserve-test"
+	utxo := chain.UTXO{TxID: "tx-reserve", VOut: 0, Value: 1000000, PkScript: []byte{0x00, 0x14, 0x01}}
+	require.NoError(t, store.SaveUTXOs(ctx, addr, []chain.UTXO{utxo}))
+	defer store.ReleaseUTXOs(ctx, "pg-r1")
+
+	assert.NoError(t, store.ReserveUTXOs(ctx, "pg-r1", []chain.OutPoint{utxo.OutPoint()}, time.Minute))
+	assert.ErrorIs(t, store.ReserveUTXOs(ctx, "pg-r2", []chain.OutPoint{utxo.OutPoint()}, time.Minute), ErrUTXOReserved)
+
+	spendable, err := store.GetSpendableUTXOs(ctx, addr)
+	assert.NoError(t, err)
+	assert.Empty(t, spendable)
+
+	assert.NoError(t, store.CommitUTXOs(ctx, "pg-r1"))
+	remaining, err := store.GetUTXOs(ctx, addr)
+	assert.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	// A node listing from before the spend confirms does not bring it back
+	require.NoError(t, store.SaveUTXOs(ctx, addr, []chain.UTXO{utxo}))
+	spendable, err = store.GetSpendableUTXOs(ctx, addr)
+	assert.NoError(t, err)
+	assert.Empty(t, spendable)
+
+	// Released, e.g. after a rejection, it is spendable with its script
+	assert.NoError(t, store.ReleaseUTXOs(ctx, "pg-r1"))
+	spendable, err = store.GetSpendableUTXOs(ctx, addr)
+	assert.NoError(t, err)
+	assert.Equal(t, []chain.UTXO{utxo}, spendable)
+	require.NoError(t, store.SaveUTXOs(ctx, addr, nil))
+}
+
+func TestPostgresStore_DepositAddresses(t *testing.T) {
+	if os.Getenv("TEST_POSTGRES") == "" {
+		t.Skip("Skipping PostgreSQL tests (set TEST_POSTGRES=1 to enable)")
+	}
+
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		connStr = "user=postgres password=postgres dbname=andi_custodian sslmode=disable"
+	}
+
+	store, err := NewPostgresStore(connStr)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	chainName := "pg-deposit-" + time.Now().Format("150405.000000")
+	first, err := store.NextAddressIndex(ctx, chainName, 0)
+	require.NoError(t, err)
+	second, err := store.NextAddressIndex(ctx, chainName, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), first)
+	assert.Equal(t, uint32(1), second)
+
+	addr := &DepositAddress{Chain: chainName, Address: "addr-0", CustomerID: chainName + "-customer", Path: "m/84'/1'/0'/0/0", CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+	require.NoError(t, store.SaveDepositAddress(ctx, addr))
+	assert.ErrorIs(t, store.SaveDepositAddress(ctx, addr), ErrAddressExists)
+
+	got, err := store.GetDepositAddress(ctx, chainName, "addr-0")
+	require.NoError(t, err)
+	assert.Equal(t, addr.Path, got.Path)
+	list, err := store.ListDepositAddresses(ctx, addr.CustomerID)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
@@ -4,12 +4,46 @@ package store
 import (
 	"andi-custodian/internal/chain"
 	"context"
+	"errors"
+	"time"
 )
 
+var (
+	// ErrUTXOReserved is returned when an output is already leased to another reservation.
+	ErrUTXOReserved = errors.New("utxo already reserved")
+	// ErrUTXONotFound is returned when reserving an output the store does not know.
+	ErrUTXONotFound = errors.New("utxo not found")
+	// ErrTransferNotFound is returned when transitioning a transfer the store does not know.
+	ErrTransferNotFound = errors.New("transfer not found")
+	// ErrStaleTransition is returned when a transfer is no longer in the state a transition starts from.
+	ErrStaleTransition = errors.New("transfer is not in the expected state")
+	// ErrAddressExists is returned when recording a deposit address that was already issued.
+	ErrAddressExists = errors.New("deposit address already issued")
+)
+
+// takeOverReason is recorded in the history of a transfer whose claim lapsed.
+const takeOverReason = "claim expired; taken over"
+
 type Store interface {
 	// Idempotency
 	GetTransferResult(ctx context.Context, id string) (*TransferResult, error)
 	SaveTransferResult(ctx context.Context, id string, result *TransferResult) error
+	// ClaimTransfer atomically stores claim under id unless a transfer with that id
+	// already exists. It returns the stored transfer and whether this call created it,
+	// so of several callers racing on one id exactly one wins. A successful claim
+	// starts the transfer's history in claim.Status.
+	ClaimTransfer(ctx context.Context, id string, claim *TransferResult) (*TransferResult, bool, error)
+	// TakeOverTransfer replaces a transfer with claim if it is still as stale
+	// left it, same status and claim, recording the step in its history. It
+	// reports whether this call took the transfer over.
+	TakeOverTransfer(ctx context.Context, id string, stale, claim *TransferResult) (bool, error)
+	// RecordTransition moves a transfer's status from tr.From to tr.To and appends tr
+	// to its history. It returns ErrStaleTransition if the status is no longer tr.From.
+	RecordTransition(ctx context.Context, id string, tr Transition) error
+	// GetTransitions returns a transfer's history, oldest first.
+	GetTransitions(ctx context.Context, id string) ([]Transition, error)
+	// ListTransfers returns the transfers whose status is one of statuses.
+	ListTransfers(ctx context.Context, statuses ...string) ([]*TransferResult, error)
 
 	// Ethereum
 	GetNonce(ctx context.Context, address string) (uint64, error)
@@ -18,4 +52,30 @@ type Store interface {
 	// Bitcoin
 	GetUTXOs(ctx context.Context, address string) ([]chain.UTXO, error)
 	SaveUTXOs(ctx context.Context, address string, utxos []chain.UTXO) error
+
+	// GetSpendableUTXOs returns the address's UTXOs that are not held by a live reservation.
+	GetSpendableUTXOs(ctx context.Context, address string) ([]chain.UTXO, error)
+	// ReserveUTXOs leases outputs to a reservation until the lease expires. It is
+	// all-or-nothing: if any output is held by another live reservation it reserves
+	// nothing and returns ErrUTXOReserved.
+	ReserveUTXOs(ctx context.Context, reservationID string, outpoints []chain.OutPoint, lease time.Duration) error
+	// ReleaseUTXOs returns a reservation's outputs to the spendable set, also
+	// after CommitUTXOs, e.g. when the spending transaction is rejected.
+	ReleaseUTXOs(ctx context.Context, reservationID string) error
+	// CommitUTXOs marks a reservation's outputs spent once the spending
+	// transaction is out. SaveUTXOs keeps them spent while it is passed them
+	// again, and drops them when it is not.
+	CommitUTXOs(ctx context.Context, reservationID string) error
+
+	// Deposit addresses
+	// NextAddressIndex allocates the next unused address index of an account on
+	// a chain, starting at 0. No index is handed out twice, even to racing callers.
+	NextAddressIndex(ctx context.Context, chain string, account uint32) (uint32, error)
+	// SaveDepositAddress records an issued address. It returns ErrAddressExists
+	// if the address is already recorded on the chain.
+	SaveDepositAddress(ctx context.Context, addr *DepositAddress) error
+	// GetDepositAddress returns the record of an issued address, or nil if it was never issued.
+	GetDepositAddress(ctx context.Context, chain, address string) (*DepositAddress, error)
+	// ListDepositAddresses returns a customer's addresses, oldest first.
+	ListDepositAddresses(ctx context.Context, customerID string) ([]*DepositAddress, error)
 }
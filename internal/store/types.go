@@ -5,7 +5,41 @@ import "time"
 
 // TransferResult represents the outcome of a custody transfer.
 type TransferResult struct {
+	ID        string    `json:"id,omitempty"`    // request ID
+	Chain     string    `json:"chain,omitempty"` // chain the transaction was sent on
 	TxID      string    `json:"tx_id"`
+	RawTx     []byte    `json:"raw_tx,omitempty"` // signed, broadcast-ready transaction
 	Status    string    `json:"status"`
 	Timestamp time.Time `json:"timestamp"`
+	// Fingerprint identifies the request payload, so a replayed ID can be checked
+	// against the transfer it first created.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// ClaimedUntil is when the claim on a transfer that has no signed
+	// transaction yet lapses, after which another caller may take it over.
+	ClaimedUntil time.Time `json:"claimed_until,omitempty"`
+
+	// Inclusion progress, kept current by the finality monitor
+	Confirmations uint64 `json:"confirmations,omitempty"`
+	BlockHash     string `json:"block_hash,omitempty"`
+	BlockHeight   uint64 `json:"block_height,omitempty"`
+}
+
+// Transition is one recorded step in a transfer's lifecycle.
+type Transition struct {
+	From      string    `json:"from"` // empty for the step that created the transfer
+	To        string    `json:"to"`
+	Reason    string    `json:"reason,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DepositAddress is an address issued to a customer for deposits. The path
+// recovers its key from the wallet seed.
+type DepositAddress struct {
+	Chain      string    `json:"chain"`
+	Address    string    `json:"address"`
+	CustomerID string    `json:"customer_id"`
+	Path       string    `json:"path"` // e.g. m/84'/1'/0'/0/7
+	Account    uint32    `json:"account"`
+	Index      uint32    `json:"index"`
+	CreatedAt  time.Time `json:"created_at"`
 }
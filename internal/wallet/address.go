@@ -6,76 +6,135 @@ import (
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
-	_ "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+
+	"andi-custodian/pkg/chains"
+	"andi-custodian/pkg/networks"
 )
 
-// Chain represents a supported blockchain network.
-type Chain string
+// Chain represents a supported blockchain network; it is the shared chains.Chain.
+type Chain = chains.Chain
 
 const (
-	BitcoinTestnet  Chain = "bitcoin-testnet"
-	EthereumSepolia Chain = "ethereum-sepolia"
-	SolanaDevnet    Chain = "solana-devnet"
-	AvalancheFuji   Chain = "avalanche-fuji"
+	BitcoinMainnet  = chains.BitcoinMainnet
+	BitcoinTestnet  = chains.BitcoinTestnet
+	BitcoinSignet   = chains.BitcoinSignet
+	BitcoinRegtest  = chains.BitcoinRegtest
+	EthereumSepolia = chains.EthereumSepolia
+	SolanaDevnet    = chains.SolanaDevnet
+	AvalancheFuji   = chains.AvalancheFuji
 )
 
-// DeriveAddress derives a wallet address for the given chain using standard BIP paths.
-func (w *Wallet) DeriveAddress(chain Chain) (interface{}, error) {
-	masterKey, err := hdkeychain.NewMaster(w.seed, &chaincfg.MainNetParams)
+// Derivation purposes and coin types.
+const (
+	purposeBIP44 = 44 // P2PKH-era layout, used by EVM wallets
+	purposeBIP84 = 84 // native segwit (P2WPKH)
+	coinTypeEVM  = 60 // every EVM chain shares Ethereum's keys
+)
+
+// DerivedAddress is an address derived from the wallet, with the key and path
+// that produced it.
+type DerivedAddress struct {
+	Chain     Chain
+	Address   string // bech32 for Bitcoin, EIP-55 checksummed hex for EVM chains
+	PublicKey []byte // compressed SEC1
+	Path      DerivationPath
+	Account   uint32
+	Change    uint32
+	Index     uint32
+}
+
+// DeriveAddress derives the first receiving address of account 0 on a chain.
+func (w *Wallet) DeriveAddress(chain Chain) (*DerivedAddress, error) {
+	return w.Derive(chain, 0, ChangeExternal, 0)
+}
+
+// AddressDeriver derives addresses at BIP-44 positions. Wallet derives them
+// from its seed, WatchOnlyWallet from account extended public keys.
+type AddressDeriver interface {
+	Derive(chain Chain, account, change, index uint32) (*DerivedAddress, error)
+}
+
+// Derive derives the address at an account, change branch and index using
+// standard BIP paths: m/84'/coin'/account'/change/index on Bitcoin and
+// m/44'/60'/account'/change/index on EVM chains. Bitcoin addresses use the
+// network's coin type and encoding, so the same seed never yields a mainnet
+// address on a test network or the reverse.
+func (w *Wallet) Derive(chain Chain, account, change, index uint32) (*DerivedAddress, error) {
+	path, btcNet, err := chainPath(chain, account, change, index)
 	if err != nil {
-		return nil, fmt.Errorf("create master key: %w", err)
+		return nil, err
+	}
+	key, err := w.deriveKey(path)
+	if err != nil {
+		return nil, err
 	}
+	return newDerivedAddress(chain, btcNet, key, path)
+}
 
-	switch chain {
-	case BitcoinTestnet:
-		path := []uint32{
-			hdkeychain.HardenedKeyStart + 84, // BIP-84
-			hdkeychain.HardenedKeyStart + 1,  // testnet coin type
-			hdkeychain.HardenedKeyStart + 0,
-			0, 0,
-		}
-		key := masterKey
-		for _, idx := range path {
-			key, err = key.Derive(idx)
-			if err != nil {
-				return nil, fmt.Errorf("derive BTC key at %d: %w", idx, err)
-			}
-		}
-		pubKey, err := key.ECPubKey()
-		if err != nil {
-			return nil, err
+// chainPath returns the path of an address on chain, and the network for
+// Bitcoin chains.
+func chainPath(chain Chain, account, change, index uint32) (DerivationPath, networks.BitcoinNetwork, error) {
+	var (
+		path   DerivationPath
+		btcNet networks.BitcoinNetwork
+		err    error
+	)
+	switch chain.Family() {
+	case chains.FamilyUTXO:
+		if btcNet, err = networks.Bitcoin(string(chain)); err != nil {
+			return nil, btcNet, err
 		}
-		addr, err := btcutil.NewAddressWitnessPubKeyHash(
-			btcutil.Hash160(pubKey.SerializeCompressed()),
-			&chaincfg.TestNet3Params,
-		)
+		path, err = BIP44Path(purposeBIP84, btcNet.CoinType, account, change, index)
+	case chains.FamilyEVM:
+		path, err = BIP44Path(purposeBIP44, coinTypeEVM, account, change, index)
+	default:
+		return nil, btcNet, fmt.Errorf("unsupported chain: %s", chain)
+	}
+	return path, btcNet, err
+}
+
+// newDerivedAddress encodes the address of key, which was derived at path.
+// btcNet is the zero value on EVM chains.
+func newDerivedAddress(chain Chain, btcNet networks.BitcoinNetwork, key *hdkeychain.ExtendedKey, path DerivationPath) (*DerivedAddress, error) {
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return nil, fmt.Errorf("extract public key: %w", err)
+	}
+	derived := &DerivedAddress{
+		Chain:     chain,
+		PublicKey: pubKey.SerializeCompressed(),
+		Path:      path,
+		Account:   path[2] - Hardened,
+		Change:    path[3],
+		Index:     path[4],
+	}
+	if btcNet.Params != nil {
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(derived.PublicKey), btcNet.Params)
 		if err != nil {
 			return nil, err
 		}
-		return addr.EncodeAddress(), nil
+		derived.Address = addr.EncodeAddress()
+	} else {
+		derived.Address = crypto.PubkeyToAddress(*pubKey.ToECDSA()).Hex()
+	}
+	return derived, nil
+}
 
-	case EthereumSepolia:
-		path := []uint32{
-			hdkeychain.HardenedKeyStart + 44, // BIP-44
-			hdkeychain.HardenedKeyStart + 60, // ETH coin type
-			hdkeychain.HardenedKeyStart + 0,
-			0, 0,
-		}
-		key := masterKey
-		for _, idx := range path {
-			key, err = key.Derive(idx)
-			if err != nil {
-				return nil, fmt.Errorf("derive ETH key at %d: %w", idx, err)
-			}
-		}
-		privKey, err := key.ECPrivKey()
+// deriveKey walks path from the master key, zeroing the private keys it
+// passes on the way.
+func (w *Wallet) deriveKey(path DerivationPath) (*hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewMaster(w.seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, fmt.Errorf("create master key: %w", err)
+	}
+	for _, idx := range path {
+		child, err := key.Derive(idx)
+		key.Zero()
 		if err != nil {
-			return nil, fmt.Errorf("extract ETH private key: %w", err)
+			return nil, fmt.Errorf("derive %s: %w", path, err)
 		}
-		return crypto.PubkeyToAddress(privKey.ToECDSA().PublicKey), nil
-
-	default:
-		return nil, fmt.Errorf("unsupported chain: %s", chain)
+		key = child
 	}
+	return key, nil
 }
@@ -2,7 +2,12 @@
 package wallet
 
 import (
+	"bytes"
+	"errors"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/ethereum/go-ethereum/common"
+	"strings"
 	"testing"
 )
 
@@ -18,13 +23,47 @@ func TestDeriveAddress_BitcoinTestnet(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	s := addr.(string)
+	s := addr.Address
 	// Should be Bech32 testnet (starts with tb1)
 	if len(s) == 0 || s[:3] != "tb1" {
 		t.Errorf("Invalid Bitcoin testnet address: %s", s)
 	}
 }
 
+func TestDeriveAddress_BitcoinNetworks(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	derive := func(chain Chain, params *chaincfg.Params) (string, []byte) {
+		addr, err := wallet.DeriveAddress(chain)
+		if err != nil {
+			t.Fatalf("%s: %v", chain, err)
+		}
+		decoded, err := btcutil.DecodeAddress(addr.Address, params)
+		if err != nil || !decoded.IsForNet(params) {
+			t.Fatalf("%s: %s is not an address of the network", chain, addr.Address)
+		}
+		return addr.Address, decoded.ScriptAddress()
+	}
+
+	mainnet, mainnetKey := derive(BitcoinMainnet, &chaincfg.MainNetParams)
+	testnet, testnetKey := derive(BitcoinTestnet, &chaincfg.TestNet3Params)
+	if !strings.HasPrefix(mainnet, "bc1") {
+		t.Errorf("Invalid Bitcoin mainnet address: %s", mainnet)
+	}
+	// Coin type 0 on mainnet, 1 on test networks: different keys, not just encodings
+	if bytes.Equal(mainnetKey, testnetKey) {
+		t.Errorf("Mainnet and testnet share a key: %s %s", mainnet, testnet)
+	}
+	if regtest, key := derive(BitcoinRegtest, &chaincfg.RegressionNetParams); !bytes.Equal(key, testnetKey) {
+		t.Errorf("Regtest address %s should hold the testnet key of %s", regtest, testnet)
+	}
+	if signet, _ := derive(BitcoinSignet, &chaincfg.SigNetParams); signet != testnet {
+		t.Errorf("Signet address %s, want %s", signet, testnet)
+	}
+}
+
 func TestDeriveAddress_EthereumSepolia(t *testing.T) {
 	wallet, err := NewWallet(testMnemonic)
 	if err != nil {
@@ -34,8 +73,64 @@ func TestDeriveAddress_EthereumSepolia(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	e := addr.(common.Address).String() // or use common.Address
-	if len(e) != 42 || e[:2] != "0x" {
+	e := addr.Address
+	if len(e) != 42 || e[:2] != "0x" || common.HexToAddress(e).Hex() != e {
 		t.Errorf("Invalid Ethereum address: %s", e)
 	}
 }
+
+func TestWallet_Derive(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := wallet.Derive(BitcoinTestnet, 0, ChangeExternal, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Address != "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm" || first.Path.String() != "m/84'/1'/0'/0/0" {
+		t.Errorf("Derive(0, 0, 0) = %s at %s", first.Address, first.Path)
+	}
+	// The public key is the one the address commits to
+	decoded, _ := btcutil.DecodeAddress(first.Address, &chaincfg.TestNet3Params)
+	if !bytes.Equal(btcutil.Hash160(first.PublicKey), decoded.ScriptAddress()) {
+		t.Errorf("Public key %x does not match %s", first.PublicKey, first.Address)
+	}
+
+	// Every account, branch and index gives a different address
+	seen := map[string]string{first.Address: first.Path.String()}
+	for _, at := range [][3]uint32{{0, 0, 1}, {0, 0, 2}, {0, ChangeInternal, 0}, {1, 0, 0}} {
+		d, err := wallet.Derive(BitcoinTestnet, at[0], at[1], at[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev, ok := seen[d.Address]; ok {
+			t.Errorf("%s repeats the address of %s", d.Path, prev)
+		}
+		seen[d.Address] = d.Path.String()
+		if d.Account != at[0] || d.Change != at[1] || d.Index != at[2] {
+			t.Errorf("Derive%v recorded %d/%d/%d", at, d.Account, d.Change, d.Index)
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected 5 distinct addresses, got %d", len(seen))
+	}
+
+	eth, err := wallet.Derive(AvalancheFuji, 2, ChangeExternal, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eth.Path.String() != "m/44'/60'/2'/0/7" {
+		t.Errorf("EVM path = %s", eth.Path)
+	}
+
+	for _, bad := range [][3]uint32{{Hardened, 0, 0}, {0, 2, 0}, {0, 0, Hardened}} {
+		if _, err := wallet.Derive(BitcoinTestnet, bad[0], bad[1], bad[2]); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Derive%v: got %v, want ErrInvalidPath", bad, err)
+		}
+	}
+	if _, err := wallet.Derive(SolanaDevnet, 0, 0, 0); err == nil {
+		t.Error("Derive accepted a chain without BIP-32 keys")
+	}
+}
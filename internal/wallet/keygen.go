@@ -2,14 +2,46 @@
 package wallet
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/tyler-smith/go-bip39"
 )
 
-// GenerateMnemonic creates a new 12-word BIP-39 mnemonic for HD wallet recovery.
-// It is the root of all derived keys and must be stored securely by the user.
-func GenerateMnemonic() (string, []byte, error) {
-	// 128 bits = 12-word mnemonic
-	entropy, err := bip39.NewEntropy(128)
+// ErrInvalidMnemonic is matched by every MnemonicError.
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// MnemonicError describes why a mnemonic was rejected. It never quotes the
+// mnemonic's words, so it is safe to log.
+type MnemonicError struct {
+	Reason string
+	Word   int // 1-based position of the offending word, 0 if not about one word
+}
+
+func (e *MnemonicError) Error() string {
+	if e.Word > 0 {
+		return fmt.Sprintf("%v: word %d: %s", ErrInvalidMnemonic, e.Word, e.Reason)
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidMnemonic, e.Reason)
+}
+
+// Unwrap lets errors.Is match ErrInvalidMnemonic.
+func (e *MnemonicError) Unwrap() error {
+	return ErrInvalidMnemonic
+}
+
+// GenerateMnemonic creates a new BIP-39 mnemonic of 12, 15, 18, 21 or 24 words
+// (128 to 256 bits of entropy) and returns it with the seed it yields under
+// passphrase. The mnemonic is the root of all derived keys and must be stored
+// securely by the user; a non-empty passphrase must be stored separately, as
+// the mnemonic alone then recovers a different, empty wallet.
+func GenerateMnemonic(words int, passphrase string) (string, []byte, error) {
+	if words < 12 || words > 24 || words%3 != 0 {
+		return "", nil, fmt.Errorf("mnemonic must have 12, 15, 18, 21 or 24 words, not %d", words)
+	}
+	// Every 3 words hold 32 bits of entropy and 1 checksum bit
+	entropy, err := bip39.NewEntropy(words / 3 * 32)
 	if err != nil {
 		return "", nil, err
 	}
@@ -17,14 +49,77 @@ func GenerateMnemonic() (string, []byte, error) {
 	if err != nil {
 		return "", nil, err
 	}
-	seed := bip39.NewSeed(mnemonic, "") // no passphrase
+	seed, err := NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return "", nil, err
+	}
 	return mnemonic, seed, nil
 }
 
-// NewWallet creates a new wallet from a BIP-39 mnemonic.
-// The seed is derived deterministically and used for HD key derivation.
-func NewWallet(mnemonic string) (*Wallet, error) {
-	seed := bip39.NewSeed(mnemonic, "")
+// ValidateMnemonic checks a mnemonic's length, words and checksum against the
+// English BIP-39 wordlist and returns a *MnemonicError if any is wrong.
+// Surrounding and repeated whitespace and letter case are ignored.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := normalizeMnemonic(mnemonic)
+	return err
+}
+
+// normalizeMnemonic validates a mnemonic and returns it lower-cased with single
+// spaces, the form its seed is computed from.
+func normalizeMnemonic(mnemonic string) (string, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if n := len(words); n < 12 || n > 24 || n%3 != 0 {
+		return "", &MnemonicError{Reason: fmt.Sprintf("%d words, want 12, 15, 18, 21 or 24", n)}
+	}
+	for i, word := range words {
+		if _, ok := bip39.GetWordIndex(word); !ok {
+			return "", &MnemonicError{Reason: "not in the BIP-39 wordlist", Word: i + 1}
+		}
+	}
+	normalized := strings.Join(words, " ")
+	if _, err := bip39.EntropyFromMnemonic(normalized); err != nil {
+		return "", &MnemonicError{Reason: "checksum mismatch"}
+	}
+	return normalized, nil
+}
+
+// NewSeed validates a mnemonic and returns its BIP-39 seed under passphrase,
+// the optional "25th word". Each passphrase yields an unrelated wallet.
+func NewSeed(mnemonic, passphrase string) ([]byte, error) {
+	normalized, err := normalizeMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	return bip39.NewSeed(normalized, passphrase), nil
+}
+
+// WalletOption configures NewWallet.
+type WalletOption func(*walletConfig)
+
+type walletConfig struct {
+	passphrase string
+}
+
+// WithPassphrase sets the BIP-39 passphrase the seed is derived with.
+func WithPassphrase(passphrase string) WalletOption {
+	return func(c *walletConfig) {
+		c.passphrase = passphrase
+	}
+}
+
+// NewWallet creates a new wallet from a BIP-39 mnemonic. Mnemonics with an
+// unknown word or a bad checksum are rejected with a *MnemonicError rather
+// than silently opening an empty wallet. The seed is derived deterministically
+// and used for HD key derivation.
+func NewWallet(mnemonic string, opts ...WalletOption) (*Wallet, error) {
+	var cfg walletConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	seed, err := NewSeed(mnemonic, cfg.passphrase)
+	if err != nil {
+		return nil, err
+	}
 	return &Wallet{seed: seed}, nil
 }
 
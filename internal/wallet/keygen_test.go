@@ -2,19 +2,43 @@
 package wallet
 
 import (
+	"encoding/hex"
+	"errors"
+	"strings"
 	"testing"
 )
 
 func TestGenerateMnemonic(t *testing.T) {
-	mnemonic, seed, err := GenerateMnemonic()
+	for _, words := range []int{12, 15, 18, 21, 24} {
+		mnemonic, seed, err := GenerateMnemonic(words, "")
+		if err != nil {
+			t.Fatalf("GenerateMnemonic(%d) failed: %v", words, err)
+		}
+		if n := len(strings.Fields(mnemonic)); n != words {
+			t.Errorf("GenerateMnemonic(%d) returned %d words", words, n)
+		}
+		if err := ValidateMnemonic(mnemonic); err != nil {
+			t.Errorf("generated mnemonic is invalid: %v", err)
+		}
+		if len(seed) != 64 {
+			t.Errorf("Seed length = %d, want 64", len(seed))
+		}
+	}
+	for _, words := range []int{0, 11, 13, 27} {
+		if _, _, err := GenerateMnemonic(words, ""); err == nil {
+			t.Errorf("GenerateMnemonic(%d) should fail", words)
+		}
+	}
+}
+
+func TestGenerateMnemonic_Passphrase(t *testing.T) {
+	mnemonic, seed, err := GenerateMnemonic(24, "TREZOR")
 	if err != nil {
 		t.Fatalf("GenerateMnemonic failed: %v", err)
 	}
-	if len(mnemonic) == 0 {
-		t.Error("Mnemonic is empty")
-	}
-	if len(seed) != 64 {
-		t.Errorf("Seed length = %d, want 64", len(seed))
+	want, _ := NewSeed(mnemonic, "TREZOR")
+	if hex.EncodeToString(seed) != hex.EncodeToString(want) {
+		t.Error("seed was not derived with the passphrase")
 	}
 }
 
@@ -28,3 +52,57 @@ func TestNewWallet(t *testing.T) {
 		t.Error("Seed is nil")
 	}
 }
+
+func TestNewWallet_Passphrase(t *testing.T) {
+	// BIP-39 test vector: 128 bits of zero entropy with passphrase "TREZOR"
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	wallet, err := NewWallet(mnemonic, WithPassphrase("TREZOR"))
+	if err != nil {
+		t.Fatalf("NewWallet failed: %v", err)
+	}
+	want := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if got := hex.EncodeToString(wallet.seed); got != want {
+		t.Errorf("seed = %s, want %s", got, want)
+	}
+
+	// Case and spacing do not change the wallet
+	messy, err := NewWallet("  ABANDON abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon   about\n", WithPassphrase("TREZOR"))
+	if err != nil {
+		t.Fatalf("NewWallet failed: %v", err)
+	}
+	if hex.EncodeToString(messy.seed) != want {
+		t.Error("normalized mnemonic yields a different seed")
+	}
+}
+
+func TestNewWallet_InvalidMnemonic(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		word     int
+	}{
+		{"empty", "", 0},
+		{"too short", "abandon abandon abandon", 0},
+		{"unknown word", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandonn", 12},
+		{"bad checksum", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", 0},
+		{"arbitrary string", "correct horse battery staple", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWallet(tt.mnemonic)
+			if !errors.Is(err, ErrInvalidMnemonic) {
+				t.Fatalf("err = %v, want ErrInvalidMnemonic", err)
+			}
+			var mnemonicErr *MnemonicError
+			if !errors.As(err, &mnemonicErr) {
+				t.Fatalf("err = %T, want *MnemonicError", err)
+			}
+			if mnemonicErr.Word != tt.word {
+				t.Errorf("Word = %d, want %d", mnemonicErr.Word, tt.word)
+			}
+			if strings.Contains(err.Error(), "abandonn") {
+				t.Error("error message leaks mnemonic words")
+			}
+		})
+	}
+}
@@ -52,11 +52,11 @@ func (s *SimulatedMPCSigner) SignNFTTransfer(ctx context.Context, req NFTTransfe
 		}
 		chain = EthereumSepolia
 
-	case BitcoinTestnet:
+	case BitcoinMainnet, BitcoinTestnet, BitcoinSignet, BitcoinRegtest:
 		// For Ordinals: payload = sighash of transaction spending the inscribed UTXO
 		// For simulation: use dummy hash (real impl would come from chain/ layer)
 		payload = make([]byte, 32) // placeholder
-		chain = BitcoinTestnet
+		chain = req.Chain
 
 	default:
 		return nil, fmt.Errorf("unsupported chain for NFT: %s", req.Chain)
@@ -0,0 +1,81 @@
+// path.go
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+// ErrInvalidPath is returned for malformed derivation paths and out-of-range
+// account, change or index values.
+var ErrInvalidPath = errors.New("invalid derivation path")
+
+// Hardened is added to an index to derive a hardened child.
+const Hardened = hdkeychain.HardenedKeyStart
+
+// BIP-44 change branches.
+const (
+	ChangeExternal uint32 = 0 // receiving addresses, e.g. deposits
+	ChangeInternal uint32 = 1 // change outputs
+)
+
+// DerivationPath is a BIP-32 path from the master key, one index per level.
+// Hardened levels include the Hardened offset.
+type DerivationPath []uint32
+
+// BIP44Path returns m/purpose'/coinType'/account'/change/index, the layout of
+// BIP-44 and BIP-84.
+func BIP44Path(purpose, coinType, account, change, index uint32) (DerivationPath, error) {
+	switch {
+	case account >= Hardened:
+		return nil, fmt.Errorf("%w: account %d out of range", ErrInvalidPath, account)
+	case change != ChangeExternal && change != ChangeInternal:
+		return nil, fmt.Errorf("%w: change must be 0 or 1, got %d", ErrInvalidPath, change)
+	case index >= Hardened:
+		return nil, fmt.Errorf("%w: index %d out of range", ErrInvalidPath, index)
+	}
+	return DerivationPath{Hardened + purpose, Hardened + coinType, Hardened + account, change, index}, nil
+}
+
+// ParseDerivationPath parses a path such as "m/84'/1'/0'/0/7". Hardened levels
+// are marked with ' or h.
+func ParseDerivationPath(s string) (DerivationPath, error) {
+	parts := strings.Split(s, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("%w: %q must start with m", ErrInvalidPath, s)
+	}
+	path := make(DerivationPath, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		idx, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || idx >= uint64(Hardened) {
+			return nil, fmt.Errorf("%w: bad level %q in %q", ErrInvalidPath, part, s)
+		}
+		if hardened {
+			idx += uint64(Hardened)
+		}
+		path = append(path, uint32(idx))
+	}
+	return path, nil
+}
+
+// String formats the path with ' marking hardened levels.
+func (p DerivationPath) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, idx := range p {
+		if idx >= Hardened {
+			fmt.Fprintf(&b, "/%d'", idx-Hardened)
+		} else {
+			fmt.Fprintf(&b, "/%d", idx)
+		}
+	}
+	return b.String()
+}
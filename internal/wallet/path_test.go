@@ -0,0 +1,30 @@
+// path_test.go
+package wallet
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseDerivationPath(t *testing.T) {
+	for _, s := range []string{"m", "m/84'/1'/0'/0/7", "m/44'/501'/0'/0'"} {
+		path, err := ParseDerivationPath(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if path.String() != s {
+			t.Errorf("Round trip of %s gave %s", s, path)
+		}
+	}
+
+	path, _ := ParseDerivationPath("m/44h/60h/0h/0/1")
+	if path.String() != "m/44'/60'/0'/0/1" || path[0] != Hardened+44 || path[4] != 1 {
+		t.Errorf("h notation parsed as %s", path)
+	}
+
+	for _, s := range []string{"", "84'/0'", "m/", "m/x", "m/-1", "m/2147483648", "m/1''"} {
+		if _, err := ParseDerivationPath(s); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("%q: got %v, want ErrInvalidPath", s, err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// recovery.go
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrRecoveryMismatch is returned when a recovered wallet does not derive the
+// addresses the original one did: a wrong word that still passes the
+// checksum, or a wrong or missing passphrase.
+var ErrRecoveryMismatch = errors.New("recovered wallet does not match known addresses")
+
+// KnownAddress is an address the original wallet is known to have derived,
+// e.g. a recorded deposit address.
+type KnownAddress struct {
+	Chain   Chain
+	Address string
+	Account uint32
+	Change  uint32
+	Index   uint32
+}
+
+// RecoverWallet opens a wallet from a mnemonic and passphrase entered during
+// recovery and confirms them by re-deriving known addresses. A mistyped
+// passphrase is a valid passphrase for another wallet, so without this check
+// recovery would succeed and show an empty wallet.
+func RecoverWallet(mnemonic string, known []KnownAddress, opts ...WalletOption) (*Wallet, error) {
+	if len(known) == 0 {
+		return nil, errors.New("recovery needs at least one known address to compare")
+	}
+	w, err := NewWallet(mnemonic, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range known {
+		derived, err := w.Derive(k.Chain, k.Account, k.Change, k.Index)
+		if err != nil {
+			return nil, err
+		}
+		// EVM addresses may be given without their EIP-55 checksum casing
+		if !strings.EqualFold(derived.Address, k.Address) {
+			return nil, fmt.Errorf("%w: %s at %s is %s, want %s", ErrRecoveryMismatch, k.Chain, derived.Path, derived.Address, k.Address)
+		}
+	}
+	return w, nil
+}
@@ -0,0 +1,47 @@
+// recovery_test.go
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRecoverWallet(t *testing.T) {
+	original, err := NewWallet(testMnemonic, WithPassphrase("correct horse"))
+	if err != nil {
+		t.Fatalf("NewWallet failed: %v", err)
+	}
+	btc, _ := original.Derive(BitcoinTestnet, 0, ChangeExternal, 4)
+	eth, _ := original.Derive(EthereumSepolia, 0, ChangeExternal, 0)
+	known := []KnownAddress{
+		{Chain: BitcoinTestnet, Address: btc.Address, Index: 4},
+		{Chain: EthereumSepolia, Address: strings.ToLower(eth.Address)},
+	}
+
+	recovered, err := RecoverWallet(testMnemonic, known, WithPassphrase("correct horse"))
+	if err != nil {
+		t.Fatalf("RecoverWallet failed: %v", err)
+	}
+	if string(recovered.seed) != string(original.seed) {
+		t.Error("recovered a different seed")
+	}
+
+	// A wrong or forgotten passphrase opens another wallet and must be caught
+	for _, passphrase := range []string{"", "Correct horse"} {
+		if _, err := RecoverWallet(testMnemonic, known, WithPassphrase(passphrase)); !errors.Is(err, ErrRecoveryMismatch) {
+			t.Errorf("passphrase %q: err = %v, want ErrRecoveryMismatch", passphrase, err)
+		}
+	}
+	// So must a different mnemonic that passes the checksum
+	other := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if _, err := RecoverWallet(other, known, WithPassphrase("correct horse")); !errors.Is(err, ErrRecoveryMismatch) {
+		t.Errorf("err = %v, want ErrRecoveryMismatch", err)
+	}
+	if _, err := RecoverWallet("abandon", known); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Errorf("err = %v, want ErrInvalidMnemonic", err)
+	}
+	if _, err := RecoverWallet(testMnemonic, nil); err == nil {
+		t.Error("recovery without known addresses should fail")
+	}
+}
@@ -4,18 +4,21 @@ package wallet
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/tyler-smith/go-bip39"
 	"testing"
 )
 
 // signer_test.go
 func TestSimulatedMPCSigner_Sign_Ethereum(t *testing.T) {
-	// Use seed[:32] to derive key — same as signer.go
+	// The signer must sign for the address the wallet hands out
 	seed := bip39.NewSeed(testMnemonic, "")
-	privKey, _ := btcec.PrivKeyFromBytes(seed[:32])
-	expectedAddr := crypto.PubkeyToAddress(privKey.ToECDSA().PublicKey).Hex()
+	derived, err := (&Wallet{seed: seed}).DeriveAddress(EthereumSepolia)
+	if err != nil {
+		t.Fatalf("derive address: %v", err)
+	}
+	expectedAddr := derived.Address
 
 	signer := NewSimulatedMPCSigner(seed)
 	payload := make([]byte, 32)
@@ -61,3 +64,74 @@ func TestSimulatedMPCSigner_Sign_Bitcoin(t *testing.T) {
 		t.Error("BTC signature verification failed")
 	}
 }
+
+func TestSimulatedMPCSigner_Sign_Path(t *testing.T) {
+	w, _ := NewWallet(testMnemonic)
+	signer := NewSimulatedMPCSigner(w.seed)
+	payload := make([]byte, 32)
+	rand.Read(payload)
+
+	derived, err := w.Derive(EthereumSepolia, 0, ChangeExternal, 5)
+	if err != nil {
+		t.Fatalf("derive: %v", err)
+	}
+	sig, err := signer.Sign(context.Background(), SignRequest{
+		Chain:   EthereumSepolia,
+		Payload: payload,
+		Path:    derived.Path,
+	})
+	if err != nil {
+		t.Fatalf("ETH sign failed: %v", err)
+	}
+	if !(&Verifier{}).VerifyEthereum(payload, sig, derived.Address) {
+		t.Error("signature does not verify against the address at the path")
+	}
+}
+
+func TestSimulatedMPCSigner_PublicKeyAt_MatchesDerivedAddress(t *testing.T) {
+	w, _ := NewWallet(testMnemonic)
+	signer := NewSimulatedMPCSigner(w.seed)
+
+	derived, err := w.Derive(BitcoinTestnet, 0, ChangeInternal, 3)
+	if err != nil {
+		t.Fatalf("derive: %v", err)
+	}
+	pub, err := signer.PublicKeyAt(context.Background(), BitcoinTestnet, derived.Path)
+	if err != nil {
+		t.Fatalf("public key: %v", err)
+	}
+	if string(pub) != string(derived.PublicKey) {
+		t.Errorf("public key = %x, want %x", pub, derived.PublicKey)
+	}
+
+	payload := make([]byte, 32)
+	rand.Read(payload)
+	sig, err := signer.Sign(context.Background(), SignRequest{Chain: BitcoinTestnet, Payload: payload, Path: derived.Path})
+	if err != nil {
+		t.Fatalf("BTC sign failed: %v", err)
+	}
+	pubKey, _ := btcec.ParsePubKey(pub)
+	if !(&Verifier{}).VerifyBitcoin(payload, sig, pubKey) {
+		t.Error("BTC signature verification failed")
+	}
+}
+
+func TestSimulatedMPCSigner_Sign_RejectsForeignPath(t *testing.T) {
+	w, _ := NewWallet(testMnemonic)
+	signer := NewSimulatedMPCSigner(w.seed)
+	btcPath, _ := ParseDerivationPath("m/84'/1'/0'/0/0")
+
+	_, err := signer.Sign(context.Background(), SignRequest{
+		Chain:   EthereumSepolia,
+		Payload: make([]byte, 32),
+		Path:    btcPath,
+	})
+	if !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("err = %v, want ErrInvalidPath", err)
+	}
+	// The mainnet coin type must not sign for testnet either
+	mainPath, _ := ParseDerivationPath("m/84'/0'/0'/0/0")
+	if _, err := signer.PublicKeyAt(context.Background(), BitcoinTestnet, mainPath); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("err = %v, want ErrInvalidPath", err)
+	}
+}
@@ -2,17 +2,19 @@
 package wallet
 
 import (
-	"bytes"
 	"context"
 	"crypto/ecdsa"
-	"crypto/rand"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
 	"math/big"
 
+	"andi-custodian/pkg/chains"
+
 	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -20,6 +22,9 @@ import (
 type SignRequest struct {
 	Chain   Chain
 	Payload []byte // raw hash to sign (e.g., ETH tx hash or BTC sighash)
+	// Path selects the key, e.g. the path a deposit address was issued at. Nil
+	// means the chain's default address, the one Wallet.DeriveAddress returns.
+	Path DerivationPath
 }
 
 // Signer signs transactions using secure, verifiable cryptography.
@@ -27,17 +32,39 @@ type Signer interface {
 	Sign(ctx context.Context, req SignRequest) ([]byte, error)
 }
 
+// PublicKeyProvider is implemented by signers that can reveal the public key
+// they sign with. Bitcoin witnesses carry the public key next to the signature.
+type PublicKeyProvider interface {
+	// PublicKey returns the key of the chain's default address.
+	PublicKey(ctx context.Context, chain Chain) ([]byte, error)
+	// PublicKeyAt returns the key at path; a nil path is the default address.
+	PublicKeyAt(ctx context.Context, chain Chain, path DerivationPath) ([]byte, error)
+}
+
+// SeedSource lends the root seed to fn for the duration of the call, e.g. a
+// keystore while it is unlocked. fn must not keep the seed after returning.
+type SeedSource interface {
+	WithSeed(fn func(seed []byte) error) error
+}
+
 // WalletSeed provides the root entropy for key derivation.
 // In a real MPC system, this would never be held in one place.
 type WalletSeed struct {
 	Seed []byte // BIP-39 seed (64 bytes)
 }
 
+// WithSeed lends the seed to fn.
+func (w WalletSeed) WithSeed(fn func(seed []byte) error) error {
+	return fn(w.Seed)
+}
+
 // SimulatedMPCSigner simulates an MPC signing service.
-// It derives a root private key from the seed and signs locally.
+// It derives keys from the seed along the same paths as Wallet and signs locally,
+// so its signatures verify against the addresses the wallet hands out. Private
+// keys exist only for the duration of a call and are zeroed afterwards.
 // In production, this would be replaced with a gRPC call to an MPC coordinator.
 type SimulatedMPCSigner struct {
-	seed WalletSeed
+	seed SeedSource
 }
 
 var (
@@ -55,95 +82,153 @@ var erc20ABIJson = []byte(`[
 	}
 ]`)
 
+// IsEVMChain reports whether c signs like Ethereum: secp256k1 keys and
+// [R || S || V] signatures over transaction hashes. That holds for every
+// network in the networks registry.
+func IsEVMChain(c Chain) bool {
+	return c.Family() == chains.FamilyEVM
+}
+
+// IsBitcoinChain reports whether c is a Bitcoin network: mainnet, testnet,
+// signet or regtest. They share keys and DER signatures; only addresses differ.
+func IsBitcoinChain(c Chain) bool {
+	return c.Family() == chains.FamilyUTXO
+}
+
+// NewSimulatedMPCSigner returns a signer holding seed for its whole life.
 func NewSimulatedMPCSigner(seed []byte) *SimulatedMPCSigner {
-	return &SimulatedMPCSigner{
-		seed: WalletSeed{Seed: seed},
-	}
+	return NewSimulatedMPCSignerFrom(WalletSeed{Seed: seed})
 }
 
-// Sign derives a private key from the seed and signs the payload.
+// NewSimulatedMPCSignerFrom returns a signer that borrows the seed from src
+// for each call, so a locked keystore stops it from signing.
+func NewSimulatedMPCSignerFrom(src SeedSource) *SimulatedMPCSigner {
+	return &SimulatedMPCSigner{seed: src}
+}
+
+// Sign derives the private key at the request's path and signs the payload.
 // It always verifies the signature before returning.
 func (s *SimulatedMPCSigner) Sign(ctx context.Context, req SignRequest) ([]byte, error) {
-	if len(s.seed.Seed) < 32 {
-		return nil, errors.New("seed too short for private key derivation")
-	}
-
-	// Use first 32 bytes as root private key (deterministic, recoverable from mnemonic)
-	privKey, _ := btcec.PrivKeyFromBytes(s.seed.Seed[:32])
-	if privKey == nil {
-		return nil, errors.New("invalid private key from seed")
+	path, err := signingPath(req.Chain, req.Path)
+	if err != nil {
+		return nil, err
 	}
-	goPub := privKey.PubKey().ToECDSA()
-	goPriv := privKey.ToECDSA()
 	var sig []byte
+	err = s.seed.WithSeed(func(seed []byte) error {
+		sig, err = signWithSeed(seed, req.Chain, path, req.Payload)
+		return err
+	})
+	return sig, err
+}
 
-	switch req.Chain {
-	case EthereumSepolia, AvalancheFuji:
-		sig, err := crypto.Sign(req.Payload, goPriv)
+// signWithSeed signs payload with the key at path.
+func signWithSeed(seed []byte, chain Chain, path DerivationPath, payload []byte) ([]byte, error) {
+	switch chain.Family() {
+	case chains.FamilyEVM:
+		privKey, err := secp256k1Key(seed, path)
+		if err != nil {
+			return nil, err
+		}
+		defer privKey.Zero()
+		// Compact signatures are [27+V || R || S]; Ethereum wants [R || S || V]
+		compact, err := btcecdsa.SignCompact(privKey, payload, false)
 		if err != nil {
 			return nil, fmt.Errorf("ethereum sign failed: %w", err)
 		}
-		// crypto.Sign already returns 65-byte sig with v=27/28
-		return sig, nil
+		return append(compact[1:], compact[0]-27), nil
 
-	case BitcoinTestnet:
-		// Sign with Go stdlib
-		r, s, err := ecdsa.Sign(rand.Reader, goPriv, req.Payload)
+	case chains.FamilyUTXO:
+		privKey, err := secp256k1Key(seed, path)
 		if err != nil {
-			return nil, fmt.Errorf("bitcoin sign failed: %w", err)
+			return nil, err
 		}
-
-		// Verify using package function
-		if !ecdsa.Verify(goPub, req.Payload, r, s) {
+		defer privKey.Zero()
+		// RFC 6979 deterministic nonce with low-S normalization (BIP-146),
+		// so the witness is standard and accepted by the mempool.
+		sig := btcecdsa.Sign(privKey, payload)
+		if !sig.Verify(payload, privKey.PubKey()) {
 			return nil, errors.New("verification failed")
 		}
+		return sig.Serialize(), nil
 
-		der := derEncodeSignature(r, s)
-		return der, nil
-
-	case SolanaDevnet:
-		return s.SignSolana(ctx, req.Payload)
+	case chains.FamilySolana:
+		priv, err := deriveEd25519(seed, path)
+		if err != nil {
+			return nil, err
+		}
+		defer clear(priv)
+		return ed25519.Sign(priv, payload), nil
 
 	default:
-		return nil, fmt.Errorf("unsupported chain: %s", req.Chain)
+		return nil, fmt.Errorf("unsupported chain: %s", chain)
 	}
+}
 
-	return sig, nil
+// PublicKey returns the key Sign uses for a chain's default address:
+// compressed SEC1 for secp256k1 chains, raw 32 bytes for Solana.
+func (s *SimulatedMPCSigner) PublicKey(ctx context.Context, chain Chain) ([]byte, error) {
+	return s.PublicKeyAt(ctx, chain, nil)
 }
 
-// derEncodeSignature returns a DER-encoded ECDSA signature (ASN.1 SEQUENCE of two INTEGERs)
-// This matches Bitcoin's strict DER requirements (BIP-66).
-func derEncodeSignature(r, s *big.Int) []byte {
-	// Handle sign bit (prepend 0x00 if high bit is set)
-	encodeInt := func(n *big.Int) []byte {
-		b := n.Bytes()
-		if len(b) == 0 {
-			b = []byte{0x00}
+// PublicKeyAt returns the key Sign uses for a request with the given path.
+func (s *SimulatedMPCSigner) PublicKeyAt(ctx context.Context, chain Chain, path DerivationPath) ([]byte, error) {
+	path, err := signingPath(chain, path)
+	if err != nil {
+		return nil, err
+	}
+	var pub []byte
+	err = s.seed.WithSeed(func(seed []byte) error {
+		if chain.Family() == chains.FamilySolana {
+			priv, err := deriveEd25519(seed, path)
+			if err != nil {
+				return err
+			}
+			pub = append([]byte(nil), priv.Public().(ed25519.PublicKey)...)
+			clear(priv)
+			return nil
 		}
-		if b[0]&0x80 != 0 {
-			// Prepend zero to make it positive
-			b = append([]byte{0x00}, b...)
+		privKey, err := secp256k1Key(seed, path)
+		if err != nil {
+			return err
 		}
-		return b
-	}
-
-	rEnc := encodeInt(r)
-	sEnc := encodeInt(s)
-	seqLen := 2 + len(rEnc) + 2 + len(sEnc)
-
-	var buf bytes.Buffer
-	buf.WriteByte(0x30) // SEQUENCE
-	buf.WriteByte(byte(seqLen))
-
-	buf.WriteByte(0x02) // INTEGER
-	buf.WriteByte(byte(len(rEnc)))
-	buf.Write(rEnc)
+		pub = privKey.PubKey().SerializeCompressed()
+		privKey.Zero()
+		return nil
+	})
+	return pub, err
+}
 
-	buf.WriteByte(0x02) // INTEGER
-	buf.WriteByte(byte(len(sEnc)))
-	buf.Write(sEnc)
+// signingPath resolves the path a request signs with, defaulting to the
+// chain's default address. A path outside the chain's purpose and coin type is
+// refused, so a key issued for one chain never signs for another.
+func signingPath(chain Chain, path DerivationPath) (DerivationPath, error) {
+	var def DerivationPath
+	var err error
+	if chain.Family() == chains.FamilySolana {
+		def, err = SolanaPath(0)
+	} else {
+		def, _, err = chainPath(chain, 0, ChangeExternal, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if path == nil {
+		return def, nil
+	}
+	if len(path) != len(def) || path[0] != def[0] || path[1] != def[1] {
+		return nil, fmt.Errorf("%w: %s is not a %s key path", ErrInvalidPath, path, chain)
+	}
+	return path, nil
+}
 
-	return buf.Bytes()
+// secp256k1Key derives the private key at path. The caller zeroes it.
+func secp256k1Key(seed []byte, path DerivationPath) (*btcec.PrivateKey, error) {
+	key, err := (&Wallet{seed: seed}).deriveKey(path)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+	return key.ECPrivKey()
 }
 
 func computeEthereumTxHash(rawTx []byte, chainID *big.Int) ([]byte, error) {
@@ -151,7 +236,7 @@ func computeEthereumTxHash(rawTx []byte, chainID *big.Int) ([]byte, error) {
 	if err := rlp.DecodeBytes(rawTx, tx); err != nil {
 		return nil, err
 	}
-	signer := types.NewEIP155Signer(chainID)
+	signer := types.NewLondonSigner(chainID)
 	return signer.Hash(tx).Bytes(), nil
 }
 
@@ -0,0 +1,50 @@
+// slip10.go
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+)
+
+// solanaCoinType is Solana's BIP-44 coin type.
+const solanaCoinType = 501
+
+// SolanaPath returns m/44'/501'/account'/0', the path Solana wallets and
+// solana-keygen derive account keys at.
+func SolanaPath(account uint32) (DerivationPath, error) {
+	if account >= Hardened {
+		return nil, fmt.Errorf("%w: account %d out of range", ErrInvalidPath, account)
+	}
+	return DerivationPath{Hardened + purposeBIP44, Hardened + solanaCoinType, Hardened + account, Hardened}, nil
+}
+
+// deriveEd25519 derives an Ed25519 key from a seed along path as SLIP-10
+// specifies. Ed25519 has no public derivation, so every level must be hardened.
+// Intermediate keys are zeroed; the caller zeroes the result.
+func deriveEd25519(seed []byte, path DerivationPath) (ed25519.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	defer func() { clear(sum) }()
+	key, chainCode := sum[:32], sum[32:]
+
+	for _, idx := range path {
+		if idx < Hardened {
+			return nil, fmt.Errorf("%w: Ed25519 keys only derive hardened children, not %s", ErrInvalidPath, path)
+		}
+		data := make([]byte, 0, 37)
+		data = append(data, 0)
+		data = append(data, key...)
+		data = binary.BigEndian.AppendUint32(data, idx)
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		clear(data)
+		clear(sum)
+		sum = mac.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+	return ed25519.NewKeyFromSeed(key), nil
+}
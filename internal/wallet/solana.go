@@ -9,15 +9,15 @@ import (
 	"github.com/btcsuite/btcutil/base58"
 )
 
-// DeriveSolanaKeypair derives a Solana Ed25519 keypair from the first 32 bytes of the seed.
-// Note: Real Solana derivation uses BIP-44 path m/44'/501'/0'/0', but for simulation,
-// here use seed[:32] as the secret key seed (sufficient for demo).
+// DeriveSolanaKeypair derives the Ed25519 keypair of the first Solana account,
+// m/44'/501'/0'/0', from a BIP-39 seed: the key Phantom, Solflare and
+// solana-keygen recover from the same mnemonic.
 func DeriveSolanaKeypair(seed []byte) (ed25519.PrivateKey, error) {
-	if len(seed) < 32 {
-		return nil, errors.New("seed must be at least 32 bytes")
+	if len(seed) < 16 {
+		return nil, errors.New("seed must be at least 16 bytes")
 	}
-	secret := seed[:32]
-	return ed25519.NewKeyFromSeed(secret), nil
+	path, _ := SolanaPath(0)
+	return deriveEd25519(seed, path)
 }
 
 // DeriveSolanaAddress returns the Solana public key as a base58-encoded string.
@@ -32,9 +32,5 @@ func DeriveSolanaAddress(seed []byte) (string, error) {
 
 // SignSolana signs a message using Ed25519.
 func (s *SimulatedMPCSigner) SignSolana(ctx context.Context, msg []byte) ([]byte, error) {
-	priv, err := DeriveSolanaKeypair(s.seed.Seed)
-	if err != nil {
-		return nil, err
-	}
-	return ed25519.Sign(priv, msg), nil
+	return s.Sign(ctx, SignRequest{Chain: SolanaDevnet, Payload: msg})
 }
@@ -3,9 +3,13 @@ package wallet
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/hex"
 	"github.com/tyler-smith/go-bip39"
 	"testing"
 
+	"github.com/btcsuite/btcutil/base58"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,3 +31,39 @@ func TestSimulatedMPCSigner_SignSolana(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, sig, 64) // Ed25519 signatures are 64 bytes
 }
+
+func TestDeriveEd25519_SLIP10Vector(t *testing.T) {
+	// SLIP-10 test vector 1 for ed25519, chain m/0H
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	priv, err := deriveEd25519(seed, DerivationPath{Hardened})
+	assert.NoError(t, err)
+	assert.Equal(t, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3", hex.EncodeToString(priv.Seed()))
+	assert.Equal(t, "8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c", hex.EncodeToString(priv.Public().(ed25519.PublicKey)))
+
+	_, err = deriveEd25519(seed, DerivationPath{Hardened, 1})
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
+
+func TestDeriveSolanaAddress_MatchesWallets(t *testing.T) {
+	// The address Phantom and solana-keygen show for this mnemonic
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	addr, err := DeriveSolanaAddress(bip39.NewSeed(mnemonic, ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk", addr)
+}
+
+func TestSimulatedMPCSigner_SignSolana_Verifies(t *testing.T) {
+	seed := bip39.NewSeed(testMnemonic, "")
+	signer := NewSimulatedMPCSigner(seed)
+
+	addr, err := DeriveSolanaAddress(seed)
+	assert.NoError(t, err)
+	pub, err := signer.PublicKey(context.Background(), SolanaDevnet)
+	assert.NoError(t, err)
+	assert.Equal(t, addr, base58.Encode(pub))
+
+	msg := []byte("hello solana")
+	sig, err := signer.SignSolana(context.Background(), msg)
+	assert.NoError(t, err)
+	assert.True(t, ed25519.Verify(pub, msg, sig))
+}
@@ -3,18 +3,15 @@
 package wallet
 
 import (
-	"errors"
 	"github.com/btcsuite/btcd/btcec/v2"
 )
 
+// GetPublicKeyFromSeed returns the public key of the chain's default address,
+// the key SimulatedMPCSigner signs with when a request carries no path.
 func GetPublicKeyFromSeed(seed []byte, chain Chain) (*btcec.PublicKey, error) {
-	if chain == BitcoinTestnet {
-		privKey, _ := btcec.PrivKeyFromBytes(seed[:32])
-		if privKey == nil {
-			return nil, errors.New("invalid seed for BTC key")
-		}
-		return privKey.PubKey(), nil // same key as signer!
+	derived, err := (&Wallet{seed: seed}).DeriveAddress(chain)
+	if err != nil {
+		return nil, err
 	}
-	// Ethereum can use HD or seed — but for consistency, consider seed too
-	return nil, errors.New("unsupported chain")
+	return btcec.ParsePubKey(derived.PublicKey)
 }
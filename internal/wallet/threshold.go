@@ -2,53 +2,228 @@
 package wallet
 
 import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"andi-custodian/internal/shamir"
 )
 
+var (
+	// ErrInvalidShare is returned for backup shares that are malformed, fail
+	// their checksum or do not match their commitments.
+	ErrInvalidShare = errors.New("invalid backup share")
+	// ErrShareMismatch is returned when restoring from shares of different backups.
+	ErrShareMismatch = errors.New("shares are from different backups")
+	// ErrTooFewShares is returned when restoring from fewer shares than the threshold.
+	ErrTooFewShares = shamir.ErrTooFewShares
+)
+
+// shareVersion is the base58check version byte of serialized shares.
+const shareVersion = 0x01
+
 // ThresholdPolicy defines how many shares are required to sign.
 type ThresholdPolicy struct {
 	Threshold int // e.g., 2
 	Total     int // e.g., 3
 }
 
-// Share represents a simulated MPC key share (in real MPC, this would be a partial key).
+// Share is one share of a seed backup. The seed is sealed under a random key
+// and the key is Shamir-shared, so every share carries the same ciphertext
+// and Feldman commitments next to its own point on the sharing polynomial.
+// Sharing a full-entropy key rather than the seed keeps every commitment as
+// hard to invert as a private key.
 type Share struct {
-	ID  int
-	Key []byte // simulated; real MPC would use secret sharing
+	ID          int // x coordinate, 1..Total
+	Threshold   int
+	Value       btcec.ModNScalar
+	Commitments shamir.Commitments
+	Nonce       []byte
+	Ciphertext  []byte
 }
 
-// ThresholdKey simulates an MPC-managed key with policy.
+// ThresholdKey is a seed split into shares with a policy. It holds no copy of
+// the seed; RestoreSeed rebuilds it from any Threshold of the shares.
 type ThresholdKey struct {
-	Policy  ThresholdPolicy
-	Shares  []Share
-	RootKey []byte // for simulation only — NEVER in production
+	Policy ThresholdPolicy
+	Shares []*Share
 }
 
-// NewThresholdKeyFromSeed creates a simulated threshold key from a BIP-39 seed.
-// In real MPC, this would be done via distributed key generation (DKG).
+// NewThresholdKeyFromSeed splits a BIP-39 seed into policy.Total shares, any
+// policy.Threshold of which restore it.
+// In real MPC, the key would come from distributed key generation (DKG) and
+// never exist in one place.
 func NewThresholdKeyFromSeed(seed []byte, policy ThresholdPolicy) (*ThresholdKey, error) {
-	if policy.Threshold < 1 || policy.Threshold > policy.Total {
+	if policy.Threshold < 1 || policy.Threshold > policy.Total || policy.Total > shamir.MaxShares {
 		return nil, errors.New("invalid threshold policy")
 	}
-
-	// For simulation: use first 32 bytes as root key
-	rootKey := seed[:32]
-	if len(rootKey) != 32 {
+	if len(seed) < 16 {
 		return nil, errors.New("seed too short")
 	}
 
-	// Simulate shares (in real MPC, these would be generated via DKG)
-	shares := make([]Share, policy.Total)
-	for i := 0; i < policy.Total; i++ {
-		// Deterministic "share" for demo (not cryptographically secure!)
-		h := sha256.Sum256(append(rootKey, byte(i)))
-		shares[i] = Share{ID: i + 1, Key: h[:32]}
+	key, err := shamir.RandomScalar()
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+	points, commitments, err := shamir.Split(key, policy.Threshold, policy.Total, nil)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := backupCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce, seed, backupHeader(policy.Threshold, commitments))
+
+	tk := &ThresholdKey{Policy: policy, Shares: make([]*Share, len(points))}
+	for i, p := range points {
+		tk.Shares[i] = &Share{
+			ID:          int(p.Index),
+			Threshold:   policy.Threshold,
+			Value:       p.Value,
+			Commitments: commitments,
+			Nonce:       nonce,
+			Ciphertext:  ciphertext,
+		}
+	}
+	return tk, nil
+}
+
+// RestoreSeed rebuilds the seed from at least the threshold of one backup's
+// shares. Every share is checked against the commitments first, so a
+// tampered or mistyped share is reported instead of yielding a wrong seed.
+func RestoreSeed(shares []*Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("%w: no shares", ErrTooFewShares)
+	}
+	first := shares[0]
+	points := make([]shamir.Share, len(shares))
+	for i, s := range shares {
+		if s.Threshold != first.Threshold || !s.Commitments.Equal(first.Commitments) ||
+			!bytes.Equal(s.Nonce, first.Nonce) || !bytes.Equal(s.Ciphertext, first.Ciphertext) {
+			return nil, fmt.Errorf("%w: share %d", ErrShareMismatch, s.ID)
+		}
+		if s.ID < 1 || s.ID > shamir.MaxShares {
+			return nil, fmt.Errorf("%w: share index %d", ErrInvalidShare, s.ID)
+		}
+		points[i] = shamir.Share{Index: uint8(s.ID), Value: s.Value}
+	}
+	key, err := shamir.Combine(points, first.Commitments)
+	if errors.Is(err, shamir.ErrInvalidShare) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidShare, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+	aead, err := backupCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := aead.Open(nil, first.Nonce, first.Ciphertext, backupHeader(first.Threshold, first.Commitments))
+	if err != nil {
+		return nil, fmt.Errorf("%w: backup does not decrypt", ErrInvalidShare)
+	}
+	return seed, nil
+}
+
+// backupCipher returns the AEAD the seed is sealed with under the shared key.
+func backupCipher(key *btcec.ModNScalar) (cipher.AEAD, error) {
+	b := key.Bytes()
+	defer clear(b[:])
+	h := sha256.New()
+	h.Write([]byte("andi-custodian seed backup"))
+	h.Write(b[:])
+	k := h.Sum(nil)
+	defer clear(k)
+	return chacha20poly1305.NewX(k)
+}
+
+// backupHeader binds the ciphertext to its policy and commitments.
+func backupHeader(threshold int, commitments shamir.Commitments) []byte {
+	header := []byte{shareVersion, byte(threshold)}
+	for _, c := range commitments {
+		header = append(header, c.SerializeCompressed()...)
 	}
+	return header
+}
 
-	return &ThresholdKey{
-		Policy:  policy,
-		Shares:  shares,
-		RootKey: rootKey,
-	}, nil
+// String encodes the share as base58check: a checksum catches transcription
+// errors before the share is used. The layout is threshold, index, value,
+// commitments, nonce, then the length-prefixed ciphertext.
+func (s *Share) String() string {
+	var b []byte
+	b = append(b, byte(s.Threshold), byte(s.ID))
+	value := s.Value.Bytes()
+	b = append(b, value[:]...)
+	clear(value[:])
+	for _, c := range s.Commitments {
+		b = append(b, c.SerializeCompressed()...)
+	}
+	b = append(b, s.Nonce...)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s.Ciphertext)))
+	b = append(b, s.Ciphertext...)
+	defer clear(b)
+	return base58.CheckEncode(b, shareVersion)
+}
+
+// ParseShare decodes a share written by Share.String and checks it against
+// its own commitments.
+func ParseShare(encoded string) (*Share, error) {
+	b, version, err := base58.CheckDecode(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidShare, err)
+	}
+	defer clear(b)
+	if version != shareVersion {
+		return nil, fmt.Errorf("%w: unknown version %d", ErrInvalidShare, version)
+	}
+	const nonceSize = chacha20poly1305.NonceSizeX
+	if len(b) < 2+32 {
+		return nil, fmt.Errorf("%w: truncated", ErrInvalidShare)
+	}
+	s := &Share{Threshold: int(b[0]), ID: int(b[1])}
+	if s.Threshold < 1 || s.ID < 1 {
+		return nil, fmt.Errorf("%w: %d-of-n share %d", ErrInvalidShare, s.Threshold, s.ID)
+	}
+	if overflow := s.Value.SetByteSlice(b[2:34]); overflow {
+		return nil, fmt.Errorf("%w: value out of range", ErrInvalidShare)
+	}
+	rest := b[34:]
+	if len(rest) < s.Threshold*33+nonceSize+2 {
+		return nil, fmt.Errorf("%w: truncated", ErrInvalidShare)
+	}
+	for i := 0; i < s.Threshold; i++ {
+		c, err := btcec.ParsePubKey(rest[:33])
+		if err != nil {
+			return nil, fmt.Errorf("%w: commitment %d: %w", ErrInvalidShare, i, err)
+		}
+		s.Commitments = append(s.Commitments, c)
+		rest = rest[33:]
+	}
+	s.Nonce = append([]byte(nil), rest[:nonceSize]...)
+	n := int(binary.BigEndian.Uint16(rest[nonceSize:]))
+	rest = rest[nonceSize+2:]
+	if len(rest) != n {
+		return nil, fmt.Errorf("%w: ciphertext length", ErrInvalidShare)
+	}
+	s.Ciphertext = append([]byte(nil), rest...)
+
+	if !s.Commitments.Verify(shamir.Share{Index: uint8(s.ID), Value: s.Value}) {
+		return nil, fmt.Errorf("%w: share %d does not match its commitments", ErrInvalidShare, s.ID)
+	}
+	return s, nil
 }
@@ -0,0 +1,110 @@
+// threshold_test.go
+package wallet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+func TestThresholdKey_Restore(t *testing.T) {
+	seed := bip39.NewSeed(testMnemonic, "")
+	tk, err := NewThresholdKeyFromSeed(seed, ThresholdPolicy{Threshold: 2, Total: 3})
+	require.NoError(t, err)
+	require.Len(t, tk.Shares, 3)
+
+	for _, pair := range [][2]int{{0, 1}, {0, 2}, {2, 1}} {
+		restored, err := RestoreSeed([]*Share{tk.Shares[pair[0]], tk.Shares[pair[1]]})
+		require.NoError(t, err)
+		assert.Equal(t, seed, restored)
+	}
+	_, err = RestoreSeed(tk.Shares[:1])
+	assert.ErrorIs(t, err, ErrTooFewShares)
+	_, err = RestoreSeed(nil)
+	assert.ErrorIs(t, err, ErrTooFewShares)
+}
+
+func TestThresholdKey_InvalidPolicy(t *testing.T) {
+	seed := bip39.NewSeed(testMnemonic, "")
+	for _, p := range []ThresholdPolicy{{0, 3}, {4, 3}, {2, 256}} {
+		_, err := NewThresholdKeyFromSeed(seed, p)
+		assert.Error(t, err, "%+v", p)
+	}
+	_, err := NewThresholdKeyFromSeed(seed[:8], ThresholdPolicy{Threshold: 2, Total: 3})
+	assert.Error(t, err)
+}
+
+func TestShare_StringRoundTrip(t *testing.T) {
+	seed := bip39.NewSeed(testMnemonic, "")
+	tk, err := NewThresholdKeyFromSeed(seed, ThresholdPolicy{Threshold: 3, Total: 5})
+	require.NoError(t, err)
+
+	var parsed []*Share
+	for _, s := range tk.Shares[2:] {
+		p, err := ParseShare(s.String())
+		require.NoError(t, err)
+		assert.Equal(t, s.ID, p.ID)
+		assert.Equal(t, 3, p.Threshold)
+		parsed = append(parsed, p)
+	}
+	restored, err := RestoreSeed(parsed)
+	require.NoError(t, err)
+	assert.Equal(t, seed, restored)
+}
+
+func TestShare_Tampered(t *testing.T) {
+	seed := bip39.NewSeed(testMnemonic, "")
+	tk, err := NewThresholdKeyFromSeed(seed, ThresholdPolicy{Threshold: 2, Total: 3})
+	require.NoError(t, err)
+
+	// A mistyped character fails the checksum
+	encoded := []byte(tk.Shares[0].String())
+	if encoded[10] == 'a' {
+		encoded[10] = 'b'
+	} else {
+		encoded[10] = 'a'
+	}
+	_, err = ParseShare(string(encoded))
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	// A share re-encoded with a valid checksum but a changed value fails the
+	// Feldman check
+	var one btcec.ModNScalar
+	one.SetInt(1)
+	forged := *tk.Shares[1]
+	forged.Value.Add(&one)
+	_, err = ParseShare(forged.String())
+	assert.ErrorIs(t, err, ErrInvalidShare)
+	_, err = RestoreSeed([]*Share{tk.Shares[0], &forged})
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	// So does one claiming another index
+	moved := *tk.Shares[1]
+	moved.ID = 3
+	_, err = RestoreSeed([]*Share{tk.Shares[0], &moved})
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	// Unknown versions are refused
+	raw, _, err := base58.CheckDecode(tk.Shares[0].String())
+	require.NoError(t, err)
+	_, err = ParseShare(base58.CheckEncode(raw, shareVersion+1))
+	assert.ErrorIs(t, err, ErrInvalidShare)
+	_, err = ParseShare(base58.CheckEncode(raw[:40], shareVersion))
+	assert.ErrorIs(t, err, ErrInvalidShare)
+}
+
+func TestRestoreSeed_MixedBackups(t *testing.T) {
+	seed := bip39.NewSeed(testMnemonic, "")
+	a, err := NewThresholdKeyFromSeed(seed, ThresholdPolicy{Threshold: 2, Total: 3})
+	require.NoError(t, err)
+	b, err := NewThresholdKeyFromSeed(seed, ThresholdPolicy{Threshold: 2, Total: 3})
+	require.NoError(t, err)
+
+	_, err = RestoreSeed([]*Share{a.Shares[0], b.Shares[1]})
+	assert.True(t, errors.Is(err, ErrShareMismatch), "err = %v", err)
+}
@@ -3,8 +3,6 @@ package wallet
 
 import (
 	"context"
-	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 	"github.com/tyler-smith/go-bip39"
 	"testing"
@@ -22,9 +20,12 @@ func TestVerifier_VerifyEthereum(t *testing.T) {
 	// 1. Use the same seed as signer tests
 	seed := getTestSeed()
 
-	// 2. Derive expected address (same way signer does)
-	privKey, _ := btcec.PrivKeyFromBytes(seed[:32])
-	expectedAddr := crypto.PubkeyToAddress(privKey.ToECDSA().PublicKey).Hex()
+	// 2. Derive expected address (same path the signer uses)
+	derived, err := (&Wallet{seed: seed}).DeriveAddress(EthereumSepolia)
+	if err != nil {
+		t.Fatalf("derive address: %v", err)
+	}
+	expectedAddr := derived.Address
 
 	// 3. Use your working signer to produce a valid signature
 	signer := NewSimulatedMPCSigner(seed)
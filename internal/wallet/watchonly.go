@@ -0,0 +1,196 @@
+// watchonly.go
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+
+	"andi-custodian/pkg/chains"
+	"andi-custodian/pkg/networks"
+)
+
+// ErrInvalidXPub is returned for extended keys that cannot back a watch-only account.
+var ErrInvalidXPub = errors.New("invalid extended public key")
+
+// XPubFormat selects how an account's extended public key is serialized.
+type XPubFormat int
+
+const (
+	// XPubBIP32 is the plain BIP-32 encoding: xpub on mainnet, tpub on test networks.
+	XPubBIP32 XPubFormat = iota
+	// XPubSLIP132 marks a Bitcoin key as native segwit (BIP-84) per SLIP-132:
+	// zpub on mainnet, vpub on test networks.
+	XPubSLIP132
+)
+
+// Extended public key version bytes (BIP-32, SLIP-132).
+var (
+	versionXPub = []byte{0x04, 0x88, 0xb2, 0x1e}
+	versionTPub = []byte{0x04, 0x35, 0x87, 0xcf}
+	versionZPub = []byte{0x04, 0xb2, 0x47, 0x46}
+	versionVPub = []byte{0x04, 0x5f, 0x1c, 0xf6}
+)
+
+// xpubVersion returns the version bytes of an account key on chain. EVM
+// tooling only knows xpub, whatever the network.
+func xpubVersion(chain Chain, format XPubFormat) ([]byte, error) {
+	switch chain.Family() {
+	case chains.FamilyUTXO:
+		btcNet, err := networks.Bitcoin(string(chain))
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case format == XPubBIP32 && !btcNet.Testnet:
+			return versionXPub, nil
+		case format == XPubBIP32:
+			return versionTPub, nil
+		case format == XPubSLIP132 && !btcNet.Testnet:
+			return versionZPub, nil
+		case format == XPubSLIP132:
+			return versionVPub, nil
+		}
+	case chains.FamilyEVM:
+		if format == XPubBIP32 {
+			return versionXPub, nil
+		}
+		return nil, fmt.Errorf("SLIP-132 keys are Bitcoin only, not %s", chain)
+	default:
+		return nil, fmt.Errorf("unsupported chain: %s", chain)
+	}
+	return nil, fmt.Errorf("unknown extended key format %d", format)
+}
+
+// ExportXPub returns the extended public key of an account, e.g. a vpub for
+// m/84'/1'/0' on bitcoin-testnet. A WatchOnlyWallet derives the account's
+// receive and change addresses from it without the seed.
+func (w *Wallet) ExportXPub(chain Chain, account uint32, format XPubFormat) (string, error) {
+	version, err := xpubVersion(chain, format)
+	if err != nil {
+		return "", err
+	}
+	path, _, err := chainPath(chain, account, ChangeExternal, 0)
+	if err != nil {
+		return "", err
+	}
+	key, err := w.deriveKey(path[:3])
+	if err != nil {
+		return "", err
+	}
+	defer key.Zero()
+	pub, err := key.Neuter()
+	if err != nil {
+		return "", err
+	}
+	if pub, err = pub.CloneWithVersion(version); err != nil {
+		return "", err
+	}
+	return pub.String(), nil
+}
+
+// WatchOnlyWallet derives addresses from account extended public keys. It
+// holds no private material and cannot sign, so it is what hot
+// infrastructure issuing deposit addresses should run with.
+type WatchOnlyWallet struct {
+	mu       sync.RWMutex
+	accounts map[watchedAccount]*hdkeychain.ExtendedKey
+}
+
+// watchedAccount identifies an imported account.
+type watchedAccount struct {
+	chain   Chain
+	account uint32
+}
+
+// NewWatchOnlyWallet returns a watch-only wallet without accounts.
+func NewWatchOnlyWallet() *WatchOnlyWallet {
+	return &WatchOnlyWallet{accounts: make(map[watchedAccount]*hdkeychain.ExtendedKey)}
+}
+
+// NewWatchOnlyWalletFrom exports the account keys of chains from a seed source
+// and returns a watch-only wallet holding only those, so a process that must
+// sign can still issue deposit addresses without touching the seed again.
+func NewWatchOnlyWalletFrom(src SeedSource, account uint32, chains ...Chain) (*WatchOnlyWallet, error) {
+	watch := NewWatchOnlyWallet()
+	err := src.WithSeed(func(seed []byte) error {
+		w := &Wallet{seed: seed}
+		for _, chain := range chains {
+			xpub, err := w.ExportXPub(chain, account, XPubBIP32)
+			if err != nil {
+				return fmt.Errorf("export %s account %d: %w", chain, account, err)
+			}
+			if _, err := watch.AddAccount(chain, xpub); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return watch, nil
+}
+
+// AddAccount imports an account key exported by Wallet.ExportXPub and returns
+// the account number, which is read from the key. The key must be public, sit
+// at account depth and carry version bytes of the chain's network, so a
+// mainnet key is never used to derive testnet addresses or the reverse. The
+// purpose and coin type levels above it are not recorded in the key and are
+// trusted to be the chain's.
+func (w *WatchOnlyWallet) AddAccount(chain Chain, xpub string) (uint32, error) {
+	key, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidXPub, err)
+	}
+	switch {
+	case key.IsPrivate():
+		return 0, fmt.Errorf("%w: refusing a private key in a watch-only wallet", ErrInvalidXPub)
+	case key.Depth() != 3 || key.ChildIndex() < Hardened:
+		return 0, fmt.Errorf("%w: not an account key (depth %d)", ErrInvalidXPub, key.Depth())
+	}
+	if !versionAllowed(chain, key.Version()) {
+		return 0, fmt.Errorf("%w: version %x is not for %s", ErrInvalidXPub, key.Version(), chain)
+	}
+
+	account := key.ChildIndex() - Hardened
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.accounts[watchedAccount{chain, account}] = key
+	return account, nil
+}
+
+// versionAllowed reports whether an account key with the given version bytes
+// may be used on chain, in any format.
+func versionAllowed(chain Chain, version []byte) bool {
+	for _, format := range []XPubFormat{XPubBIP32, XPubSLIP132} {
+		if want, err := xpubVersion(chain, format); err == nil && string(want) == string(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive derives the address at a change branch and index of an imported account.
+func (w *WatchOnlyWallet) Derive(chain Chain, account, change, index uint32) (*DerivedAddress, error) {
+	path, btcNet, err := chainPath(chain, account, change, index)
+	if err != nil {
+		return nil, err
+	}
+	w.mu.RLock()
+	key, ok := w.accounts[watchedAccount{chain, account}]
+	w.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no watch-only key for account %d on %s", account, chain)
+	}
+
+	// Public derivation only reaches the unhardened levels below the account
+	for _, idx := range path[3:] {
+		if key, err = key.Derive(idx); err != nil {
+			return nil, fmt.Errorf("derive %s: %w", path, err)
+		}
+	}
+	return newDerivedAddress(chain, btcNet, key, path)
+}
@@ -0,0 +1,130 @@
+// watchonly_test.go
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// BIP-84 test vector
+const bip84Mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestWallet_ExportXPub_BIP84Vector(t *testing.T) {
+	wallet, _ := NewWallet(bip84Mnemonic)
+	zpub, err := wallet.ExportXPub(BitcoinMainnet, 0, XPubSLIP132)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+	if zpub != want {
+		t.Errorf("zpub = %s, want %s", zpub, want)
+	}
+
+	watch := NewWatchOnlyWallet()
+	if _, err := watch.AddAccount(BitcoinMainnet, zpub); err != nil {
+		t.Fatal(err)
+	}
+	first, err := watch.Derive(BitcoinMainnet, 0, ChangeExternal, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Address != "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu" {
+		t.Errorf("First receive address = %s", first.Address)
+	}
+}
+
+func TestWallet_ExportXPub_Formats(t *testing.T) {
+	wallet, _ := NewWallet(testMnemonic)
+	for _, tc := range []struct {
+		chain  Chain
+		format XPubFormat
+		prefix string
+	}{
+		{BitcoinMainnet, XPubBIP32, "xpub"},
+		{BitcoinMainnet, XPubSLIP132, "zpub"},
+		{BitcoinTestnet, XPubBIP32, "tpub"},
+		{BitcoinRegtest, XPubSLIP132, "vpub"},
+		{EthereumSepolia, XPubBIP32, "xpub"},
+	} {
+		xpub, err := wallet.ExportXPub(tc.chain, 0, tc.format)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.chain, err)
+		}
+		if !strings.HasPrefix(xpub, tc.prefix) {
+			t.Errorf("%s format %d: %s, want %s prefix", tc.chain, tc.format, xpub, tc.prefix)
+		}
+	}
+	if _, err := wallet.ExportXPub(EthereumSepolia, 0, XPubSLIP132); err == nil {
+		t.Error("Exported a SLIP-132 key for an EVM chain")
+	}
+	if _, err := wallet.ExportXPub(SolanaDevnet, 0, XPubBIP32); err == nil {
+		t.Error("Exported an extended key for Solana")
+	}
+}
+
+func TestWatchOnlyWallet_MatchesSeed(t *testing.T) {
+	wallet, _ := NewWallet(testMnemonic)
+	watch := NewWatchOnlyWallet()
+	for _, tc := range []struct {
+		chain   Chain
+		account uint32
+		format  XPubFormat
+	}{
+		{BitcoinTestnet, 0, XPubSLIP132},
+		{BitcoinSignet, 4, XPubBIP32},
+		{EthereumSepolia, 1, XPubBIP32},
+	} {
+		xpub, _ := wallet.ExportXPub(tc.chain, tc.account, tc.format)
+		account, err := watch.AddAccount(tc.chain, xpub)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.chain, err)
+		}
+		if account != tc.account {
+			t.Errorf("%s: account %d read as %d", tc.chain, tc.account, account)
+		}
+		for _, at := range [][2]uint32{{ChangeExternal, 0}, {ChangeExternal, 9}, {ChangeInternal, 3}} {
+			want, _ := wallet.Derive(tc.chain, tc.account, at[0], at[1])
+			got, err := watch.Derive(tc.chain, tc.account, at[0], at[1])
+			if err != nil {
+				t.Fatalf("%s %v: %v", tc.chain, at, err)
+			}
+			if got.Address != want.Address || got.Path.String() != want.Path.String() {
+				t.Errorf("%s %v: watch-only %s at %s, seed %s at %s", tc.chain, at, got.Address, got.Path, want.Address, want.Path)
+			}
+		}
+	}
+
+	if _, err := watch.Derive(BitcoinTestnet, 1, ChangeExternal, 0); err == nil {
+		t.Error("Derived for an account that was never imported")
+	}
+	if _, err := watch.Derive(BitcoinTestnet, 0, 2, 0); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Bad change branch: got %v", err)
+	}
+}
+
+func TestWatchOnlyWallet_AddAccount_Rejects(t *testing.T) {
+	wallet, _ := NewWallet(testMnemonic)
+	mainnet, _ := wallet.ExportXPub(BitcoinMainnet, 0, XPubSLIP132)
+	testnet, _ := wallet.ExportXPub(BitcoinTestnet, 0, XPubBIP32)
+	accountKey, _ := wallet.deriveKey(DerivationPath{Hardened + 84, Hardened + 1, Hardened})
+	master, _ := wallet.deriveKey(nil)
+	masterPub, _ := master.Neuter()
+
+	watch := NewWatchOnlyWallet()
+	for name, tc := range map[string]struct {
+		chain Chain
+		key   string
+	}{
+		"mainnet key on testnet": {BitcoinTestnet, mainnet},
+		"testnet key on mainnet": {BitcoinMainnet, testnet},
+		"testnet key on EVM":     {EthereumSepolia, testnet},
+		"private key":            {BitcoinMainnet, accountKey.String()},
+		"master key":             {BitcoinMainnet, masterPub.String()},
+		"garbage":                {BitcoinTestnet, "vpub123"},
+	} {
+		if _, err := watch.AddAccount(tc.chain, tc.key); !errors.Is(err, ErrInvalidXPub) {
+			t.Errorf("%s: got %v, want ErrInvalidXPub", name, err)
+		}
+	}
+}
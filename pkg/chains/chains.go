@@ -0,0 +1,126 @@
+// Package chains describes the blockchains the custodian supports. A Chain is
+// the name clients send on the wire ("bitcoin-testnet", "ethereum-sepolia");
+// Lookup resolves it to the family, network and capabilities that building,
+// signing and custody branch on. EVM chains are whatever the networks registry
+// holds, so chains added at runtime are picked up without code changes.
+package chains
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"andi-custodian/pkg/networks"
+)
+
+// ErrUnknownChain is returned for chain names that are not supported.
+var ErrUnknownChain = errors.New("unknown chain")
+
+// Chain is a supported blockchain, identified by its wire name.
+type Chain string
+
+const (
+	BitcoinMainnet  Chain = "bitcoin-mainnet"
+	BitcoinTestnet  Chain = "bitcoin-testnet"
+	BitcoinSignet   Chain = "bitcoin-signet"
+	BitcoinRegtest  Chain = "bitcoin-regtest"
+	EthereumSepolia Chain = "ethereum-sepolia"
+	AvalancheFuji   Chain = "avalanche-fuji"
+	SolanaDevnet    Chain = "solana-devnet"
+)
+
+func (c Chain) String() string {
+	return string(c)
+}
+
+// Family groups chains that share keys, transaction formats and signing.
+type Family string
+
+const (
+	FamilyUTXO   Family = "utxo"   // Bitcoin: secp256k1, P2WPKH outputs, DER signatures
+	FamilyEVM    Family = "evm"    // secp256k1, account nonces, [R || S || V] signatures
+	FamilySolana Family = "solana" // Ed25519, recent blockhashes
+)
+
+// Family returns the chain's family, or "" for unknown chains.
+func (c Chain) Family() Family {
+	d, err := Lookup(c)
+	if err != nil {
+		return ""
+	}
+	return d.Family
+}
+
+// Capability is a feature a chain supports.
+type Capability uint32
+
+const (
+	CapNativeTransfer Capability = 1 << iota // transfers of the native coin
+	CapTokenTransfer                         // fungible token transfers (ERC-20, SPL)
+	CapNFTTransfer                           // NFT transfers
+	CapAccountNonce                          // transactions are ordered by a per-account nonce
+	CapUTXO                                  // transactions spend unspent outputs
+)
+
+var familyCapabilities = map[Family]Capability{
+	FamilyUTXO:   CapNativeTransfer | CapUTXO,
+	FamilyEVM:    CapNativeTransfer | CapTokenTransfer | CapNFTTransfer | CapAccountNonce,
+	FamilySolana: CapNativeTransfer | CapTokenTransfer,
+}
+
+// Descriptor is everything known about a chain.
+type Descriptor struct {
+	Chain        Chain
+	Family       Family
+	Network      string // network within the family, e.g. "mainnet", "sepolia", "devnet"
+	Testnet      bool
+	Capabilities Capability
+}
+
+// Supports reports whether the chain has every capability in c.
+func (d Descriptor) Supports(c Capability) bool {
+	return d.Capabilities&c == c
+}
+
+// solanaClusters are the Solana clusters the custodian can use, by whether
+// they are test clusters.
+var solanaClusters = map[Chain]bool{
+	SolanaDevnet: true,
+}
+
+// Lookup returns the descriptor of a chain, or an error wrapping ErrUnknownChain.
+func Lookup(c Chain) (Descriptor, error) {
+	d := Descriptor{Chain: c, Network: networkName(c)}
+	if n, err := networks.Bitcoin(string(c)); err == nil {
+		d.Family, d.Testnet = FamilyUTXO, n.Testnet
+	} else if n, err := networks.Get(string(c)); err == nil {
+		d.Family, d.Testnet = FamilyEVM, n.Testnet
+	} else if testnet, ok := solanaClusters[c]; ok {
+		d.Family, d.Testnet = FamilySolana, testnet
+	} else {
+		return Descriptor{}, fmt.Errorf("%w: %q", ErrUnknownChain, string(c))
+	}
+	d.Capabilities = familyCapabilities[d.Family]
+	return d, nil
+}
+
+// Parse validates a chain name received on the wire.
+func Parse(s string) (Chain, error) {
+	if s == "" {
+		return "", fmt.Errorf("%w: chain is required", ErrUnknownChain)
+	}
+	c := Chain(s)
+	if _, err := Lookup(c); err != nil {
+		return "", err
+	}
+	return c, nil
+}
+
+// networkName is the part of a chain name after the family or project prefix:
+// "signet" for bitcoin-signet, "amoy" for polygon-amoy.
+func networkName(c Chain) string {
+	if _, network, ok := strings.Cut(string(c), "-"); ok {
+		return network
+	}
+	return string(c)
+}
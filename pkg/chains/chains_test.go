@@ -0,0 +1,71 @@
+// chains_test.go
+package chains
+
+import (
+	"math/big"
+	"testing"
+
+	"andi-custodian/pkg/networks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	for _, tc := range []struct {
+		chain   Chain
+		family  Family
+		network string
+		testnet bool
+	}{
+		{BitcoinMainnet, FamilyUTXO, "mainnet", false},
+		{BitcoinRegtest, FamilyUTXO, "regtest", true},
+		{EthereumSepolia, FamilyEVM, "sepolia", true},
+		{AvalancheFuji, FamilyEVM, "fuji", true},
+		{SolanaDevnet, FamilySolana, "devnet", true},
+	} {
+		d, err := Lookup(tc.chain)
+		require.NoError(t, err, tc.chain)
+		assert.Equal(t, tc.family, d.Family, tc.chain)
+		assert.Equal(t, tc.network, d.Network, tc.chain)
+		assert.Equal(t, tc.testnet, d.Testnet, tc.chain)
+		assert.Equal(t, tc.family, tc.chain.Family(), tc.chain)
+	}
+
+	_, err := Lookup("dogecoin-mainnet")
+	assert.ErrorIs(t, err, ErrUnknownChain)
+	assert.Equal(t, Family(""), Chain("dogecoin-mainnet").Family())
+}
+
+func TestDescriptor_Supports(t *testing.T) {
+	btc, _ := Lookup(BitcoinTestnet)
+	assert.True(t, btc.Supports(CapNativeTransfer|CapUTXO))
+	assert.False(t, btc.Supports(CapTokenTransfer))
+	assert.False(t, btc.Supports(CapNativeTransfer|CapAccountNonce))
+
+	sol, _ := Lookup(SolanaDevnet)
+	assert.True(t, sol.Supports(CapTokenTransfer))
+	assert.False(t, sol.Supports(CapAccountNonce))
+
+	eth, _ := Lookup(EthereumSepolia)
+	assert.True(t, eth.Supports(CapTokenTransfer|CapNFTTransfer|CapAccountNonce))
+}
+
+func TestParse(t *testing.T) {
+	c, err := Parse("ethereum-sepolia")
+	require.NoError(t, err)
+	assert.Equal(t, EthereumSepolia, c)
+
+	for _, s := range []string{"", "Ethereum-Sepolia", "bitcoin", "solana-mainnet"} {
+		_, err := Parse(s)
+		assert.ErrorIs(t, err, ErrUnknownChain, s)
+	}
+
+	// EVM networks registered at runtime parse without code changes
+	require.NoError(t, networks.Register(networks.Network{
+		Name: "chains-testnet", ChainID: 424242, FeeStrategy: networks.FeeLegacy, GasPrice: big.NewInt(1),
+		FinalityDepth: 1, Testnet: true, NativeCurrency: networks.Currency{Name: "Ether", Symbol: "ETH", Decimals: 18},
+	}))
+	c, err = Parse("chains-testnet")
+	require.NoError(t, err)
+	assert.Equal(t, FamilyEVM, c.Family())
+}
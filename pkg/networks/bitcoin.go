@@ -0,0 +1,62 @@
+// bitcoin.go
+package networks
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// ErrWrongNetwork is returned for a well-formed address of another network,
+// e.g. a mainnet address given to a testnet wallet.
+var ErrWrongNetwork = errors.New("address is for another network")
+
+// BitcoinNetwork is a Bitcoin network with the parameters that shape its
+// addresses, keys and derivation paths.
+type BitcoinNetwork struct {
+	Name          string
+	Params        *chaincfg.Params
+	CoinType      uint32 // BIP-44 coin type: 0 on mainnet, 1 on every test network
+	FinalityDepth uint64
+	Testnet       bool
+}
+
+var bitcoinNetworks = map[string]BitcoinNetwork{
+	"bitcoin-mainnet": {Name: "bitcoin-mainnet", Params: &chaincfg.MainNetParams, CoinType: 0, FinalityDepth: 6},
+	"bitcoin-testnet": {Name: "bitcoin-testnet", Params: &chaincfg.TestNet3Params, CoinType: 1, FinalityDepth: 6, Testnet: true},
+	"bitcoin-signet":  {Name: "bitcoin-signet", Params: &chaincfg.SigNetParams, CoinType: 1, FinalityDepth: 6, Testnet: true},
+	// Blocks are mined on demand, so one confirmation keeps CI fast
+	"bitcoin-regtest": {Name: "bitcoin-regtest", Params: &chaincfg.RegressionNetParams, CoinType: 1, FinalityDepth: 1, Testnet: true},
+}
+
+// Bitcoin returns the Bitcoin network called name.
+func Bitcoin(name string) (BitcoinNetwork, error) {
+	n, ok := bitcoinNetworks[name]
+	if !ok {
+		return BitcoinNetwork{}, fmt.Errorf("%w: %s", ErrUnknownNetwork, name)
+	}
+	return n, nil
+}
+
+// IsBitcoin reports whether name is a Bitcoin network.
+func IsBitcoin(name string) bool {
+	_, ok := bitcoinNetworks[name]
+	return ok
+}
+
+// DecodeAddress parses a Bitcoin address and checks it belongs to the network.
+// btcutil accepts base58 addresses of any network it knows, so without the
+// check a mainnet address would pass as a testnet one. Testnet and signet
+// share their address formats and cannot be told apart.
+func (n BitcoinNetwork) DecodeAddress(addr string) (btcutil.Address, error) {
+	decoded, err := btcutil.DecodeAddress(addr, n.Params)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.IsForNet(n.Params) {
+		return nil, fmt.Errorf("%w: %s is not a %s address", ErrWrongNetwork, addr, n.Name)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,46 @@
+// bitcoin_test.go
+package networks
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcoinNetworks(t *testing.T) {
+	mainnet, err := Bitcoin("bitcoin-mainnet")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), mainnet.CoinType)
+	assert.False(t, mainnet.Testnet)
+
+	regtest, err := Bitcoin("bitcoin-regtest")
+	require.NoError(t, err)
+	assert.Equal(t, &chaincfg.RegressionNetParams, regtest.Params)
+	assert.Equal(t, uint32(1), regtest.CoinType)
+
+	_, err = Bitcoin("ethereum-sepolia")
+	assert.ErrorIs(t, err, ErrUnknownNetwork)
+	assert.True(t, IsBitcoin("bitcoin-signet"))
+	assert.False(t, IsBitcoin("bitcoin"))
+}
+
+func TestBitcoinNetwork_DecodeAddress(t *testing.T) {
+	const testnetAddr = "tb1q4d750u3s88c6mt8732j2q6gsn23rwwey25xxnm"
+	testnet, _ := Bitcoin("bitcoin-testnet")
+	signet, _ := Bitcoin("bitcoin-signet")
+	mainnet, _ := Bitcoin("bitcoin-mainnet")
+
+	_, err := testnet.DecodeAddress(testnetAddr)
+	assert.NoError(t, err)
+	// Signet shares testnet's address format
+	_, err = signet.DecodeAddress(testnetAddr)
+	assert.NoError(t, err)
+
+	_, err = mainnet.DecodeAddress(testnetAddr)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+	_, err = testnet.DecodeAddress("not an address")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrWrongNetwork)
+}
@@ -0,0 +1,221 @@
+// Package networks is the registry of EVM networks the custodian can use.
+// The built-in networks come from networks.json; deployments add or override
+// networks with their own file, e.g. to set RPC endpoints. The Bitcoin
+// networks are fixed and live in bitcoin.go.
+package networks
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownNetwork is returned for network names and chain IDs that are not registered.
+var ErrUnknownNetwork = errors.New("unknown network")
+
+// FeeStrategy selects how transactions on a network pay for gas.
+type FeeStrategy string
+
+const (
+	FeeEIP1559 FeeStrategy = "eip1559" // max fee and priority fee per gas
+	FeeLegacy  FeeStrategy = "legacy"  // single gas price
+)
+
+// Currency is a network's native coin.
+type Currency struct {
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+}
+
+// Network describes an EVM network.
+type Network struct {
+	Name           string      `json:"name"`
+	ChainID        uint64      `json:"chain_id"`
+	RPCURLs        []string    `json:"rpc_urls,omitempty"` // in order of preference
+	FeeStrategy    FeeStrategy `json:"fee_strategy"`
+	GasPrice       *big.Int    `json:"gas_price_wei"` // default gas price, or max fee per gas under EIP-1559
+	ExplorerURL    string      `json:"explorer_url,omitempty"`
+	FinalityDepth  uint64      `json:"finality_depth"` // confirmations after which a transaction is final
+	Testnet        bool        `json:"testnet"`
+	NativeCurrency Currency    `json:"native_currency"`
+}
+
+// Validate checks that a network is complete enough to build and sign for.
+func (n Network) Validate() error {
+	switch {
+	case n.Name == "":
+		return errors.New("network needs a name")
+	case n.ChainID == 0:
+		return fmt.Errorf("network %s needs a chain ID", n.Name)
+	case n.FeeStrategy != FeeEIP1559 && n.FeeStrategy != FeeLegacy:
+		return fmt.Errorf("network %s: unknown fee strategy %q", n.Name, n.FeeStrategy)
+	case n.GasPrice == nil || n.GasPrice.Sign() <= 0:
+		return fmt.Errorf("network %s needs a positive gas price", n.Name)
+	case n.FinalityDepth == 0:
+		return fmt.Errorf("network %s needs a finality depth", n.Name)
+	case n.NativeCurrency.Symbol == "" || n.NativeCurrency.Decimals < 0:
+		return fmt.Errorf("network %s needs a native currency", n.Name)
+	}
+	return nil
+}
+
+// TxURL links to a transaction on the network's block explorer, or returns ""
+// when none is configured.
+func (n Network) TxURL(txID string) string {
+	if n.ExplorerURL == "" {
+		return ""
+	}
+	return strings.TrimRight(n.ExplorerURL, "/") + "/tx/" + txID
+}
+
+// clone copies the fields callers could otherwise modify through the registry.
+func (n Network) clone() Network {
+	n.RPCURLs = append([]string(nil), n.RPCURLs...)
+	if n.GasPrice != nil {
+		n.GasPrice = new(big.Int).Set(n.GasPrice)
+	}
+	return n
+}
+
+// Registry holds networks by name. Chain IDs are unique across names, so a
+// transaction signed for one network can never be replayed on another.
+type Registry struct {
+	mu        sync.RWMutex
+	byName    map[string]Network
+	byChainID map[uint64]string
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{byName: make(map[string]Network), byChainID: make(map[uint64]string)}
+}
+
+// Add registers a network, replacing any network of the same name.
+func (r *Registry) Add(n Network) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.add(n)
+}
+
+func (r *Registry) add(n Network) error {
+	if err := n.Validate(); err != nil {
+		return err
+	}
+	if other, ok := r.byChainID[n.ChainID]; ok && other != n.Name {
+		return fmt.Errorf("network %s: chain ID %d is already used by %s", n.Name, n.ChainID, other)
+	}
+	if old, ok := r.byName[n.Name]; ok {
+		delete(r.byChainID, old.ChainID)
+	}
+	r.byName[n.Name] = n.clone()
+	r.byChainID[n.ChainID] = n.Name
+	return nil
+}
+
+// configFile is the layout of a networks file.
+type configFile struct {
+	Networks []Network `json:"networks"`
+}
+
+// Load adds every network in a JSON networks file. Nothing is added unless all are valid.
+func (r *Registry) Load(rd io.Reader) error {
+	var cfg configFile
+	dec := json.NewDecoder(rd)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return fmt.Errorf("parse networks: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	staged := &Registry{byName: make(map[string]Network), byChainID: make(map[uint64]string)}
+	for name, n := range r.byName {
+		staged.byName[name] = n
+		staged.byChainID[n.ChainID] = name
+	}
+	for _, n := range cfg.Networks {
+		if err := staged.add(n); err != nil {
+			return err
+		}
+	}
+	r.byName, r.byChainID = staged.byName, staged.byChainID
+	return nil
+}
+
+// Get returns the network called name.
+func (r *Registry) Get(name string) (Network, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n, ok := r.byName[name]
+	if !ok {
+		return Network{}, fmt.Errorf("%w: %s", ErrUnknownNetwork, name)
+	}
+	return n.clone(), nil
+}
+
+// ByChainID returns the network with the given chain ID.
+func (r *Registry) ByChainID(id uint64) (Network, error) {
+	r.mu.RLock()
+	name, ok := r.byChainID[id]
+	r.mu.RUnlock()
+	if !ok {
+		return Network{}, fmt.Errorf("%w: chain ID %d", ErrUnknownNetwork, id)
+	}
+	return r.Get(name)
+}
+
+// All returns every network, sorted by name.
+func (r *Registry) All() []Network {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make([]Network, 0, len(r.byName))
+	for _, n := range r.byName {
+		all = append(all, n.clone())
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+//go:embed networks.json
+var builtin string
+
+var defaultRegistry = func() *Registry {
+	r := NewRegistry()
+	if err := r.Load(strings.NewReader(builtin)); err != nil {
+		panic("networks: built-in networks.json: " + err.Error())
+	}
+	return r
+}()
+
+// Default returns the process-wide registry, preloaded with the built-in networks.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Get returns a network from the default registry.
+func Get(name string) (Network, error) {
+	return defaultRegistry.Get(name)
+}
+
+// Register adds a network to the default registry.
+func Register(n Network) error {
+	return defaultRegistry.Add(n)
+}
+
+// LoadFile adds the networks in a JSON file to the default registry.
+func LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return defaultRegistry.Load(f)
+}
@@ -0,0 +1,114 @@
+// networks_test.go
+package networks
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNetwork(name string, chainID uint64) Network {
+	return Network{
+		Name:           name,
+		ChainID:        chainID,
+		FeeStrategy:    FeeEIP1559,
+		GasPrice:       big.NewInt(1_000_000_000),
+		FinalityDepth:  10,
+		NativeCurrency: Currency{Name: "Ether", Symbol: "ETH", Decimals: 18},
+	}
+}
+
+func TestBuiltinNetworks(t *testing.T) {
+	sepolia, err := Get("ethereum-sepolia")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11155111), sepolia.ChainID)
+	assert.Equal(t, FeeEIP1559, sepolia.FeeStrategy)
+	assert.True(t, sepolia.Testnet)
+	assert.Equal(t, "https://sepolia.etherscan.io/tx/0xabc", sepolia.TxURL("0xabc"))
+
+	fuji, err := Default().ByChainID(43113)
+	require.NoError(t, err)
+	assert.Equal(t, "avalanche-fuji", fuji.Name)
+	assert.Equal(t, "AVAX", fuji.NativeCurrency.Symbol)
+
+	_, err = Get("ethereum-mainnet")
+	assert.ErrorIs(t, err, ErrUnknownNetwork)
+}
+
+func TestRegistry_Load(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Add(testNetwork("devnet", 1337)))
+
+	err := r.Load(strings.NewReader(`{"networks": [{
+		"name": "devnet", "chain_id": 1337, "rpc_urls": ["http://127.0.0.1:8545"],
+		"fee_strategy": "legacy", "gas_price_wei": 7, "finality_depth": 2,
+		"native_currency": {"name": "Ether", "symbol": "ETH", "decimals": 18}
+	}, {
+		"name": "base-sepolia", "chain_id": 84532, "fee_strategy": "eip1559", "gas_price_wei": 1000000,
+		"explorer_url": "https://sepolia.basescan.org/", "finality_depth": 10, "testnet": true,
+		"native_currency": {"name": "Ether", "symbol": "ETH", "decimals": 18}
+	}]}`))
+	require.NoError(t, err)
+
+	// Same name overrides
+	devnet, err := r.Get("devnet")
+	require.NoError(t, err)
+	assert.Equal(t, FeeLegacy, devnet.FeeStrategy)
+	assert.Equal(t, []string{"http://127.0.0.1:8545"}, devnet.RPCURLs)
+	assert.Equal(t, int64(7), devnet.GasPrice.Int64())
+	assert.Equal(t, "", devnet.TxURL("0x1"))
+
+	base, err := r.ByChainID(84532)
+	require.NoError(t, err)
+	assert.Equal(t, "https://sepolia.basescan.org/tx/0x1", base.TxURL("0x1"))
+
+	var names []string
+	for _, n := range r.All() {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{"base-sepolia", "devnet"}, names)
+
+	// Callers cannot change the registry through returned values
+	devnet.GasPrice.SetInt64(1)
+	devnet.RPCURLs[0] = "http://evil"
+	again, _ := r.Get("devnet")
+	assert.Equal(t, int64(7), again.GasPrice.Int64())
+	assert.Equal(t, "http://127.0.0.1:8545", again.RPCURLs[0])
+}
+
+func TestRegistry_RejectsInvalid(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Add(testNetwork("devnet", 1337)))
+
+	for name, mutate := range map[string]func(*Network){
+		"no name":         func(n *Network) { n.Name = "" },
+		"no chain ID":     func(n *Network) { n.ChainID = 0 },
+		"fee strategy":    func(n *Network) { n.FeeStrategy = "cheap" },
+		"no gas price":    func(n *Network) { n.GasPrice = nil },
+		"no depth":        func(n *Network) { n.FinalityDepth = 0 },
+		"no native coin":  func(n *Network) { n.NativeCurrency = Currency{} },
+		"reused chain ID": func(n *Network) { n.ChainID = 1337 },
+	} {
+		n := testNetwork("other", 1)
+		mutate(&n)
+		assert.Error(t, r.Add(n), name)
+	}
+
+	// One bad entry leaves the registry as it was
+	err := r.Load(strings.NewReader(`{"networks": [
+		{"name": "good", "chain_id": 5, "fee_strategy": "legacy", "gas_price_wei": 1, "finality_depth": 1,
+		 "native_currency": {"name": "Ether", "symbol": "ETH", "decimals": 18}},
+		{"name": "bad", "chain_id": 1337, "fee_strategy": "legacy", "gas_price_wei": 1, "finality_depth": 1,
+		 "native_currency": {"name": "Ether", "symbol": "ETH", "decimals": 18}}
+	]}`))
+	assert.Error(t, err)
+	_, err = r.Get("good")
+	assert.True(t, errors.Is(err, ErrUnknownNetwork))
+
+	err = r.Load(strings.NewReader(`{"networks": [{"name": "x", "chainid": 5}]}`))
+	assert.ErrorContains(t, err, "unknown field")
+}
@@ -2,30 +2,61 @@
 package tokens
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
+	"strings"
 
+	"andi-custodian/pkg/chains"
+	"andi-custodian/pkg/networks"
+
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// Amount errors
+var (
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrNegativeAmount  = errors.New("negative amount")
+	ErrTooManyDecimals = errors.New("too many fractional digits")
+	ErrAmountOverflow  = errors.New("amount overflows uint256")
+	maxUint256         = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+)
+
 // Token represents a supported fungible or non-fungible token.
 type Token struct {
 	Name     string
 	Symbol   string
-	Chain    string
-	Contract common.Address
+	Chain    chains.Chain
+	Contract common.Address // EVM token contract
+	Mint     SolanaMint     // Solana SPL token mint
 	Decimals int
 }
 
+// SolanaMint is the address of an SPL token mint. Solana addresses are 32-byte
+// Ed25519 keys written in base58; they do not fit an EVM address.
+type SolanaMint [32]byte
+
+// MustSolanaMint decodes a base58 mint address and panics if it is not 32 bytes.
+// It is meant for registry entries.
+func MustSolanaMint(s string) SolanaMint {
+	var m SolanaMint
+	b := base58.Decode(s)
+	if len(b) != len(m) {
+		panic(fmt.Sprintf("tokens: %q is not a Solana mint address", s))
+	}
+	copy(m[:], b)
+	return m
+}
+
+func (m SolanaMint) String() string {
+	return base58.Encode(m[:])
+}
+
 // --- Ethereum Sepolia ---
 var (
 	// Native
-	ETH_Sepolia = &Token{
-		Name:     "Ethereum",
-		Symbol:   "ETH",
-		Chain:    "ethereum-sepolia",
-		Contract: common.Address{}, // native coin
-		Decimals: 18,
-	}
+	ETH_Sepolia = mustNetworkNative("ethereum-sepolia")
 
 	// Stablecoins
 	USDC_Sepolia = &Token{
@@ -54,16 +85,41 @@ var (
 	}
 )
 
-// --- Avalanche Fuji ---
+// --- Bitcoin ---
 var (
-	AVAX_Fuji = &Token{
-		Name:     "Avalanche",
-		Symbol:   "AVAX",
-		Chain:    "avalanche-fuji",
-		Contract: common.Address{}, // native C-Chain coin
-		Decimals: 18,
+	BTC_Mainnet = &Token{
+		Name:     "Bitcoin",
+		Symbol:   "BTC",
+		Chain:    "bitcoin-mainnet",
+		Decimals: 8,
 	}
 
+	BTC_Testnet = &Token{
+		Name:     "Bitcoin",
+		Symbol:   "BTC",
+		Chain:    "bitcoin-testnet",
+		Decimals: 8,
+	}
+
+	BTC_Signet = &Token{
+		Name:     "Bitcoin",
+		Symbol:   "BTC",
+		Chain:    "bitcoin-signet",
+		Decimals: 8,
+	}
+
+	BTC_Regtest = &Token{
+		Name:     "Bitcoin",
+		Symbol:   "BTC",
+		Chain:    "bitcoin-regtest",
+		Decimals: 8,
+	}
+)
+
+// --- Avalanche Fuji ---
+var (
+	AVAX_Fuji = mustNetworkNative("avalanche-fuji") // native C-Chain coin
+
 	USDCe_Fuji = &Token{
 		Name:     "USD Coin (Avalanche)",
 		Symbol:   "USDC.e",
@@ -74,14 +130,13 @@ var (
 )
 
 // --- Solana Devnet ---
-// Solana doesn't use contract addresses — tokens are PDAs.
-// For simulation, we use a registry of mint addresses.
+// Solana tokens are identified by their SPL mint; balances live in token
+// accounts derived from the owner and the mint.
 var (
 	SOL_Devnet = &Token{
-		Name:   "Solana",
-		Symbol: "SOL",
-		Chain:  "solana-devnet",
-		// Contract = Mint address for native SOL is system program (not used)
+		Name:     "Solana",
+		Symbol:   "SOL",
+		Chain:    "solana-devnet",
 		Decimals: 9,
 	}
 
@@ -89,7 +144,7 @@ var (
 		Name:     "USD Coin (Solana)",
 		Symbol:   "USDC",
 		Chain:    "solana-devnet",
-		Contract: common.HexToAddress("4zMMC9srt5Ri5X14GAgGqhPgJ6Hdw84Yjy818YxdYkG9"), // Devnet USDC mint
+		Mint:     MustSolanaMint("4zMMC9srt5Ri5X14GAgGqhPgJ6Hdw84Yjy818YxdYkG9"), // Devnet USDC mint
 		Decimals: 6,
 	}
 )
@@ -97,6 +152,8 @@ var (
 // AllTokens returns a slice of all supported tokens (useful for tests or CLI).
 func AllTokens() []*Token {
 	return []*Token{
+		// Bitcoin
+		BTC_Mainnet, BTC_Testnet, BTC_Signet, BTC_Regtest,
 		// Ethereum
 		ETH_Sepolia, USDC_Sepolia, USTC_Sepolia, EUTC_Sepolia,
 		// Avalanche
@@ -107,32 +164,129 @@ func AllTokens() []*Token {
 }
 
 // GetTokenBySymbol returns a token by chain and symbol.
-func GetTokenBySymbol(chain, symbol string) (*Token, bool) {
+func GetTokenBySymbol(chain chains.Chain, symbol string) (*Token, bool) {
 	for _, t := range AllTokens() {
 		if t.Chain == chain && t.Symbol == symbol {
 			return t, true
 		}
 	}
+	if t, ok := networkNative(chain); ok && t.Symbol == symbol {
+		return t, true
+	}
 	return nil, false
 }
 
 // IsNative returns true if the token is the chain's native coin.
 func (t *Token) IsNative() bool {
-	return t.Contract == (common.Address{})
+	return t.Contract == (common.Address{}) && t.Mint == (SolanaMint{})
+}
+
+// NativeToken returns the native coin of a chain. Networks registered at
+// runtime get theirs from their configured native currency.
+func NativeToken(chain chains.Chain) (*Token, bool) {
+	for _, t := range AllTokens() {
+		if t.Chain == chain && t.IsNative() {
+			return t, true
+		}
+	}
+	return networkNative(chain)
+}
+
+// networkNative returns the native coin of a network in the networks registry.
+func networkNative(chain chains.Chain) (*Token, bool) {
+	n, err := networks.Get(string(chain))
+	if err != nil {
+		return nil, false
+	}
+	return &Token{
+		Name:     n.NativeCurrency.Name,
+		Symbol:   n.NativeCurrency.Symbol,
+		Chain:    chains.Chain(n.Name),
+		Decimals: n.NativeCurrency.Decimals,
+	}, true
+}
+
+func mustNetworkNative(chain chains.Chain) *Token {
+	t, ok := networkNative(chain)
+	if !ok {
+		panic("tokens: no built-in network " + string(chain))
+	}
+	return t
 }
 
 // ParseAmount converts a decimal string (e.g., "1.5") to base units (e.g., 1500000 for 6 decimals).
-// Simplified for demo — in production, use github.com/shopspring/decimal.
+// Parsing is exact: no floating point is involved. Trailing fractional zeros beyond the
+// token's precision are accepted, any other extra fractional digit is rejected.
 func (t *Token) ParseAmount(amountStr string) (*big.Int, error) {
-	// For demo: assume "1.000000" → 1_000_000
-	if t.Decimals == 6 {
-		return big.NewInt(1_000_000), nil
+	s := strings.TrimSpace(amountStr)
+	if strings.HasPrefix(s, "-") {
+		return nil, fmt.Errorf("%w: %q", ErrNegativeAmount, amountStr)
+	}
+	s = strings.TrimPrefix(s, "+")
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, amountStr)
+	}
+	if hasDot && fracPart == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, amountStr)
 	}
-	if t.Decimals == 18 {
-		return new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil), nil
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, amountStr)
+	}
+
+	if len(fracPart) > t.Decimals {
+		if strings.Trim(fracPart[t.Decimals:], "0") != "" {
+			return nil, fmt.Errorf("%w: %q has more than %d decimals", ErrTooManyDecimals, amountStr, t.Decimals)
+		}
+		fracPart = fracPart[:t.Decimals]
 	}
-	if t.Decimals == 9 {
-		return big.NewInt(1_000_000_000), nil
+	fracPart += strings.Repeat("0", t.Decimals-len(fracPart))
+
+	digits := strings.TrimLeft(intPart+fracPart, "0")
+	if digits == "" {
+		return new(big.Int), nil
+	}
+	amount, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, amountStr)
+	}
+	if amount.Cmp(maxUint256) > 0 {
+		return nil, fmt.Errorf("%w: %q", ErrAmountOverflow, amountStr)
+	}
+	return amount, nil
+}
+
+// FormatAmount converts base units back to a decimal string (e.g., 1500000 → "1.5" for 6 decimals).
+// Trailing fractional zeros are dropped; it is the inverse of ParseAmount.
+func (t *Token) FormatAmount(amount *big.Int) string {
+	if amount == nil {
+		return "0"
+	}
+	sign := ""
+	digits := new(big.Int).Abs(amount).String()
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	if t.Decimals <= 0 {
+		return sign + digits
+	}
+	if len(digits) <= t.Decimals {
+		digits = strings.Repeat("0", t.Decimals-len(digits)+1) + digits
+	}
+	intPart := digits[:len(digits)-t.Decimals]
+	fracPart := strings.TrimRight(digits[len(digits)-t.Decimals:], "0")
+	if fracPart == "" {
+		return sign + intPart
+	}
+	return sign + intPart + "." + fracPart
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
 	}
-	return big.NewInt(1), nil
+	return true
 }
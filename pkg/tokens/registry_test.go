@@ -0,0 +1,102 @@
+// registry_test.go
+package tokens
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken_ParseAmount(t *testing.T) {
+	tests := []struct {
+		token *Token
+		in    string
+		want  string
+	}{
+		{USDC_Sepolia, "1.000000", "1000000"},
+		{USDC_Sepolia, "1.5", "1500000"},
+		{USDC_Sepolia, ".25", "250000"},
+		{USDC_Sepolia, "0", "0"},
+		{USDC_Sepolia, "1.1000000000", "1100000"}, // trailing zeros beyond precision are exact
+		{ETH_Sepolia, "1", "1000000000000000000"},
+		{ETH_Sepolia, "0.000000000000000001", "1"},
+		{ETH_Sepolia, "123456789012345678901234567890.123456789012345678", "123456789012345678901234567890123456789012345678"},
+		{BTC_Testnet, "0.00000001", "1"},
+		{SOL_Devnet, "2.5", "2500000000"},
+	}
+	for _, tt := range tests {
+		got, err := tt.token.ParseAmount(tt.in)
+		if assert.NoError(t, err, tt.in) {
+			assert.Equal(t, tt.want, got.String(), tt.in)
+		}
+	}
+}
+
+func TestToken_ParseAmount_Errors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want error
+	}{
+		{"", ErrInvalidAmount},
+		{".", ErrInvalidAmount},
+		{"1.", ErrInvalidAmount},
+		{"1e6", ErrInvalidAmount},
+		{"1,5", ErrInvalidAmount},
+		{"1.2.3", ErrInvalidAmount},
+		{"-1", ErrNegativeAmount},
+		{"1.0000001", ErrTooManyDecimals},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129.639936", ErrAmountOverflow},
+	}
+	for _, tt := range tests {
+		_, err := USDC_Sepolia.ParseAmount(tt.in)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("ParseAmount(%q) error = %v, want %v", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestToken_FormatAmount(t *testing.T) {
+	assert.Equal(t, "1.5", USDC_Sepolia.FormatAmount(big.NewInt(1_500_000)))
+	assert.Equal(t, "0.000001", USDC_Sepolia.FormatAmount(big.NewInt(1)))
+	assert.Equal(t, "0", USDC_Sepolia.FormatAmount(big.NewInt(0)))
+	assert.Equal(t, "42", USDC_Sepolia.FormatAmount(big.NewInt(42_000_000)))
+	assert.Equal(t, "-0.5", USDC_Sepolia.FormatAmount(big.NewInt(-500_000)))
+
+	// Round trip
+	for _, s := range []string{"0.1", "1", "98765.4321", "0.000000000000000001"} {
+		v, err := ETH_Sepolia.ParseAmount(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s, ETH_Sepolia.FormatAmount(v))
+	}
+}
+
+func TestNativeToken(t *testing.T) {
+	token, ok := NativeToken("bitcoin-testnet")
+	assert.True(t, ok)
+	assert.Equal(t, "BTC", token.Symbol)
+
+	token, ok = NativeToken("bitcoin-regtest")
+	assert.True(t, ok)
+	assert.Equal(t, BTC_Regtest, token)
+
+	token, ok = NativeToken("avalanche-fuji")
+	assert.True(t, ok)
+	assert.Equal(t, "AVAX", token.Symbol)
+
+	// SPL tokens have no EVM contract but are not native
+	token, ok = NativeToken("solana-devnet")
+	assert.True(t, ok)
+	assert.Equal(t, "SOL", token.Symbol)
+	assert.False(t, USDC_SolanaDevnet.IsNative())
+
+	_, ok = NativeToken("unknown")
+	assert.False(t, ok)
+}
+
+func TestSolanaMint(t *testing.T) {
+	const devnetUSDC = "4zMMC9srt5Ri5X14GAgGqhPgJ6Hdw84Yjy818YxdYkG9"
+	assert.Equal(t, devnetUSDC, USDC_SolanaDevnet.Mint.String())
+	assert.Panics(t, func() { MustSolanaMint("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238") })
+}